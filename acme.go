@@ -0,0 +1,111 @@
+package libdnsrage4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ACMEDelegation manages ACME DNS-01 challenges for domains whose
+// _acme-challenge name is a CNAME into a zone hosted in Rage4, the
+// pattern popularized by acme-dns. Customers configure the CNAME once;
+// certificates can then be issued without access to their DNS.
+type ACMEDelegation struct {
+	// Provider manages the records of Zone
+	Provider *Provider
+
+	// Zone is the zone the challenges are delegated into
+	Zone string
+
+	// TTL of the challenge records; it defaults to one minute
+	TTL time.Duration
+}
+
+// acmeChallengeLabel is the label ACME servers query for DNS-01
+const acmeChallengeLabel = "_acme-challenge"
+
+// ChallengeName returns the name the customer has to point at Target,
+// without a trailing dot.
+func (d *ACMEDelegation) ChallengeName(domain string) string {
+	return acmeChallengeLabel + "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// Target returns the fully-qualified CNAME target for domain, without a
+// trailing dot. It is derived from the domain name, so it is stable and
+// different domains never share a target.
+func (d *ACMEDelegation) Target(domain string) string {
+	return d.label(domain) + "." + strings.TrimSuffix(d.Zone, ".")
+}
+
+// Instructions returns what the customer has to configure at their DNS
+// provider to delegate challenges for domain.
+func (d *ACMEDelegation) Instructions(domain string) string {
+	return fmt.Sprintf("Add a CNAME record for %s pointing to %s", d.ChallengeName(domain), d.Target(domain))
+}
+
+// CheckDelegation reports whether the CNAME for domain is in place.
+// lookup returns the canonical name of a name; it defaults to a lookup
+// with the Resolver of Provider. A name that does not exist is not an
+// error.
+func (d *ACMEDelegation) CheckDelegation(ctx context.Context, domain string, lookup func(ctx context.Context, name string) (string, error)) (bool, error) {
+	if lookup == nil {
+		lookup = d.Provider.resolver().LookupCNAME
+	}
+
+	cname, err := lookup(ctx, d.ChallengeName(domain)+".")
+	if dnsErr := (*net.DNSError)(nil); errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %w", d.ChallengeName(domain), err)
+	}
+
+	return strings.EqualFold(strings.TrimSuffix(cname, "."), d.Target(domain)), nil
+}
+
+// Present publishes a DNS-01 key authorization digest for domain at its
+// target. Several values may be present at once, as when a wildcard and
+// the apex are validated together.
+func (d *ACMEDelegation) Present(ctx context.Context, domain, value string) error {
+	_, err := d.Provider.AppendRecords(ctx, d.Zone, []libdns.Record{d.record(domain, value)})
+	if err != nil {
+		return fmt.Errorf("failed to present challenge for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// CleanUp removes a value published by Present. Removing a value that
+// is not present is not an error.
+func (d *ACMEDelegation) CleanUp(ctx context.Context, domain, value string) error {
+	name := d.label(domain)
+	_, err := d.Provider.DeleteMatching(ctx, d.Zone, MatchFunc(func(r libdns.Record) bool {
+		txt, ok := r.(libdns.TXT)
+		return ok && strings.EqualFold(txt.Name, name) && txt.Text == value
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to clean up challenge for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// record returns the challenge record for domain, relative to Zone
+func (d *ACMEDelegation) record(domain, value string) libdns.Record {
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return libdns.TXT{Name: d.label(domain), TTL: ttl, Text: value}
+}
+
+// label returns the label of the challenge target for domain
+func (d *ACMEDelegation) label(domain string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSuffix(domain, "."))))
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:15]))
+}
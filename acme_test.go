@@ -0,0 +1,62 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestACMEDelegationTarget(t *testing.T) {
+	d := &ACMEDelegation{Zone: "acme.example.net."}
+
+	target := d.Target("Customer.com.")
+	if target != d.Target("customer.com") || !strings.HasSuffix(target, ".acme.example.net") {
+		t.Errorf("unexpected target %q", target)
+	}
+	if target == d.Target("www.customer.com") {
+		t.Error("expected different domains to have different targets")
+	}
+	if d.ChallengeName("customer.com.") != "_acme-challenge.customer.com" {
+		t.Errorf("unexpected challenge name %q", d.ChallengeName("customer.com."))
+	}
+
+	lookup := func(ctx context.Context, name string) (string, error) {
+		if name == "_acme-challenge.customer.com." {
+			return target + ".", nil
+		}
+		return "", &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	if ok, err := d.CheckDelegation(context.Background(), "customer.com", lookup); !ok || err != nil {
+		t.Errorf("expected delegation to be detected, got %v, %v", ok, err)
+	}
+	if ok, err := d.CheckDelegation(context.Background(), "other.com", lookup); ok || err != nil {
+		t.Errorf("expected missing delegation, got %v, %v", ok, err)
+	}
+}
+
+func TestACMEDelegationPresentAndCleanUp(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "acme.example.net.")
+	d := &ACMEDelegation{Provider: p, Zone: "acme.example.net."}
+
+	for _, value := range []string{"apex-digest", "wildcard-digest"} {
+		if err := d.Present(ctx, "customer.com", value); err != nil {
+			t.Fatalf("Present failed: %v", err)
+		}
+	}
+	records := f.domainRecords(1)
+	if len(records) != 2 || records[0].Name != d.Target("customer.com") || records[0].TTL != 60 {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	if err := d.CleanUp(ctx, "customer.com", "apex-digest"); err != nil {
+		t.Fatalf("CleanUp failed: %v", err)
+	}
+	if err := d.CleanUp(ctx, "customer.com", "apex-digest"); err != nil {
+		t.Errorf("expected repeated clean up to succeed: %v", err)
+	}
+	if records := f.domainRecords(1); len(records) != 1 || records[0].Content != "wildcard-digest" {
+		t.Errorf("unexpected records after clean up: %+v", records)
+	}
+}
@@ -0,0 +1,320 @@
+// Package acmedns implements the HTTP API of acme-dns on top of a libdns
+// provider. Each host is provisioned its own subdomain of a dedicated
+// zone and its own credentials, which only allow updating the TXT
+// records of that subdomain. Hosts delegate their ACME challenges to it
+// with a CNAME record, so a compromised host cannot touch anything else
+// in the Rage4 account.
+//
+// The API is compatible with existing acme-dns clients:
+//
+//	POST /register   provision a new account
+//	POST /update     set the TXT record of an account's subdomain
+//	GET  /health     liveness check
+package acmedns
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrAccountNotFound is returned by an AccountStore for unknown users.
+var ErrAccountNotFound = errors.New("account not found")
+
+// Provider is the set of libdns operations used by the handler.
+// *libdnsrage4.Provider satisfies this interface.
+type Provider interface {
+	libdns.RecordSetter
+}
+
+// Account is a provisioned host.
+type Account struct {
+	Username     string         `json:"username"`
+	PasswordHash string         `json:"password_hash"`
+	Subdomain    string         `json:"subdomain"`
+	AllowFrom    []netip.Prefix `json:"allowfrom,omitempty"`
+
+	// TXT holds the most recent challenge values, newest last
+	TXT []string `json:"txt,omitempty"`
+}
+
+// AccountStore persists accounts. Implementations must be safe for
+// concurrent use.
+type AccountStore interface {
+	// Get returns the account of username, or ErrAccountNotFound.
+	Get(ctx context.Context, username string) (Account, error)
+
+	// Put creates or replaces an account.
+	Put(ctx context.Context, account Account) error
+}
+
+// MemoryAccountStore is an AccountStore that keeps accounts in memory.
+type MemoryAccountStore struct {
+	mu       sync.Mutex
+	accounts map[string]Account
+}
+
+// Get implements AccountStore.
+func (m *MemoryAccountStore) Get(ctx context.Context, username string) (Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.accounts[username]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// Put implements AccountStore.
+func (m *MemoryAccountStore) Put(ctx context.Context, account Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.accounts == nil {
+		m.accounts = make(map[string]Account)
+	}
+	m.accounts[account.Username] = account
+	return nil
+}
+
+// Credentials are returned once when an account is registered.
+type Credentials struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	FullDomain string   `json:"fulldomain"`
+	Subdomain  string   `json:"subdomain"`
+	AllowFrom  []string `json:"allowfrom"`
+}
+
+// Handler serves the acme-dns API.
+type Handler struct {
+	// Provider writes the TXT records
+	Provider Provider
+
+	// Zone is the zone holding the per-host subdomains, for example
+	// "acme.example.com."
+	Zone string
+
+	// Accounts stores the provisioned accounts
+	Accounts AccountStore
+
+	// DisableRegistration rejects POST /register, so that accounts can
+	// only be provisioned with Register
+	DisableRegistration bool
+
+	// TTL is the TTL of the TXT records; it defaults to 60 seconds
+	TTL time.Duration
+
+	mu sync.Mutex // serializes updates of the same account
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/register" && r.Method == http.MethodPost && !h.DisableRegistration:
+		h.serveRegister(w, r)
+	case r.URL.Path == "/update" && r.Method == http.MethodPost:
+		h.serveUpdate(w, r)
+	case r.URL.Path == "/health" && r.Method == http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Register provisions a new account. allowFrom optionally restricts the
+// networks updates are accepted from.
+func (h *Handler) Register(ctx context.Context, allowFrom []netip.Prefix) (*Credentials, error) {
+	username, err := randomUUID()
+	if err != nil {
+		return nil, err
+	}
+	subdomain, err := randomUUID()
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 30)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	password := base64.RawURLEncoding.EncodeToString(secret)
+
+	account := Account{
+		Username:     username,
+		PasswordHash: hashPassword(password),
+		Subdomain:    subdomain,
+		AllowFrom:    allowFrom,
+	}
+	if err := h.Accounts.Put(ctx, account); err != nil {
+		return nil, fmt.Errorf("failed to store account: %w", err)
+	}
+
+	creds := &Credentials{
+		Username:   username,
+		Password:   password,
+		FullDomain: subdomain + "." + strings.TrimSuffix(h.Zone, "."),
+		Subdomain:  subdomain,
+		AllowFrom:  []string{},
+	}
+	for _, prefix := range allowFrom {
+		creds.AllowFrom = append(creds.AllowFrom, prefix.String())
+	}
+	return creds, nil
+}
+
+// Update sets the TXT record of the account's subdomain to value,
+// keeping the previous value as well so that validations of two
+// names (e.g. a wildcard and the base domain) can be in flight at once.
+func (h *Handler) Update(ctx context.Context, username, value string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	account, err := h.Accounts.Get(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	account.TXT = append(account.TXT, value)
+	if len(account.TXT) > 2 {
+		account.TXT = account.TXT[len(account.TXT)-2:]
+	}
+
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = 60 * time.Second
+	}
+	var records []libdns.Record
+	for _, txt := range account.TXT {
+		records = append(records, libdns.TXT{Name: account.Subdomain, TTL: ttl, Text: txt})
+	}
+
+	if _, err := h.Provider.SetRecords(ctx, h.Zone, records); err != nil {
+		return fmt.Errorf("failed to set TXT records: %w", err)
+	}
+
+	return h.Accounts.Put(ctx, account)
+}
+
+func (h *Handler) serveRegister(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AllowFrom []string `json:"allowfrom"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "malformed_json_payload"})
+			return
+		}
+	}
+
+	var allowFrom []netip.Prefix
+	for _, s := range req.AllowFrom {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_allowfrom_cidr"})
+			return
+		}
+		allowFrom = append(allowFrom, prefix)
+	}
+
+	creds, err := h.Register(r.Context(), allowFrom)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db_error"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, creds)
+}
+
+func (h *Handler) serveUpdate(w http.ResponseWriter, r *http.Request) {
+	account, err := h.Accounts.Get(r.Context(), r.Header.Get("X-Api-User"))
+	if err != nil || !checkPassword(account.PasswordHash, r.Header.Get("X-Api-Key")) || !allowedFrom(account, r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "forbidden"})
+		return
+	}
+
+	var req struct {
+		Subdomain string `json:"subdomain"`
+		TXT       string `json:"txt"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "malformed_json_payload"})
+		return
+	}
+	if req.Subdomain != account.Subdomain {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "forbidden"})
+		return
+	}
+	// ACME challenge values are base64url-encoded SHA-256 digests
+	if len(req.TXT) != 43 || strings.Trim(req.TXT, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad_txt"})
+		return
+	}
+
+	if err := h.Update(r.Context(), account.Username, req.TXT); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "db_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"txt": req.TXT})
+}
+
+// allowedFrom reports whether the request comes from a network the
+// account may update from
+func allowedFrom(account Account, r *http.Request) bool {
+	if len(account.AllowFrom) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range account.AllowFrom {
+		if prefix.Contains(ip.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashPassword hashes a generated password. Passwords carry 240 bits of
+// entropy, so a plain digest is sufficient.
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func checkPassword(hash, password string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashPassword(password))) == 1
+}
+
+// randomUUID returns a random version 4 UUID
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
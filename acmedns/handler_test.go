@@ -0,0 +1,136 @@
+package acmedns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r6c/rage4/internal/memprovider"
+)
+
+// txtValues returns the data of the records of the ACME zone
+func txtValues(p *memprovider.Provider) []string {
+	var values []string
+	for _, r := range p.Records("acme.example.com.") {
+		values = append(values, r.RR().Data)
+	}
+	return values
+}
+
+func do(t *testing.T, h http.Handler, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegisterAndUpdate(t *testing.T) {
+	p := &memprovider.Provider{}
+	h := &Handler{Provider: p, Zone: "acme.example.com.", Accounts: &MemoryAccountStore{}}
+
+	rec := do(t, h, http.MethodPost, "/register", nil, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register failed: %d %s", rec.Code, rec.Body)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(rec.Body.Bytes(), &creds); err != nil {
+		t.Fatal(err)
+	}
+	if creds.FullDomain != creds.Subdomain+".acme.example.com" {
+		t.Errorf("unexpected fulldomain: %q", creds.FullDomain)
+	}
+
+	auth := map[string]string{"X-Api-User": creds.Username, "X-Api-Key": creds.Password}
+	values := []string{
+		strings.Repeat("a", 43),
+		strings.Repeat("b", 43),
+		strings.Repeat("c", 43),
+	}
+	for _, v := range values {
+		rec = do(t, h, http.MethodPost, "/update", auth, `{"subdomain":"`+creds.Subdomain+`","txt":"`+v+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update failed: %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	got := txtValues(p)
+	if len(got) != 2 || got[0] != values[1] || got[1] != values[2] {
+		t.Errorf("expected the two most recent values, got %v", got)
+	}
+	if r := p.Records("acme.example.com.")[0].RR(); r.Name != creds.Subdomain || r.Type != "TXT" {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
+
+func TestUpdateRejects(t *testing.T) {
+	p := &memprovider.Provider{}
+	h := &Handler{Provider: p, Zone: "acme.example.com.", Accounts: &MemoryAccountStore{}}
+
+	creds, err := h.Register(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := h.Register(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txt := strings.Repeat("a", 43)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		code    int
+	}{
+		{"wrong password", map[string]string{"X-Api-User": creds.Username, "X-Api-Key": "wrong"}, `{"subdomain":"` + creds.Subdomain + `","txt":"` + txt + `"}`, http.StatusUnauthorized},
+		{"unknown user", map[string]string{"X-Api-User": "nobody", "X-Api-Key": creds.Password}, `{"subdomain":"` + creds.Subdomain + `","txt":"` + txt + `"}`, http.StatusUnauthorized},
+		{"foreign subdomain", map[string]string{"X-Api-User": creds.Username, "X-Api-Key": creds.Password}, `{"subdomain":"` + other.Subdomain + `","txt":"` + txt + `"}`, http.StatusUnauthorized},
+		{"bad txt", map[string]string{"X-Api-User": creds.Username, "X-Api-Key": creds.Password}, `{"subdomain":"` + creds.Subdomain + `","txt":"short"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(t, h, http.MethodPost, "/update", tt.headers, tt.body)
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d %s", tt.code, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	if records := p.Records("acme.example.com."); len(records) != 0 {
+		t.Errorf("rejected updates must not change the zone: %+v", records)
+	}
+}
+
+func TestAllowFrom(t *testing.T) {
+	p := &memprovider.Provider{}
+	h := &Handler{Provider: p, Zone: "acme.example.com.", Accounts: &MemoryAccountStore{}, DisableRegistration: true}
+
+	if rec := do(t, h, http.MethodPost, "/register", nil, `{"allowfrom":["192.0.2.0/24"]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected registration to be disabled, got %d", rec.Code)
+	}
+
+	h.DisableRegistration = false
+	rec := do(t, h, http.MethodPost, "/register", nil, `{"allowfrom":["198.51.100.0/24"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register failed: %d %s", rec.Code, rec.Body)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(rec.Body.Bytes(), &creds); err != nil {
+		t.Fatal(err)
+	}
+
+	// httptest requests originate from 192.0.2.1
+	auth := map[string]string{"X-Api-User": creds.Username, "X-Api-Key": creds.Password}
+	body := `{"subdomain":"` + creds.Subdomain + `","txt":"` + strings.Repeat("a", 43) + `"}`
+	if rec := do(t, h, http.MethodPost, "/update", auth, body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected update from outside allowfrom to be rejected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ImportFromAXFR transfers zone from primary (a host, optionally with a
+// port) using AXFR and imports the supported records into the Rage4 zone
+// of the same name. The returned ImportResult reports every record that
+// was skipped during conversion, alongside the change set that was
+// applied.
+func (p *Provider) ImportFromAXFR(ctx context.Context, zone, primary string) (*ImportResult, *ChangeSet, error) {
+	result, err := transferZone(ctx, zone, primary)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cs, err := p.Import(ctx, zone, result)
+	if err != nil {
+		return result, nil, err
+	}
+
+	return result, cs, nil
+}
+
+// transferZone performs an AXFR of zone from primary and converts the
+// received records
+func transferZone(ctx context.Context, zone, primary string) (*ImportResult, error) {
+	if _, _, err := net.SplitHostPort(primary); err != nil {
+		primary = net.JoinHostPort(primary, "53")
+	}
+
+	origin := dns.Fqdn(zone)
+	msg := new(dns.Msg)
+	msg.SetAxfr(origin)
+
+	tr := &dns.Transfer{}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		tr.DialTimeout = timeout
+		tr.ReadTimeout = timeout
+	}
+
+	envelopes, err := tr.In(msg, primary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start zone transfer: %w", err)
+	}
+
+	// Closing the connection unblocks the reader, which then reports an
+	// error as its final envelope
+	stop := context.AfterFunc(ctx, func() { tr.Close() })
+	defer stop()
+
+	result := &ImportResult{}
+	for env := range envelopes {
+		if env.Error != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("zone transfer failed: %w", env.Error)
+		}
+		for _, rr := range env.RR {
+			result.add(rr, origin)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/libdns/libdns"
+)
+
+// startAXFRServer serves the given zone file contents over TCP and
+// returns the listening address
+func startAXFRServer(t *testing.T, zone string, records []string) string {
+	t.Helper()
+
+	var rrs []dns.RR
+	for _, line := range records {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("invalid test record %q: %v", line, err)
+		}
+		rrs = append(rrs, rr)
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc(zone, func(w dns.ResponseWriter, req *dns.Msg) {
+		ch := make(chan *dns.Envelope)
+		tr := new(dns.Transfer)
+		go func() {
+			ch <- &dns.Envelope{RR: rrs}
+			close(ch)
+		}()
+		tr.Out(w, req, ch)
+		w.Hijack()
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &dns.Server{Listener: ln, Handler: mux}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return ln.Addr().String()
+}
+
+func TestTransferZone(t *testing.T) {
+	soa := "example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 7200 900 1209600 300"
+	addr := startAXFRServer(t, "example.com.", []string{
+		soa,
+		"example.com. 3600 IN NS ns1.example.com.",
+		"www.example.com. 300 IN A 192.0.2.1",
+		"example.com. 300 IN MX 10 mail.example.com.",
+		"key.example.com. 300 IN DNSKEY 257 3 13 kXKkvWU3vGYfTJGl3qBd4qhiWp5aRs7YtkCJxD2d+t7KXqwahww5IgJtxJT2yFItlggazyfXqJEVOmMJ3qT0tQ==",
+		soa,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := transferZone(ctx, "example.com", addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Records) != 2 {
+		t.Fatalf("expected 2 records, got %d: %+v", len(result.Records), result.Records)
+	}
+	if result.Records[0].RR().Name != "www" || result.Records[1].(libdns.MX).Preference != 10 {
+		t.Errorf("unexpected records: %+v", result.Records)
+	}
+
+	// both SOAs, the apex NS and the DNSKEY are reported
+	if len(result.Skipped) != 4 {
+		t.Errorf("expected 4 skipped records, got %d: %+v", len(result.Skipped), result.Skipped)
+	}
+}
+
+func TestTransferZoneRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &dns.Server{Listener: ln, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(m)
+	})}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := transferZone(ctx, "example.com.", ln.Addr().String()); err == nil {
+		t.Fatal("expected error for refused transfer")
+	}
+}
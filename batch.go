@@ -0,0 +1,98 @@
+package libdnsrage4
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// batchWorkers returns the number of records AppendRecords and
+// DeleteRecords may create or delete at once
+func (p *Provider) batchWorkers() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return max(1, p.BatchConcurrency)
+}
+
+// batch calls fn for the indexes 0 to n-1, running up to workers calls
+// at once, and returns their results and errors by index
+func batch[T any](workers, n int, fn func(i int) (T, error)) ([]T, []error) {
+	results := make([]T, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for range min(workers, n) {
+		wg.Go(func() {
+			for i := range queue {
+				results[i], errs[i] = fn(i)
+			}
+		})
+	}
+	for i := range n {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return results, errs
+}
+
+// BatchError reports the records of an AppendRecords or DeleteRecords
+// call that were not processed. The records that were are returned along
+// with it, as the changes they stand for have been made.
+type BatchError struct {
+	// Failed are the records that failed, in input order
+	Failed []*RecordError
+
+	// NotAttempted are the records after a failed one, which are not
+	// tried with a BatchConcurrency of one; with more, every record is
+	// tried
+	NotAttempted []libdns.Record
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("%d record(s) failed", len(e.Failed))
+	if len(e.NotAttempted) > 0 {
+		msg += fmt.Sprintf(", %d not attempted", len(e.NotAttempted))
+	}
+	for _, failed := range e.Failed {
+		msg += "; " + failed.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the errors of the failed records.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, failed := range e.Failed {
+		errs[i] = failed
+	}
+	return errs
+}
+
+// batchError returns the error for the failed records, or nil if none
+// failed
+func batchError(failed []*RecordError, notAttempted []libdns.Record) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: failed, NotAttempted: notAttempted}
+}
+
+// RecordError is the error of one record of a batch.
+type RecordError struct {
+	Record libdns.Record
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	rr := e.Record.RR()
+	return fmt.Sprintf("%s %s %q: %v", rr.Name, rr.Type, rr.Data, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
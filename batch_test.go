@@ -0,0 +1,116 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// slowRage4 delays every request to a fake API, tracking the most
+// requests in flight at once, and rejects records named "bad"
+type slowRage4 struct {
+	http.Handler
+	inflight, peak atomic.Int32
+}
+
+func (s *slowRage4) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	for peak := s.peak.Load(); n > peak && !s.peak.CompareAndSwap(peak, n); peak = s.peak.Load() {
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if strings.HasPrefix(r.FormValue("name"), "bad.") {
+		writeFakeJSON(w, CommonResponse{Error: "invalid record"})
+		return
+	}
+	s.Handler.ServeHTTP(w, r)
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newFakeRage4(t, "example.com.")
+	handler := &slowRage4{Handler: f}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi", BatchConcurrency: 4}
+
+	var records []libdns.Record
+	for i := range 12 {
+		records = append(records, libdns.RR{Name: fmt.Sprintf("host%d", i), Type: "A", Data: "192.0.2.1"})
+	}
+	records = append(records, libdns.RR{Name: "bad", Type: "A", Data: "192.0.2.1"})
+
+	created, err := p.AppendRecords(ctx, "example.com.", records)
+	if err == nil || !strings.Contains(err.Error(), `bad A "192.0.2.1": failed to create record`) {
+		t.Errorf("expected the failed record to be reported, got %v", err)
+	}
+	if len(created) != 12 || created[0].RR().Name != "host0" || created[11].RR().Name != "host11" {
+		t.Fatalf("expected the created records in order, got %+v", created)
+	}
+	if peak := handler.peak.Load(); peak < 2 || peak > 4 {
+		t.Errorf("expected up to 4 requests at once, got %d", peak)
+	}
+
+	stale := libdns.Address{Name: "gone", ProviderData: Rage4Record{ID: 999}}
+	deleted, err := p.DeleteRecords(ctx, "example.com.", append(created, stale))
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected the stale record to be reported, got %v", err)
+	}
+	if len(deleted) != 12 || len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the other records to be deleted, got %d deleted and %+v left", len(deleted), f.domainRecords(1))
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newFakeRage4(t, "example.com.")
+	srv := httptest.NewServer(&slowRage4{Handler: f})
+	t.Cleanup(srv.Close)
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi"}
+
+	records := []libdns.Record{
+		libdns.RR{Name: "one", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "bad", Type: "A", Data: "192.0.2.2"},
+		libdns.RR{Name: "two", Type: "A", Data: "192.0.2.3"},
+	}
+	created, err := p.AppendRecords(ctx, "example.com.", records)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a *BatchError, got %v", err)
+	}
+	if len(created) != 1 || created[0].RR().Name != "one" || recordID(created[0]) == 0 {
+		t.Errorf("expected the record added before the failure, got %+v", created)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed[0].Record.RR().Name != "bad" {
+		t.Errorf("expected the bad record to fail, got %+v", batchErr.Failed)
+	}
+	if len(batchErr.NotAttempted) != 1 || batchErr.NotAttempted[0].RR().Name != "two" {
+		t.Errorf("expected the last record not to be attempted, got %+v", batchErr.NotAttempted)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("expected the API error to be wrapped, got %v", err)
+	}
+	if want := `1 record(s) failed, 1 not attempted; bad A "192.0.2.2": failed to create record`; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("expected %q, got %q", want, err)
+	}
+
+	stale := libdns.Address{Name: "gone", ProviderData: Rage4Record{ID: 999}}
+	deleted, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{created[0], stale})
+	if !errors.As(err, &batchErr) || !errors.Is(err, ErrRecordNotFound) || batchErr.Failed[0].Record.RR().Name != "gone" {
+		t.Errorf("expected the stale record to fail, got %v", err)
+	}
+	if len(deleted) != 1 || len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the first record to be deleted, got %+v", deleted)
+	}
+}
@@ -0,0 +1,63 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrBudgetExceeded is returned when an operation needs more API
+// requests than its budget allows.
+var ErrBudgetExceeded = errors.New("request budget exceeded")
+
+type budgetKey struct{}
+
+// WithRequestBudget returns a context that caps the number of API
+// requests a single provider operation started with it may issue,
+// overriding Provider.MaxRequestsPerOperation. For example:
+//
+//	p.SetRecords(libdnsrage4.WithRequestBudget(ctx, 50), zone, records)
+func WithRequestBudget(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, budgetKey{}, n)
+}
+
+// requestBudget returns the budget set with WithRequestBudget, or zero
+func requestBudget(ctx context.Context) int {
+	n, _ := ctx.Value(budgetKey{}).(int)
+	return n
+}
+
+// charge records an API request made on behalf of the operation and
+// fails once the budget is spent. The error lists the requests made so
+// far, which usually makes the N+1 pattern responsible obvious.
+func (op *operation) charge(method string) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if op.budget > 0 && len(op.requests) >= op.budget {
+		return fmt.Errorf("%w: %s needs more than %d API requests (%s)",
+			ErrBudgetExceeded, op.name, op.budget, summarizeRequests(op.requests))
+	}
+	op.requests = append(op.requests, method)
+	return nil
+}
+
+// summarizeRequests renders request counts per API method in order of
+// first use, e.g. "GetDomains×1, DeleteRecord×49"
+func summarizeRequests(methods []string) string {
+	var order []string
+	counts := make(map[string]int)
+	for _, m := range methods {
+		if counts[m] == 0 {
+			order = append(order, m)
+		}
+		counts[m]++
+	}
+
+	parts := make([]string, len(order))
+	for i, m := range order {
+		parts[i] = fmt.Sprintf("%s×%d", m, counts[m])
+	}
+	return strings.Join(parts, ", ")
+}
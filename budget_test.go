@@ -0,0 +1,47 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRequestBudget(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	for _, name := range []string{"a", "b", "c"} {
+		f.addRecord(1, Rage4Record{Name: name + ".example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	}
+
+	// deleting without IDs needs GetDomains and GetRecords to look the
+	// records up, then DeleteRecord per record
+	records := []libdns.Record{
+		libdns.RR{Name: "a", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "b", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "c", Type: "A", Data: "192.0.2.1"},
+	}
+
+	p.MaxRequestsPerOperation = 4
+	_, err := p.DeleteRecords(ctx, "example.com.", records)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "DeleteRecords needs more than 4 API requests (GetDomainByName×1, GetRecords×1, DeleteRecord×2)") {
+		t.Errorf("unexpected diagnostic: %v", err)
+	}
+
+	// the context overrides the provider-wide budget
+	if _, err := p.DeleteRecords(WithRequestBudget(ctx, 10), "example.com.", records[2:]); err != nil {
+		t.Errorf("expected the larger budget to suffice, got %v", err)
+	}
+
+	// every call is a separate operation with its own budget
+	for range 3 {
+		if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrCanaryRejected is returned by ApplyCanary when the canary changes
+// failed verification and were rolled back.
+var ErrCanaryRejected = errors.New("canary changes failed verification")
+
+// CanaryOptions controls ApplyCanary.
+type CanaryOptions struct {
+	// Fraction is the share of the record sets of the change set applied
+	// first, between 0 and 1; it defaults to 10%. At least one record
+	// set is applied first.
+	Fraction float64
+
+	// Verify is called once the canary changes are made, for example to
+	// run synthetic checks against the changed names. Returning an error
+	// rolls the canary changes back and leaves the rest unapplied.
+	Verify func(ctx context.Context, canary *ChangeSet) error
+}
+
+// ApplyCanary executes a change set in two steps to limit the blast
+// radius of risky bulk edits: it applies a fraction of its record sets,
+// waits for opts.Verify to pass, and only then applies the rest. If
+// verification fails, the canary changes are rolled back and an error
+// wrapping ErrCanaryRejected is returned, or a *RollbackError if
+// rolling back failed too. The Approver and the state check of Apply
+// run once, for the whole change set, before anything is changed.
+func (p *Provider) ApplyCanary(ctx context.Context, cs *ChangeSet, opts CanaryOptions) (err error) {
+	if opts.Verify == nil {
+		return fmt.Errorf("canary apply needs a Verify callback")
+	}
+	if opts.Fraction < 0 || opts.Fraction > 1 {
+		return fmt.Errorf("invalid canary fraction %v", opts.Fraction)
+	}
+	if cs == nil || cs.Empty() {
+		return nil
+	}
+
+	ctx, done, err := p.beginOp(ctx, "ApplyCanary", cs.Zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	if p.Approver != nil {
+		if err := p.Approver(ctx, cs); err != nil {
+			return fmt.Errorf("%w: %w", ErrNotApproved, err)
+		}
+	}
+	if err := p.verifyState(ctx, cs); err != nil {
+		return err
+	}
+
+	domainID, err := p.getDomainID(ctx, cs.Zone)
+	if err != nil {
+		return fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	rb := &setRollback{domainID: domainID, zoneName: strings.TrimSuffix(cs.Zone, ".")}
+
+	canary, rest := cs.split(opts.Fraction)
+	rb.deleted, rb.created, err = p.applyChanges(ctx, canary)
+	if err == nil {
+		if verifyErr := opts.Verify(ctx, canary); verifyErr != nil {
+			err = fmt.Errorf("%w: %w", ErrCanaryRejected, verifyErr)
+		}
+	}
+	if err != nil {
+		if rollbackErr := rb.undo(ctx, p); rollbackErr != nil {
+			return &RollbackError{Err: err, RollbackErr: rollbackErr}
+		}
+		return err
+	}
+
+	if rest.Empty() {
+		return nil
+	}
+	if _, _, err := p.applyChanges(ctx, rest); err != nil {
+		return fmt.Errorf("failed to apply after canary: %w", err)
+	}
+	return nil
+}
+
+// split divides a change set into the changes of the first fraction of
+// its record sets, in the order they appear, and the rest. The changes
+// of a record set always stay together.
+func (cs *ChangeSet) split(fraction float64) (canary, rest *ChangeSet) {
+	if fraction == 0 {
+		fraction = 0.1
+	}
+
+	var order []recordSetKey
+	seen := make(map[recordSetKey]bool)
+	for _, r := range append(append(Records{}, cs.Delete...), cs.Create...) {
+		if key := recordSetOf(r); !seen[key] {
+			seen[key] = true
+			order = append(order, key)
+		}
+	}
+	n := max(1, int(math.Ceil(fraction*float64(len(order)))))
+	first := make(map[recordSetKey]bool)
+	for _, key := range order[:min(n, len(order))] {
+		first[key] = true
+	}
+
+	canary, rest = &ChangeSet{Zone: cs.Zone}, &ChangeSet{Zone: cs.Zone}
+	for _, r := range cs.Delete {
+		if first[recordSetOf(r)] {
+			canary.Delete = append(canary.Delete, r)
+		} else {
+			rest.Delete = append(rest.Delete, r)
+		}
+	}
+	for _, r := range cs.Create {
+		if first[recordSetOf(r)] {
+			canary.Create = append(canary.Create, r)
+		} else {
+			rest.Create = append(rest.Create, r)
+		}
+	}
+	return canary, rest
+}
@@ -0,0 +1,80 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestApplyCanary(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	cs := &ChangeSet{Zone: "example.com.", Create: []libdns.Record{
+		libdns.RR{Name: "a", Type: "A", Data: "192.0.2.1", TTL: time.Hour},
+		libdns.RR{Name: "a", Type: "A", Data: "192.0.2.2", TTL: time.Hour},
+		libdns.RR{Name: "b", Type: "A", Data: "192.0.2.3", TTL: time.Hour},
+		libdns.RR{Name: "c", Type: "A", Data: "192.0.2.4", TTL: time.Hour},
+	}}
+
+	var canary *ChangeSet
+	opts := CanaryOptions{Fraction: 0.3, Verify: func(ctx context.Context, cs *ChangeSet) error {
+		canary = cs
+		if n := len(f.domainRecords(1)); n != 2 {
+			t.Errorf("expected only the canary to be applied before verification, got %d records", n)
+		}
+		return nil
+	}}
+	if err := p.ApplyCanary(ctx, cs, opts); err != nil {
+		t.Fatalf("ApplyCanary failed: %v", err)
+	}
+	// both records of the first record set go first
+	if canary == nil || len(canary.Create) != 2 || canary.Create[1].RR().Data != "192.0.2.2" {
+		t.Errorf("unexpected canary: %+v", canary)
+	}
+	if n := len(f.domainRecords(1)); n != 4 {
+		t.Errorf("expected all records to be created, got %d", n)
+	}
+}
+
+func TestApplyCanaryRejected(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "a.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	cs := &ChangeSet{
+		Zone:   "example.com.",
+		Delete: []libdns.Record{recordWithID(id, libdns.RR{Name: "a", Type: "A", Data: "192.0.2.1", TTL: time.Hour})},
+		Create: []libdns.Record{
+			libdns.RR{Name: "a", Type: "A", Data: "192.0.2.9", TTL: time.Hour},
+			libdns.RR{Name: "b", Type: "A", Data: "192.0.2.3", TTL: time.Hour},
+		},
+	}
+
+	failed := errors.New("synthetic check failed")
+	opts := CanaryOptions{Fraction: 0.5, Verify: func(ctx context.Context, cs *ChangeSet) error {
+		return failed
+	}}
+	err := p.ApplyCanary(ctx, cs, opts)
+	if !errors.Is(err, ErrCanaryRejected) || !errors.Is(err, failed) {
+		t.Fatalf("expected ErrCanaryRejected, got %v", err)
+	}
+
+	records := f.domainRecords(1)
+	if len(records) != 1 || records[0].Content != "192.0.2.1" {
+		t.Errorf("expected the canary to be rolled back and the rest not applied, got %+v", records)
+	}
+}
+
+func TestApplyCanaryOptions(t *testing.T) {
+	p := &Provider{}
+	cs := &ChangeSet{Zone: "example.com.", Create: []libdns.Record{libdns.RR{Name: "a", Type: "A", Data: "192.0.2.1"}}}
+	if err := p.ApplyCanary(context.Background(), cs, CanaryOptions{}); err == nil {
+		t.Error("expected an error without a Verify callback")
+	}
+	verify := func(context.Context, *ChangeSet) error { return nil }
+	if err := p.ApplyCanary(context.Background(), cs, CanaryOptions{Fraction: 1.5, Verify: verify}); err == nil {
+		t.Error("expected an error for a fraction above 1")
+	}
+}
@@ -0,0 +1,100 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// supportedRecordTypes are the record types the package converts between
+// Rage4 and libdns without a registered Converter
+var supportedRecordTypes = []string{
+	"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SPF", "SRV", "SSHFP", "TLSA", "TXT",
+}
+
+// Capabilities describes what the provider can do with the configured
+// account, so that orchestration layers can feature-detect instead of
+// failing at runtime.
+type Capabilities struct {
+	// RecordTypes are the record types that can be managed, including
+	// those handled by registered converters
+	RecordTypes []string `json:"record_types"`
+
+	// Geo reports whether geo-routed records can be managed: true
+	// unless Rage4 has rejected geo settings as not offered to the
+	// account
+	Geo bool `json:"geo"`
+
+	// Failover reports whether failover settings can be managed, in the
+	// same way as Geo
+	Failover bool `json:"failover"`
+
+	// DefaultTTL is the TTL used for records created without one
+	DefaultTTL time.Duration `json:"default_ttl"`
+
+	// MaxTTL is the largest TTL that can be set
+	MaxTTL time.Duration `json:"max_ttl"`
+
+	// MaxBatchSize is the number of records a single API request can
+	// create or delete; larger batches take one request per record
+	MaxBatchSize int `json:"max_batch_size"`
+
+	// MaxRequestsPerOperation is the configured request budget of a
+	// single operation, or zero if unlimited
+	MaxRequestsPerOperation int `json:"max_requests_per_operation,omitempty"`
+
+	// Zones are the zones in the account, with trailing dots
+	Zones []string `json:"zones"`
+}
+
+// SupportsRecordType reports whether records of the given type can be
+// managed.
+func (c *Capabilities) SupportsRecordType(recordType string) bool {
+	i := sort.SearchStrings(c.RecordTypes, recordType)
+	return i < len(c.RecordTypes) && c.RecordTypes[i] == recordType
+}
+
+// Capabilities reports the capabilities of the provider. It combines
+// what the package supports with live account data, so it also serves
+// as a check that the credentials work.
+func (p *Provider) Capabilities(ctx context.Context) (*Capabilities, error) {
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	p.mu.RLock()
+	budget := p.MaxRequestsPerOperation
+	p.mu.RUnlock()
+
+	caps := &Capabilities{
+		DefaultTTL:              defaultTTL,
+		MaxTTL:                  math.MaxInt32 * time.Second, // RFC 2181, section 8
+		MaxBatchSize:            1,
+		MaxRequestsPerOperation: budget,
+		Geo:                     !p.features.isUnavailable(FeatureGeo),
+		Failover:                !p.features.isUnavailable(FeatureFailover),
+	}
+
+	types := make(map[string]bool)
+	for _, t := range supportedRecordTypes {
+		types[t] = true
+	}
+	convertersMu.RLock()
+	for t := range converters {
+		types[t] = true
+	}
+	convertersMu.RUnlock()
+	for t := range types {
+		caps.RecordTypes = append(caps.RecordTypes, t)
+	}
+	sort.Strings(caps.RecordTypes)
+
+	for _, domain := range domains {
+		caps.Zones = append(caps.Zones, domain.Name+".")
+	}
+
+	return caps, nil
+}
@@ -0,0 +1,38 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCapabilities(t *testing.T) {
+	RegisterConverter("X-CAPS", Converter{
+		FromRage4: func(r Rage4Record, rr libdns.RR) (libdns.RR, error) { return rr, nil },
+	})
+
+	_, p := newFakeRage4(t, "example.com.", "example.net.")
+	p.MaxRequestsPerOperation = 50
+
+	caps, err := p.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+
+	for _, recordType := range []string{"A", "MX", "TXT", "X-CAPS"} {
+		if !caps.SupportsRecordType(recordType) {
+			t.Errorf("expected %s to be supported", recordType)
+		}
+	}
+	if caps.SupportsRecordType("HINFO") {
+		t.Error("expected HINFO to be unsupported")
+	}
+	if caps.DefaultTTL != time.Hour || caps.MaxBatchSize != 1 || caps.MaxRequestsPerOperation != 50 {
+		t.Errorf("unexpected limits: %+v", caps)
+	}
+	if len(caps.Zones) != 2 || caps.Zones[0] != "example.com." {
+		t.Errorf("unexpected zones: %v", caps.Zones)
+	}
+}
@@ -0,0 +1,211 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrNotApproved is returned by Apply when the configured Approver rejects
+// a change set.
+var ErrNotApproved = errors.New("change set not approved")
+
+// Approver is called with a computed change set before it is applied.
+// Returning a non-nil error aborts the apply without touching the zone.
+// Implementations may block (e.g. while waiting for a human to confirm
+// in chat) and should honor context cancellation.
+type Approver func(ctx context.Context, cs *ChangeSet) error
+
+// ChangeSet describes the record changes needed to bring a zone to a
+// desired state.
+type ChangeSet struct {
+	// Zone is the zone the changes apply to
+	Zone string `json:"zone"`
+
+	// Delete lists existing records that will be removed
+	Delete Records `json:"delete,omitempty"`
+
+	// Create lists records that will be added
+	Create Records `json:"create,omitempty"`
+
+	// Before and After are content hashes of the record sets the change
+	// set touches, as Plan found them and as the change set leaves them.
+	// Apply refuses change sets whose Before no longer matches the zone.
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Empty reports whether the change set contains no changes.
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.Delete) == 0 && len(cs.Create) == 0
+}
+
+// Plan computes the changes required to make the record sets named in
+// desired match it exactly. Only record sets (name and type pairs) that
+// appear in desired are considered; all other records in the zone are
+// left untouched, mirroring the semantics of SetRecords. Desired records
+// rejected by the configured Validators fail with a *ValidationError.
+func (p *Provider) Plan(ctx context.Context, zone string, desired []libdns.Record) (*ChangeSet, error) {
+	return p.plan(ctx, zone, desired, false)
+}
+
+// plan implements Plan; with prune, existing record sets missing from
+// desired are deleted as well, except those managed by Rage4
+func (p *Provider) plan(ctx context.Context, zone string, desired []libdns.Record, prune bool) (*ChangeSet, error) {
+	if err := p.Validate(ctx, zone, desired); err != nil {
+		return nil, err
+	}
+	desired, err := p.snapTTLs(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+
+	cs := diffRecords(existing, desired)
+	cs.Zone = zone
+	if prune {
+		managed := make(map[recordSetKey]bool)
+		for _, r := range desired {
+			managed[recordSetOf(r)] = true
+		}
+		for _, e := range existing {
+			if !managed[recordSetOf(e)] && !managedByRage4(e) {
+				cs.Delete = append(cs.Delete, e)
+			}
+		}
+	}
+	cs.seal(existing)
+	return cs, nil
+}
+
+// Apply executes a change set computed by Plan. If an Approver is
+// configured it is consulted first, and nothing is changed unless it
+// approves. Then, for change sets with a Before hash, the record sets
+// they touch are checked against it, and ErrStalePlan is returned if
+// they changed since. Deletions are applied before creations.
+func (p *Provider) Apply(ctx context.Context, cs *ChangeSet) (err error) {
+	if cs == nil || cs.Empty() {
+		return nil
+	}
+
+	ctx, done, err := p.beginOp(ctx, "Apply", cs.Zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	if p.Approver != nil {
+		if err := p.Approver(ctx, cs); err != nil {
+			return fmt.Errorf("%w: %w", ErrNotApproved, err)
+		}
+	}
+	if err := p.verifyState(ctx, cs); err != nil {
+		return err
+	}
+
+	_, _, err = p.applyChanges(ctx, cs)
+	return err
+}
+
+// applyChanges makes the changes of cs, deletions first, and returns
+// the records deleted and created, also when it fails halfway
+func (p *Provider) applyChanges(ctx context.Context, cs *ChangeSet) (deleted, created []libdns.Record, err error) {
+	if len(cs.Delete) > 0 {
+		if deleted, err = p.DeleteRecords(ctx, cs.Zone, cs.Delete); err != nil {
+			return deleted, nil, fmt.Errorf("failed to delete records: %w", err)
+		}
+	}
+
+	if len(cs.Create) > 0 {
+		if created, err = p.AppendRecords(ctx, cs.Zone, cs.Create); err != nil {
+			return deleted, created, fmt.Errorf("failed to create records: %w", err)
+		}
+	}
+
+	return deleted, created, nil
+}
+
+// recordSetKey identifies a record set by relative name and type
+type recordSetKey struct {
+	name string
+	typ  string
+}
+
+// normalizeName maps the different spellings of the zone apex to "@"
+func normalizeName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
+
+// recordSetOf returns the record set a record belongs to
+func recordSetOf(record libdns.Record) recordSetKey {
+	rr := record.RR()
+	return recordSetKey{normalizeName(rr.Name), rr.Type}
+}
+
+// sameRecordSet reports whether two records belong to the same record
+// set
+func sameRecordSet(a, b libdns.Record) bool {
+	return recordSetOf(a) == recordSetOf(b)
+}
+
+// sameRecord reports whether an existing record already satisfies a
+// desired one. A zero TTL in desired means "don't care".
+func sameRecord(existing, desired libdns.Record) bool {
+	e, d := existing.RR(), desired.RR()
+	if normalizeName(e.Name) != normalizeName(d.Name) || e.Type != d.Type || e.Data != d.Data {
+		return false
+	}
+	if d.TTL != 0 && e.TTL != d.TTL {
+		return false
+	}
+
+	// Rage4-specific settings only matter if desired sets them
+	have, _ := rage4Data(existing)
+	return settingsOf(have).equal(desiredSettings(existing, desired))
+}
+
+// diffRecords computes the change set that turns existing into desired
+// for every record set mentioned in desired
+func diffRecords(existing, desired []libdns.Record) *ChangeSet {
+	managed := make(map[recordSetKey]bool)
+	for _, r := range desired {
+		managed[recordSetOf(r)] = true
+	}
+
+	cs := &ChangeSet{}
+	matched := make([]bool, len(desired))
+	for _, e := range existing {
+		if !managed[recordSetOf(e)] {
+			continue
+		}
+
+		keep := false
+		for i, d := range desired {
+			if !matched[i] && sameRecord(e, d) {
+				matched[i] = true
+				keep = true
+				break
+			}
+		}
+		if !keep {
+			cs.Delete = append(cs.Delete, e)
+		}
+	}
+
+	for i, d := range desired {
+		if !matched[i] {
+			cs.Create = append(cs.Create, d)
+		}
+	}
+
+	return cs
+}
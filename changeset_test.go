@@ -0,0 +1,90 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestDiffRecords(t *testing.T) {
+	existing := []libdns.Record{
+		recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 3600 * time.Second}),
+		recordWithID(2, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: 3600 * time.Second}),
+		recordWithID(3, libdns.RR{Name: "@", Type: "MX", Data: "10 mail.example.com", TTL: 3600 * time.Second}),
+		recordWithID(4, libdns.RR{Name: "other", Type: "A", Data: "192.0.2.9", TTL: 3600 * time.Second}),
+	}
+	desired := []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3"},
+		libdns.RR{Name: "", Type: "MX", Data: "20 mail.example.com"},
+	}
+
+	cs := diffRecords(existing, desired)
+
+	if len(cs.Delete) != 2 {
+		t.Fatalf("expected 2 deletions, got %d: %+v", len(cs.Delete), cs.Delete)
+	}
+	if recordID(cs.Delete[0]) != 2 || recordID(cs.Delete[1]) != 3 {
+		t.Errorf("unexpected deletions: %+v", cs.Delete)
+	}
+
+	if len(cs.Create) != 2 {
+		t.Fatalf("expected 2 creations, got %d: %+v", len(cs.Create), cs.Create)
+	}
+	if cs.Create[0].RR().Data != "192.0.2.3" || cs.Create[1].RR().Data != "20 mail.example.com" {
+		t.Errorf("unexpected creations: %+v", cs.Create)
+	}
+}
+
+func TestDiffRecordsNoChanges(t *testing.T) {
+	existing := []libdns.Record{
+		recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 3600 * time.Second}),
+	}
+	desired := []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 3600 * time.Second},
+	}
+
+	if cs := diffRecords(existing, desired); !cs.Empty() {
+		t.Errorf("expected empty change set, got %+v", cs)
+	}
+}
+
+func TestApplyRejectedByApprover(t *testing.T) {
+	var seen *ChangeSet
+	reason := errors.New("denied in #dns-changes")
+	p := &Provider{
+		Approver: func(ctx context.Context, cs *ChangeSet) error {
+			seen = cs
+			return reason
+		},
+	}
+
+	cs := &ChangeSet{
+		Zone:   "example.com.",
+		Create: []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}},
+	}
+
+	err := p.Apply(context.Background(), cs)
+	if !errors.Is(err, ErrNotApproved) || !errors.Is(err, reason) {
+		t.Fatalf("expected ErrNotApproved wrapping approver error, got %v", err)
+	}
+	if seen != cs {
+		t.Error("approver was not given the change set")
+	}
+}
+
+func TestApplyEmptySkipsApprover(t *testing.T) {
+	p := &Provider{
+		Approver: func(ctx context.Context, cs *ChangeSet) error {
+			t.Error("approver should not be called for an empty change set")
+			return nil
+		},
+	}
+
+	if err := p.Apply(context.Background(), &ChangeSet{Zone: "example.com."}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
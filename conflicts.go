@@ -0,0 +1,176 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// ImportConflict is a record set of an import that already exists in
+// the zone with different content.
+type ImportConflict struct {
+	// Name and Type identify the record set, with Name relative to the
+	// zone
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Existing are the records in the zone, Imported those of the import
+	Existing Records `json:"existing"`
+	Imported Records `json:"imported"`
+
+	// Resolution is how the conflict was resolved; it is only set by
+	// ImportWithOptions
+	Resolution ImportResolution `json:"resolution"`
+}
+
+// ConflictAction is a way to resolve an ImportConflict.
+type ConflictAction int
+
+const (
+	// OverwriteExisting replaces the existing records with the imported
+	// ones. It is the default.
+	OverwriteExisting ConflictAction = iota
+
+	// KeepExisting leaves the existing records alone and drops the
+	// imported ones
+	KeepExisting
+
+	// RenameImported keeps the existing records and imports the
+	// imported ones under another name
+	RenameImported
+)
+
+func (a ConflictAction) String() string {
+	switch a {
+	case KeepExisting:
+		return "keep existing"
+	case RenameImported:
+		return "rename imported"
+	}
+	return "overwrite existing"
+}
+
+// MarshalText implements encoding.TextMarshaler, so that conflict
+// reports read well as JSON.
+func (a ConflictAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// ImportResolution is the resolution of an ImportConflict.
+type ImportResolution struct {
+	Action ConflictAction `json:"action"`
+
+	// Name is the name, relative to the zone, the imported records get
+	// with RenameImported
+	Name string `json:"name,omitempty"`
+}
+
+// ImportOptions controls ImportWithOptions.
+type ImportOptions struct {
+	// Resolve decides each conflict, for example by asking the user; an
+	// error aborts the import before anything is changed. If nil, every
+	// conflict is resolved with OverwriteExisting.
+	Resolve func(ctx context.Context, conflict ImportConflict) (ImportResolution, error)
+}
+
+// ImportConflicts returns the record sets of result that exist in zone
+// with different content, in the order they appear in result, without
+// changing anything.
+func (p *Provider) ImportConflicts(ctx context.Context, zone string, result *ImportResult) ([]ImportConflict, error) {
+	existing, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+	return importConflicts(existing, result.Records), nil
+}
+
+// ImportWithOptions is Import with each conflicting record set resolved
+// by opts.Resolve. The conflicts and their resolutions are recorded in
+// result.Conflicts.
+func (p *Provider) ImportWithOptions(ctx context.Context, zone string, result *ImportResult, opts ImportOptions) (*ChangeSet, error) {
+	ctx = background(ctx)
+
+	conflicts, err := p.ImportConflicts(ctx, zone, result)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[recordSetKey]ImportResolution)
+	for i, c := range conflicts {
+		if opts.Resolve != nil {
+			if c.Resolution, err = opts.Resolve(ctx, c); err != nil {
+				return nil, fmt.Errorf("failed to resolve conflict on %s %s: %w", c.Name, c.Type, err)
+			}
+		}
+		if c.Resolution.Action == RenameImported && c.Resolution.Name == "" {
+			return nil, fmt.Errorf("no name to rename %s %s to", c.Name, c.Type)
+		}
+		conflicts[i] = c
+		resolved[recordSetKey{normalizeName(c.Name), c.Type}] = c.Resolution
+	}
+	result.Conflicts = conflicts
+
+	var desired []libdns.Record
+	for _, r := range result.Records {
+		resolution := resolved[recordSetOf(r)]
+		switch resolution.Action {
+		case KeepExisting:
+			continue
+		case RenameImported:
+			r = withName(r, resolution.Name)
+		}
+		desired = append(desired, r)
+	}
+
+	cs, err := p.Plan(ctx, zone, desired)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Apply(ctx, cs); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// importConflicts returns the record sets of imported that exist in
+// existing with different records
+func importConflicts(existing, imported []libdns.Record) []ImportConflict {
+	var order []recordSetKey
+	sets := make(map[recordSetKey]*ImportConflict)
+	for _, r := range imported {
+		key := recordSetOf(r)
+		c, ok := sets[key]
+		if !ok {
+			c = &ImportConflict{Name: key.name, Type: key.typ}
+			sets[key] = c
+			order = append(order, key)
+		}
+		c.Imported = append(c.Imported, r)
+	}
+	for _, e := range existing {
+		if c, ok := sets[recordSetOf(e)]; ok {
+			c.Existing = append(c.Existing, e)
+		}
+	}
+
+	var conflicts []ImportConflict
+	for _, key := range order {
+		c := sets[key]
+		if len(c.Existing) > 0 && !diffRecords(c.Existing, c.Imported).Empty() {
+			conflicts = append(conflicts, *c)
+		}
+	}
+	return conflicts
+}
+
+// withName returns record renamed, keeping its provider data
+func withName(record libdns.Record, name string) libdns.Record {
+	rr := record.RR()
+	rr.Name = name
+	renamed := parseRecord(rr)
+	if data, ok := rage4Data(record); ok {
+		renamed = withRage4Data(renamed, data)
+	}
+	return renamed
+}
@@ -0,0 +1,118 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestImportWithOptions(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "mail.example.com", Type: "A", Content: "192.0.2.2", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "api.example.com", Type: "A", Content: "192.0.2.3", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "same.example.com", Type: "A", Content: "192.0.2.4", TTL: 300})
+
+	result := &ImportResult{Records: Records{
+		libdns.RR{Name: "www", Type: "A", Data: "198.51.100.1", TTL: 5 * time.Minute},
+		libdns.RR{Name: "mail", Type: "A", Data: "198.51.100.2", TTL: 5 * time.Minute},
+		libdns.RR{Name: "api", Type: "A", Data: "198.51.100.3", TTL: 5 * time.Minute},
+		libdns.RR{Name: "same", Type: "A", Data: "192.0.2.4", TTL: 5 * time.Minute},
+		libdns.RR{Name: "new", Type: "A", Data: "198.51.100.5", TTL: 5 * time.Minute},
+	}}
+
+	conflicts, err := p.ImportConflicts(ctx, "example.com.", result)
+	if err != nil {
+		t.Fatalf("ImportConflicts failed: %v", err)
+	}
+	var names []string
+	for _, c := range conflicts {
+		names = append(names, c.Name)
+	}
+	if !slices.Equal(names, []string{"www", "mail", "api"}) {
+		t.Fatalf("expected conflicts on www, mail and api, got %v", names)
+	}
+	if c := conflicts[0]; c.Type != "A" || len(c.Existing) != 1 || c.Existing[0].RR().Data != "192.0.2.1" ||
+		len(c.Imported) != 1 || c.Imported[0].RR().Data != "198.51.100.1" {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+
+	_, err = p.ImportWithOptions(ctx, "example.com.", result, ImportOptions{
+		Resolve: func(ctx context.Context, c ImportConflict) (ImportResolution, error) {
+			switch c.Name {
+			case "mail":
+				return ImportResolution{Action: KeepExisting}, nil
+			case "api":
+				return ImportResolution{Action: RenameImported, Name: "api-imported"}, nil
+			}
+			return ImportResolution{Action: OverwriteExisting}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportWithOptions failed: %v", err)
+	}
+
+	var stored []string
+	for _, r := range f.domainRecords(1) {
+		stored = append(stored, r.Name+" "+r.Content)
+	}
+	slices.Sort(stored)
+	want := []string{
+		"api-imported.example.com 198.51.100.3",
+		"api.example.com 192.0.2.3",
+		"mail.example.com 192.0.2.2",
+		"new.example.com 198.51.100.5",
+		"same.example.com 192.0.2.4",
+		"www.example.com 198.51.100.1",
+	}
+	if !slices.Equal(stored, want) {
+		t.Errorf("expected %v, got %v", want, stored)
+	}
+
+	// the report records the resolutions
+	if len(result.Conflicts) != 3 || result.Conflicts[1].Resolution.Action != KeepExisting {
+		t.Errorf("unexpected conflicts: %+v", result.Conflicts)
+	}
+	data, err := json.Marshal(result.Conflicts[2])
+	if err != nil || !strings.Contains(string(data), `"resolution":{"action":"rename imported","name":"api-imported"}`) {
+		t.Errorf("unexpected JSON: %s, %v", data, err)
+	}
+}
+
+func TestImportWithOptionsAborts(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+
+	result := &ImportResult{Records: Records{
+		libdns.RR{Name: "www", Type: "A", Data: "198.51.100.1", TTL: 5 * time.Minute},
+		libdns.RR{Name: "new", Type: "A", Data: "198.51.100.2", TTL: 5 * time.Minute},
+	}}
+
+	errAborted := errors.New("aborted by user")
+	_, err := p.ImportWithOptions(ctx, "example.com.", result, ImportOptions{
+		Resolve: func(ctx context.Context, c ImportConflict) (ImportResolution, error) {
+			return ImportResolution{}, errAborted
+		},
+	})
+	if !errors.Is(err, errAborted) {
+		t.Errorf("expected the import to be aborted, got %v", err)
+	}
+	if _, err := p.ImportWithOptions(ctx, "example.com.", result, ImportOptions{
+		Resolve: func(ctx context.Context, c ImportConflict) (ImportResolution, error) {
+			return ImportResolution{Action: RenameImported}, nil
+		},
+	}); err == nil {
+		t.Errorf("expected an error renaming without a name")
+	}
+	if len(f.domainRecords(1)) != 1 {
+		t.Errorf("expected no changes, got %+v", f.domainRecords(1))
+	}
+}
@@ -0,0 +1,85 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrNotConsistent is returned by GetRecordsConsistent when the expected
+// state does not become visible in time.
+var ErrNotConsistent = errors.New("expected records not visible")
+
+// consistencyTimeout bounds GetRecordsConsistent when ctx has no earlier
+// deadline
+const consistencyTimeout = 10 * time.Second
+
+// GetRecordsConsistent lists the records of zone like GetRecords, but
+// re-reads the zone until expect accepts the records, since Rage4 may
+// briefly return stale data right after a mutation. It polls with
+// increasing intervals for at most 10 seconds, or until ctx is done if
+// sooner. On timeout it returns the last records read along with
+// ErrNotConsistent.
+func (p *Provider) GetRecordsConsistent(ctx context.Context, zone string, expect func([]libdns.Record) bool) ([]libdns.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, consistencyTimeout)
+	defer cancel()
+
+	interval := 250 * time.Millisecond
+	for {
+		records, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			return nil, err
+		}
+		if expect(records) {
+			return records, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return records, fmt.Errorf("%w in %s: %w", ErrNotConsistent, zone, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, 2*time.Second)
+	}
+}
+
+// ExpectPresent returns an expectation for GetRecordsConsistent that is
+// met once every given record exists. Zero TTLs and priorities match
+// any value.
+func ExpectPresent(records ...libdns.Record) func([]libdns.Record) bool {
+	return func(existing []libdns.Record) bool {
+		for _, want := range records {
+			if !containsRecord(existing, want) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ExpectAbsent returns an expectation for GetRecordsConsistent that is
+// met once none of the given records exists. Zero TTLs and priorities
+// match any value.
+func ExpectAbsent(records ...libdns.Record) func([]libdns.Record) bool {
+	return func(existing []libdns.Record) bool {
+		for _, unwanted := range records {
+			if containsRecord(existing, unwanted) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// containsRecord reports whether records contains a record matching want
+func containsRecord(records []libdns.Record, want libdns.Record) bool {
+	for _, r := range records {
+		if sameRecord(r, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestGetRecordsConsistent(t *testing.T) {
+	f, p := newFakeRage4(t, "example.com.")
+
+	// the record only becomes visible after a couple of reads
+	go func() {
+		for f.calls("GetRecords") < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	}()
+
+	www := libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}
+	records, err := p.GetRecordsConsistent(context.Background(), "example.com.", ExpectPresent(www))
+	if err != nil {
+		t.Fatalf("GetRecordsConsistent failed: %v", err)
+	}
+	if len(records) != 1 || f.calls("GetRecords") < 3 {
+		t.Errorf("expected the record after polling, got %+v after %d reads", records, f.calls("GetRecords"))
+	}
+
+	if !ExpectAbsent(libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"})(records) {
+		t.Error("expected an unrelated record to be absent")
+	}
+}
+
+func TestGetRecordsConsistentTimeout(t *testing.T) {
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "old.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	records, err := p.GetRecordsConsistent(ctx, "example.com.", ExpectAbsent(libdns.RR{Name: "old", Type: "A", Data: "192.0.2.1"}))
+	if !errors.Is(err, ErrNotConsistent) {
+		t.Fatalf("expected ErrNotConsistent, got %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("expected the last records read, got %+v", records)
+	}
+}
@@ -0,0 +1,158 @@
+package libdnsrage4
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Rage4 keeps the priority of MX, SRV, and NAPTR records (and the weight
+// of SRV records) in separate fields, but content read back from the
+// API, imported, or written by other tools may carry them inline as
+// well. These converters accept both forms and always produce the full
+// RDATA libdns expects, such as "10 mail.example.com" for MX and
+// "10 5 5060 sip.example.com" for SRV.
+func init() {
+	RegisterConverter("MX", Converter{FromRage4: decodeMX, ToRage4: encodeMX})
+	RegisterConverter("SRV", Converter{FromRage4: decodeSRV, ToRage4: encodeSRV})
+	RegisterConverter("NAPTR", Converter{FromRage4: decodeNAPTR, ToRage4: encodeNAPTR})
+}
+
+// decodeMX accepts "target", with the preference taken from the priority
+// field, and "preference target"
+func decodeMX(r Rage4Record, rr libdns.RR) (libdns.RR, error) {
+	fields := strings.Fields(r.Content)
+	preference := uint16(r.Priority)
+	switch len(fields) {
+	case 1:
+	case 2:
+		n, err := parseUint16(fields[0])
+		if err != nil {
+			return rr, fmt.Errorf("invalid preference %q", fields[0])
+		}
+		preference = n
+	default:
+		return rr, fmt.Errorf("expected [preference] target")
+	}
+	rr.Data = fmt.Sprintf("%d %s", preference, strings.TrimSuffix(fields[len(fields)-1], "."))
+	return rr, nil
+}
+
+// encodeMX sends the preference as priority, separately from the target
+func encodeMX(rr libdns.RR, r Rage4Record) (Rage4Record, error) {
+	decoded, err := decodeMX(Rage4Record{Content: rr.Data}, rr)
+	if err != nil {
+		return r, err
+	}
+	fields := strings.Fields(decoded.Data)
+	preference, _ := parseUint16(fields[0])
+	r.Content = fields[1]
+	r.Priority = int(preference)
+	return r, nil
+}
+
+// decodeSRV accepts "port target", "weight port target" and
+// "priority weight port target", taking what is missing from the
+// priority and weight fields
+func decodeSRV(r Rage4Record, rr libdns.RR) (libdns.RR, error) {
+	fields := strings.Fields(r.Content)
+	if len(fields) < 2 || len(fields) > 4 {
+		return rr, fmt.Errorf("expected [[priority] weight] port target")
+	}
+
+	numbers := []uint16{uint16(r.Priority), uint16(r.Weight)}
+	numbers = numbers[:4-len(fields)]
+	for _, field := range fields[:len(fields)-1] {
+		n, err := parseUint16(field)
+		if err != nil {
+			return rr, fmt.Errorf("invalid number %q", field)
+		}
+		numbers = append(numbers, n)
+	}
+
+	target := strings.TrimSuffix(fields[len(fields)-1], ".")
+	rr.Data = fmt.Sprintf("%d %d %d %s", numbers[0], numbers[1], numbers[2], target)
+	return rr, nil
+}
+
+// encodeSRV sends "weight port target" with the priority separately
+func encodeSRV(rr libdns.RR, r Rage4Record) (Rage4Record, error) {
+	decoded, err := decodeSRV(Rage4Record{Content: rr.Data}, rr)
+	if err != nil {
+		return r, err
+	}
+	fields := strings.Fields(decoded.Data)
+	priority, _ := parseUint16(fields[0])
+	weight, _ := parseUint16(fields[1])
+	r.Content = strings.Join(fields[1:], " ")
+	r.Priority = int(priority)
+	r.Weight = int(weight)
+	return r, nil
+}
+
+// decodeNAPTR accepts the full "order preference flags service regexp
+// replacement" form and the form without the order, which is then taken
+// from the priority field
+func decodeNAPTR(r Rage4Record, rr libdns.RR) (libdns.RR, error) {
+	fields, err := splitNAPTR(r.Content)
+	if err != nil {
+		return rr, err
+	}
+	if len(fields) == 5 {
+		fields = append([]string{strconv.Itoa(r.Priority)}, fields...)
+	}
+
+	for _, field := range fields[:2] {
+		if _, err := parseUint16(field); err != nil {
+			return rr, fmt.Errorf("invalid number %q", field)
+		}
+	}
+
+	rr.Data = strings.Join(fields, " ")
+	return rr, nil
+}
+
+// encodeNAPTR sends the full form, with the order also as priority
+func encodeNAPTR(rr libdns.RR, r Rage4Record) (Rage4Record, error) {
+	decoded, err := decodeNAPTR(Rage4Record{Content: rr.Data}, rr)
+	if err != nil {
+		return r, err
+	}
+	order, _ := parseUint16(strings.Fields(decoded.Data)[0])
+	r.Content = decoded.Data
+	r.Priority = int(order)
+	return r, nil
+}
+
+// splitNAPTR splits NAPTR content into fields, keeping quoted strings
+// (which may contain spaces) intact
+func splitNAPTR(content string) ([]string, error) {
+	var fields []string
+	for rest := strings.TrimSpace(content); rest != ""; rest = strings.TrimSpace(rest) {
+		end := strings.IndexByte(rest, ' ')
+		if rest[0] == '"' {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			end = closing + 2
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+
+	if len(fields) != 5 && len(fields) != 6 {
+		return nil, fmt.Errorf("expected [order] preference flags service regexp replacement")
+	}
+	return fields, nil
+}
+
+func parseUint16(s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	return uint16(n), err
+}
@@ -0,0 +1,95 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestContentDecoding(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Rage4Record
+		data string
+	}{
+		{"MX separate priority", Rage4Record{Type: "MX", Content: "mail.example.com", Priority: 10}, "10 mail.example.com"},
+		{"MX inline priority", Rage4Record{Type: "MX", Content: "20 mail.example.com."}, "20 mail.example.com"},
+		{"SRV port target", Rage4Record{Type: "SRV", Content: "5060 sip.example.com", Priority: 10, Weight: 5}, "10 5 5060 sip.example.com"},
+		{"SRV weight port target", Rage4Record{Type: "SRV", Content: "5 5060 sip.example.com", Priority: 10}, "10 5 5060 sip.example.com"},
+		{"SRV full", Rage4Record{Type: "SRV", Content: "10 5 5060 sip.example.com."}, "10 5 5060 sip.example.com"},
+		{"NAPTR full", Rage4Record{Type: "NAPTR", Content: `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`}, `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+		{"NAPTR order as priority", Rage4Record{Type: "NAPTR", Content: `10 "S" "SIP+D2U" "" _sip._udp.example.com.`, Priority: 100}, `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.r.Name = "_sip._udp.example.com"
+			record, err := fromRage4(tt.r, "example.com")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if data := record.RR().Data; data != tt.data {
+				t.Errorf("got %q, want %q", data, tt.data)
+			}
+		})
+	}
+
+	mx, err := fromRage4(Rage4Record{ID: 7, Name: "example.com", Type: "MX", Content: "mail.example.com", Priority: 10}, "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mx, ok := mx.(libdns.MX); !ok || mx.Preference != 10 || mx.Target != "mail.example.com" || recordID(mx) != 7 {
+		t.Errorf("unexpected MX: %#v", mx)
+	}
+
+	srv, err := fromRage4(Rage4Record{Name: "_sip._udp.example.com", Type: "SRV", Content: "5 5060 sip.example.com", Priority: 10}, "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if srv, ok := srv.(libdns.SRV); !ok || srv.Service != "sip" || srv.Transport != "udp" ||
+		srv.Priority != 10 || srv.Weight != 5 || srv.Port != 5060 || srv.Target != "sip.example.com" {
+		t.Errorf("unexpected SRV: %#v", srv)
+	}
+
+	for _, r := range []Rage4Record{
+		{Type: "MX", Content: "ten mail.example.com"},
+		{Type: "SRV", Content: "sip.example.com"},
+		{Type: "SRV", Content: "70000 5060 sip.example.com"},
+		{Type: "NAPTR", Content: `100 10 "U" "E2U+sip`},
+	} {
+		if _, err := fromRage4(r, "example.com"); err == nil {
+			t.Errorf("expected %s content %q to be rejected", r.Type, r.Content)
+		}
+	}
+}
+
+func TestContentRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	records := []libdns.Record{
+		libdns.MX{Name: "@", Preference: 10, Target: "mail.example.com"},
+		libdns.SRV{Service: "sip", Transport: "udp", Name: "@", Priority: 20, Weight: 5, Port: 5060, Target: "sip.example.com"},
+	}
+	if _, err := p.AppendRecords(ctx, "example.com.", records); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	stored := f.domainRecords(1)
+	if stored[0].Content != "mail.example.com" || stored[0].Priority != 10 {
+		t.Errorf("unexpected stored MX: %+v", stored[0])
+	}
+	if stored[1].Content != "5 5060 sip.example.com" || stored[1].Priority != 20 {
+		t.Errorf("unexpected stored SRV: %+v", stored[1])
+	}
+
+	got, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	for i, want := range records {
+		if got[i].RR().Data != want.RR().Data {
+			t.Errorf("record %d did not round-trip: got %+v, want %+v", i, got[i], want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package libdnsrage4
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// Converter customizes how records of one type are mapped between Rage4
+// and libdns, for record types the package does not handle specially,
+// such as provider-specific or newly introduced types.
+//
+// Both functions receive the result of the default conversion, so they
+// only need to adjust what differs. Either may be nil. They work on the
+// generic libdns.RR form; the provider parses the result into the typed
+// record for the type, if libdns has one. Errors they return reach the
+// caller as a *ConversionError naming the record; a converter can return
+// its own *ConversionError to name a more specific field.
+type Converter struct {
+	// FromRage4 converts a record read from Rage4. rr holds the default
+	// conversion of r, with a relative name and the content as data.
+	FromRage4 func(r Rage4Record, rr libdns.RR) (libdns.RR, error)
+
+	// ToRage4 converts a record about to be written to Rage4. r holds
+	// the default conversion of rr, with a fully-qualified name and the
+	// data as content; the returned Content is what is sent to Rage4.
+	ToRage4 func(rr libdns.RR, r Rage4Record) (Rage4Record, error)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = make(map[string]Converter)
+)
+
+// RegisterConverter registers the converter for a record type, replacing
+// any converter registered before. It is typically called from an init
+// function.
+func RegisterConverter(recordType string, c Converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[strings.ToUpper(recordType)] = c
+}
+
+// converterFor returns the converter registered for a record type
+func converterFor(recordType string) (Converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	c, ok := converters[strings.ToUpper(recordType)]
+	return c, ok
+}
+
+// fromRage4 converts a Rage4 record to a libdns record, applying any
+// registered converter. The result carries r as its provider data.
+func fromRage4(r Rage4Record, zoneName string) (libdns.Record, error) {
+	rr, err := fromRage4RR(r, zoneName)
+	if err != nil {
+		return nil, err
+	}
+	return withRage4Data(parseRecord(rr), r), nil
+}
+
+// fromRage4RR converts a Rage4 record to its resource record, applying
+// any registered converter. Errors are returned as *ConversionError.
+func fromRage4RR(r Rage4Record, zoneName string) (libdns.RR, error) {
+	rr, err := safely(func() (libdns.RR, error) {
+		rr := toLibdnsRR(r, zoneName)
+		if c, ok := converterFor(r.Type); ok && c.FromRage4 != nil {
+			return c.FromRage4(r, rr)
+		}
+		return rr, nil
+	})
+	if err != nil {
+		return rr, conversionError(err, &ConversionError{
+			Zone: zoneName, Name: r.Name, Type: r.Type, ID: r.ID, Field: "content", Value: r.Content,
+		})
+	}
+	return rr, nil
+}
+
+// toRage4 converts a libdns record to the Rage4 record to create,
+// applying any registered converter. Errors are returned as
+// *ConversionError.
+func toRage4(record libdns.Record, zoneName string) (Rage4Record, error) {
+	r, err := safely(func() (Rage4Record, error) {
+		rr := record.RR()
+		data, _ := rage4Data(record)
+		r := Rage4Record{
+			ID:      data.ID,
+			Name:    fullName(rr.Name, zoneName),
+			Type:    rr.Type,
+			Content: rr.Data,
+			TTL:     int(rr.TTL.Seconds()),
+		}
+		settingsOf(data).applyTo(&r)
+		if c, ok := converterFor(rr.Type); ok && c.ToRage4 != nil {
+			return c.ToRage4(rr, r)
+		}
+		return r, nil
+	})
+	if err != nil {
+		rr := record.RR()
+		return r, conversionError(err, &ConversionError{
+			Zone: zoneName, Name: rr.Name, Type: rr.Type, ID: recordID(record), Field: "data", Value: rr.Data,
+		})
+	}
+	return r, nil
+}
+
+// ConversionError reports a record that could not be converted between
+// its Rage4 and libdns forms, naming the field and the raw value that
+// did not convert.
+type ConversionError struct {
+	// Zone is the zone of the record, without the trailing dot
+	Zone string
+
+	// Name and Type identify the record; ID is its Rage4 ID, or zero if
+	// it has none yet
+	Name string
+	Type string
+	ID   int64
+
+	// Field is the field that did not convert, "content" for records
+	// read from Rage4 and "data" for records written to it, and Value
+	// its raw value
+	Field string
+	Value string
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *ConversionError) Error() string {
+	record := fmt.Sprintf("%s record %q", e.Type, e.Name)
+	if e.ID != 0 {
+		record += fmt.Sprintf(" (ID %d)", e.ID)
+	}
+	return fmt.Sprintf("failed to convert %s: invalid %s %q: %v", record, e.Field, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError returns err as a *ConversionError, filling in the
+// details of template that a converter returning its own
+// *ConversionError left out
+func conversionError(err error, template *ConversionError) error {
+	var ce *ConversionError
+	if errors.As(err, &ce) {
+		filled := *ce
+		if filled.Zone == "" {
+			filled.Zone = template.Zone
+		}
+		if filled.Name == "" {
+			filled.Name = template.Name
+		}
+		if filled.Type == "" {
+			filled.Type = template.Type
+		}
+		if filled.ID == 0 {
+			filled.ID = template.ID
+		}
+		if filled.Field == "" {
+			filled.Field, filled.Value = template.Field, template.Value
+		}
+		return &filled
+	}
+	template.Err = err
+	return template
+}
+
+// fullName returns the fully-qualified name, without a trailing dot, of
+// a name relative to zoneName
+func fullName(name, zoneName string) string {
+	if name == "" || name == "@" {
+		return zoneName
+	}
+	return name + "." + zoneName
+}
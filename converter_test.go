@@ -0,0 +1,74 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRegisterConverter(t *testing.T) {
+	// a made-up type whose content Rage4 stores upper-cased
+	RegisterConverter("x-upper", Converter{
+		FromRage4: func(r Rage4Record, rr libdns.RR) (libdns.RR, error) {
+			rr.Data = strings.ToLower(r.Content)
+			return rr, nil
+		},
+		ToRage4: func(rr libdns.RR, r Rage4Record) (Rage4Record, error) {
+			r.Content = strings.ToUpper(rr.Data)
+			return r, nil
+		},
+	})
+
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "x", Type: "X-UPPER", Data: "hello"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); len(stored) != 1 || stored[0].Content != "HELLO" {
+		t.Fatalf("expected converted content to be stored, got %+v", stored)
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Data != "hello" {
+		t.Fatalf("expected converted value to be read back, got %+v", records)
+	}
+
+	// lookups without an ID compare converted values
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "x", Type: "X-UPPER", Data: "hello"}}); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); len(stored) != 0 {
+		t.Errorf("expected the record to be deleted, got %+v", stored)
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "example.com", Type: "MX", Content: "ten mail.example.com", TTL: 3600})
+
+	_, err := p.GetRecords(ctx, "example.com.")
+	var ce *ConversionError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected a ConversionError, got %v", err)
+	}
+	if ce.ID != id || ce.Type != "MX" || ce.Field != "content" || ce.Value != "ten mail.example.com" {
+		t.Fatalf("unexpected error details %+v", ce)
+	}
+	if want := fmt.Sprintf(`failed to convert MX record "example.com" (ID %d): invalid content "ten mail.example.com": invalid preference "ten"`, id); !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected %q in %q", want, err)
+	}
+
+	_, err = p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "sip", Type: "SRV", Data: "5060"}})
+	if !errors.As(err, &ce) || ce.Name != "sip" || ce.Field != "data" || ce.Value != "5060" {
+		t.Fatalf("expected a ConversionError for the SRV data, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+// Code generated by geogen from internal/geogen/countries.tsv; DO NOT EDIT.
+
+package libdnsrage4
+
+// Continents, which countries are grouped by.
+const (
+	ContinentAfrica       Continent = "Africa"
+	ContinentAntarctica   Continent = "Antarctica"
+	ContinentAsia         Continent = "Asia"
+	ContinentEurope       Continent = "Europe"
+	ContinentNorthAmerica Continent = "North America"
+	ContinentOceania      Continent = "Oceania"
+	ContinentSouthAmerica Continent = "South America"
+)
+
+// countries maps ISO 3166-1 alpha-2 codes to their country
+var countries = map[string]Country{
+	"AD": {Code: "AD", Name: "Andorra", Continent: ContinentEurope},
+	"AE": {Code: "AE", Name: "United Arab Emirates", Continent: ContinentAsia},
+	"AF": {Code: "AF", Name: "Afghanistan", Continent: ContinentAsia},
+	"AG": {Code: "AG", Name: "Antigua and Barbuda", Continent: ContinentNorthAmerica},
+	"AI": {Code: "AI", Name: "Anguilla", Continent: ContinentNorthAmerica},
+	"AL": {Code: "AL", Name: "Albania", Continent: ContinentEurope},
+	"AM": {Code: "AM", Name: "Armenia", Continent: ContinentAsia},
+	"AO": {Code: "AO", Name: "Angola", Continent: ContinentAfrica},
+	"AQ": {Code: "AQ", Name: "Antarctica", Continent: ContinentAntarctica},
+	"AR": {Code: "AR", Name: "Argentina", Continent: ContinentSouthAmerica},
+	"AS": {Code: "AS", Name: "American Samoa", Continent: ContinentOceania},
+	"AT": {Code: "AT", Name: "Austria", Continent: ContinentEurope},
+	"AU": {Code: "AU", Name: "Australia", Continent: ContinentOceania},
+	"AW": {Code: "AW", Name: "Aruba", Continent: ContinentNorthAmerica},
+	"AX": {Code: "AX", Name: "Åland Islands", Continent: ContinentEurope},
+	"AZ": {Code: "AZ", Name: "Azerbaijan", Continent: ContinentAsia},
+	"BA": {Code: "BA", Name: "Bosnia and Herzegovina", Continent: ContinentEurope},
+	"BB": {Code: "BB", Name: "Barbados", Continent: ContinentNorthAmerica},
+	"BD": {Code: "BD", Name: "Bangladesh", Continent: ContinentAsia},
+	"BE": {Code: "BE", Name: "Belgium", Continent: ContinentEurope},
+	"BF": {Code: "BF", Name: "Burkina Faso", Continent: ContinentAfrica},
+	"BG": {Code: "BG", Name: "Bulgaria", Continent: ContinentEurope},
+	"BH": {Code: "BH", Name: "Bahrain", Continent: ContinentAsia},
+	"BI": {Code: "BI", Name: "Burundi", Continent: ContinentAfrica},
+	"BJ": {Code: "BJ", Name: "Benin", Continent: ContinentAfrica},
+	"BL": {Code: "BL", Name: "Saint Barthélemy", Continent: ContinentNorthAmerica},
+	"BM": {Code: "BM", Name: "Bermuda", Continent: ContinentNorthAmerica},
+	"BN": {Code: "BN", Name: "Brunei", Continent: ContinentAsia},
+	"BO": {Code: "BO", Name: "Bolivia", Continent: ContinentSouthAmerica},
+	"BQ": {Code: "BQ", Name: "Bonaire, Sint Eustatius and Saba", Continent: ContinentNorthAmerica},
+	"BR": {Code: "BR", Name: "Brazil", Continent: ContinentSouthAmerica},
+	"BS": {Code: "BS", Name: "Bahamas", Continent: ContinentNorthAmerica},
+	"BT": {Code: "BT", Name: "Bhutan", Continent: ContinentAsia},
+	"BV": {Code: "BV", Name: "Bouvet Island", Continent: ContinentAntarctica},
+	"BW": {Code: "BW", Name: "Botswana", Continent: ContinentAfrica},
+	"BY": {Code: "BY", Name: "Belarus", Continent: ContinentEurope},
+	"BZ": {Code: "BZ", Name: "Belize", Continent: ContinentNorthAmerica},
+	"CA": {Code: "CA", Name: "Canada", Continent: ContinentNorthAmerica},
+	"CC": {Code: "CC", Name: "Cocos (Keeling) Islands", Continent: ContinentAsia},
+	"CD": {Code: "CD", Name: "Democratic Republic of the Congo", Continent: ContinentAfrica},
+	"CF": {Code: "CF", Name: "Central African Republic", Continent: ContinentAfrica},
+	"CG": {Code: "CG", Name: "Republic of the Congo", Continent: ContinentAfrica},
+	"CH": {Code: "CH", Name: "Switzerland", Continent: ContinentEurope},
+	"CI": {Code: "CI", Name: "Ivory Coast", Continent: ContinentAfrica},
+	"CK": {Code: "CK", Name: "Cook Islands", Continent: ContinentOceania},
+	"CL": {Code: "CL", Name: "Chile", Continent: ContinentSouthAmerica},
+	"CM": {Code: "CM", Name: "Cameroon", Continent: ContinentAfrica},
+	"CN": {Code: "CN", Name: "China", Continent: ContinentAsia},
+	"CO": {Code: "CO", Name: "Colombia", Continent: ContinentSouthAmerica},
+	"CR": {Code: "CR", Name: "Costa Rica", Continent: ContinentNorthAmerica},
+	"CU": {Code: "CU", Name: "Cuba", Continent: ContinentNorthAmerica},
+	"CV": {Code: "CV", Name: "Cabo Verde", Continent: ContinentAfrica},
+	"CW": {Code: "CW", Name: "Curaçao", Continent: ContinentNorthAmerica},
+	"CX": {Code: "CX", Name: "Christmas Island", Continent: ContinentOceania},
+	"CY": {Code: "CY", Name: "Cyprus", Continent: ContinentEurope},
+	"CZ": {Code: "CZ", Name: "Czechia", Continent: ContinentEurope},
+	"DE": {Code: "DE", Name: "Germany", Continent: ContinentEurope},
+	"DJ": {Code: "DJ", Name: "Djibouti", Continent: ContinentAfrica},
+	"DK": {Code: "DK", Name: "Denmark", Continent: ContinentEurope},
+	"DM": {Code: "DM", Name: "Dominica", Continent: ContinentNorthAmerica},
+	"DO": {Code: "DO", Name: "Dominican Republic", Continent: ContinentNorthAmerica},
+	"DZ": {Code: "DZ", Name: "Algeria", Continent: ContinentAfrica},
+	"EC": {Code: "EC", Name: "Ecuador", Continent: ContinentSouthAmerica},
+	"EE": {Code: "EE", Name: "Estonia", Continent: ContinentEurope},
+	"EG": {Code: "EG", Name: "Egypt", Continent: ContinentAfrica},
+	"EH": {Code: "EH", Name: "Western Sahara", Continent: ContinentAfrica},
+	"ER": {Code: "ER", Name: "Eritrea", Continent: ContinentAfrica},
+	"ES": {Code: "ES", Name: "Spain", Continent: ContinentEurope},
+	"ET": {Code: "ET", Name: "Ethiopia", Continent: ContinentAfrica},
+	"FI": {Code: "FI", Name: "Finland", Continent: ContinentEurope},
+	"FJ": {Code: "FJ", Name: "Fiji", Continent: ContinentOceania},
+	"FK": {Code: "FK", Name: "Falkland Islands", Continent: ContinentSouthAmerica},
+	"FM": {Code: "FM", Name: "Micronesia", Continent: ContinentOceania},
+	"FO": {Code: "FO", Name: "Faroe Islands", Continent: ContinentEurope},
+	"FR": {Code: "FR", Name: "France", Continent: ContinentEurope},
+	"GA": {Code: "GA", Name: "Gabon", Continent: ContinentAfrica},
+	"GB": {Code: "GB", Name: "United Kingdom", Continent: ContinentEurope},
+	"GD": {Code: "GD", Name: "Grenada", Continent: ContinentNorthAmerica},
+	"GE": {Code: "GE", Name: "Georgia", Continent: ContinentAsia},
+	"GF": {Code: "GF", Name: "French Guiana", Continent: ContinentSouthAmerica},
+	"GG": {Code: "GG", Name: "Guernsey", Continent: ContinentEurope},
+	"GH": {Code: "GH", Name: "Ghana", Continent: ContinentAfrica},
+	"GI": {Code: "GI", Name: "Gibraltar", Continent: ContinentEurope},
+	"GL": {Code: "GL", Name: "Greenland", Continent: ContinentNorthAmerica},
+	"GM": {Code: "GM", Name: "Gambia", Continent: ContinentAfrica},
+	"GN": {Code: "GN", Name: "Guinea", Continent: ContinentAfrica},
+	"GP": {Code: "GP", Name: "Guadeloupe", Continent: ContinentNorthAmerica},
+	"GQ": {Code: "GQ", Name: "Equatorial Guinea", Continent: ContinentAfrica},
+	"GR": {Code: "GR", Name: "Greece", Continent: ContinentEurope},
+	"GS": {Code: "GS", Name: "South Georgia and the South Sandwich Islands", Continent: ContinentAntarctica},
+	"GT": {Code: "GT", Name: "Guatemala", Continent: ContinentNorthAmerica},
+	"GU": {Code: "GU", Name: "Guam", Continent: ContinentOceania},
+	"GW": {Code: "GW", Name: "Guinea-Bissau", Continent: ContinentAfrica},
+	"GY": {Code: "GY", Name: "Guyana", Continent: ContinentSouthAmerica},
+	"HK": {Code: "HK", Name: "Hong Kong", Continent: ContinentAsia},
+	"HM": {Code: "HM", Name: "Heard Island and McDonald Islands", Continent: ContinentAntarctica},
+	"HN": {Code: "HN", Name: "Honduras", Continent: ContinentNorthAmerica},
+	"HR": {Code: "HR", Name: "Croatia", Continent: ContinentEurope},
+	"HT": {Code: "HT", Name: "Haiti", Continent: ContinentNorthAmerica},
+	"HU": {Code: "HU", Name: "Hungary", Continent: ContinentEurope},
+	"ID": {Code: "ID", Name: "Indonesia", Continent: ContinentAsia},
+	"IE": {Code: "IE", Name: "Ireland", Continent: ContinentEurope},
+	"IL": {Code: "IL", Name: "Israel", Continent: ContinentAsia},
+	"IM": {Code: "IM", Name: "Isle of Man", Continent: ContinentEurope},
+	"IN": {Code: "IN", Name: "India", Continent: ContinentAsia},
+	"IO": {Code: "IO", Name: "British Indian Ocean Territory", Continent: ContinentAsia},
+	"IQ": {Code: "IQ", Name: "Iraq", Continent: ContinentAsia},
+	"IR": {Code: "IR", Name: "Iran", Continent: ContinentAsia},
+	"IS": {Code: "IS", Name: "Iceland", Continent: ContinentEurope},
+	"IT": {Code: "IT", Name: "Italy", Continent: ContinentEurope},
+	"JE": {Code: "JE", Name: "Jersey", Continent: ContinentEurope},
+	"JM": {Code: "JM", Name: "Jamaica", Continent: ContinentNorthAmerica},
+	"JO": {Code: "JO", Name: "Jordan", Continent: ContinentAsia},
+	"JP": {Code: "JP", Name: "Japan", Continent: ContinentAsia},
+	"KE": {Code: "KE", Name: "Kenya", Continent: ContinentAfrica},
+	"KG": {Code: "KG", Name: "Kyrgyzstan", Continent: ContinentAsia},
+	"KH": {Code: "KH", Name: "Cambodia", Continent: ContinentAsia},
+	"KI": {Code: "KI", Name: "Kiribati", Continent: ContinentOceania},
+	"KM": {Code: "KM", Name: "Comoros", Continent: ContinentAfrica},
+	"KN": {Code: "KN", Name: "Saint Kitts and Nevis", Continent: ContinentNorthAmerica},
+	"KP": {Code: "KP", Name: "North Korea", Continent: ContinentAsia},
+	"KR": {Code: "KR", Name: "South Korea", Continent: ContinentAsia},
+	"KW": {Code: "KW", Name: "Kuwait", Continent: ContinentAsia},
+	"KY": {Code: "KY", Name: "Cayman Islands", Continent: ContinentNorthAmerica},
+	"KZ": {Code: "KZ", Name: "Kazakhstan", Continent: ContinentAsia},
+	"LA": {Code: "LA", Name: "Laos", Continent: ContinentAsia},
+	"LB": {Code: "LB", Name: "Lebanon", Continent: ContinentAsia},
+	"LC": {Code: "LC", Name: "Saint Lucia", Continent: ContinentNorthAmerica},
+	"LI": {Code: "LI", Name: "Liechtenstein", Continent: ContinentEurope},
+	"LK": {Code: "LK", Name: "Sri Lanka", Continent: ContinentAsia},
+	"LR": {Code: "LR", Name: "Liberia", Continent: ContinentAfrica},
+	"LS": {Code: "LS", Name: "Lesotho", Continent: ContinentAfrica},
+	"LT": {Code: "LT", Name: "Lithuania", Continent: ContinentEurope},
+	"LU": {Code: "LU", Name: "Luxembourg", Continent: ContinentEurope},
+	"LV": {Code: "LV", Name: "Latvia", Continent: ContinentEurope},
+	"LY": {Code: "LY", Name: "Libya", Continent: ContinentAfrica},
+	"MA": {Code: "MA", Name: "Morocco", Continent: ContinentAfrica},
+	"MC": {Code: "MC", Name: "Monaco", Continent: ContinentEurope},
+	"MD": {Code: "MD", Name: "Moldova", Continent: ContinentEurope},
+	"ME": {Code: "ME", Name: "Montenegro", Continent: ContinentEurope},
+	"MF": {Code: "MF", Name: "Saint Martin", Continent: ContinentNorthAmerica},
+	"MG": {Code: "MG", Name: "Madagascar", Continent: ContinentAfrica},
+	"MH": {Code: "MH", Name: "Marshall Islands", Continent: ContinentOceania},
+	"MK": {Code: "MK", Name: "North Macedonia", Continent: ContinentEurope},
+	"ML": {Code: "ML", Name: "Mali", Continent: ContinentAfrica},
+	"MM": {Code: "MM", Name: "Myanmar", Continent: ContinentAsia},
+	"MN": {Code: "MN", Name: "Mongolia", Continent: ContinentAsia},
+	"MO": {Code: "MO", Name: "Macao", Continent: ContinentAsia},
+	"MP": {Code: "MP", Name: "Northern Mariana Islands", Continent: ContinentOceania},
+	"MQ": {Code: "MQ", Name: "Martinique", Continent: ContinentNorthAmerica},
+	"MR": {Code: "MR", Name: "Mauritania", Continent: ContinentAfrica},
+	"MS": {Code: "MS", Name: "Montserrat", Continent: ContinentNorthAmerica},
+	"MT": {Code: "MT", Name: "Malta", Continent: ContinentEurope},
+	"MU": {Code: "MU", Name: "Mauritius", Continent: ContinentAfrica},
+	"MV": {Code: "MV", Name: "Maldives", Continent: ContinentAsia},
+	"MW": {Code: "MW", Name: "Malawi", Continent: ContinentAfrica},
+	"MX": {Code: "MX", Name: "Mexico", Continent: ContinentNorthAmerica},
+	"MY": {Code: "MY", Name: "Malaysia", Continent: ContinentAsia},
+	"MZ": {Code: "MZ", Name: "Mozambique", Continent: ContinentAfrica},
+	"NA": {Code: "NA", Name: "Namibia", Continent: ContinentAfrica},
+	"NC": {Code: "NC", Name: "New Caledonia", Continent: ContinentOceania},
+	"NE": {Code: "NE", Name: "Niger", Continent: ContinentAfrica},
+	"NF": {Code: "NF", Name: "Norfolk Island", Continent: ContinentOceania},
+	"NG": {Code: "NG", Name: "Nigeria", Continent: ContinentAfrica},
+	"NI": {Code: "NI", Name: "Nicaragua", Continent: ContinentNorthAmerica},
+	"NL": {Code: "NL", Name: "Netherlands", Continent: ContinentEurope},
+	"NO": {Code: "NO", Name: "Norway", Continent: ContinentEurope},
+	"NP": {Code: "NP", Name: "Nepal", Continent: ContinentAsia},
+	"NR": {Code: "NR", Name: "Nauru", Continent: ContinentOceania},
+	"NU": {Code: "NU", Name: "Niue", Continent: ContinentOceania},
+	"NZ": {Code: "NZ", Name: "New Zealand", Continent: ContinentOceania},
+	"OM": {Code: "OM", Name: "Oman", Continent: ContinentAsia},
+	"PA": {Code: "PA", Name: "Panama", Continent: ContinentNorthAmerica},
+	"PE": {Code: "PE", Name: "Peru", Continent: ContinentSouthAmerica},
+	"PF": {Code: "PF", Name: "French Polynesia", Continent: ContinentOceania},
+	"PG": {Code: "PG", Name: "Papua New Guinea", Continent: ContinentOceania},
+	"PH": {Code: "PH", Name: "Philippines", Continent: ContinentAsia},
+	"PK": {Code: "PK", Name: "Pakistan", Continent: ContinentAsia},
+	"PL": {Code: "PL", Name: "Poland", Continent: ContinentEurope},
+	"PM": {Code: "PM", Name: "Saint Pierre and Miquelon", Continent: ContinentNorthAmerica},
+	"PN": {Code: "PN", Name: "Pitcairn", Continent: ContinentOceania},
+	"PR": {Code: "PR", Name: "Puerto Rico", Continent: ContinentNorthAmerica},
+	"PS": {Code: "PS", Name: "Palestine", Continent: ContinentAsia},
+	"PT": {Code: "PT", Name: "Portugal", Continent: ContinentEurope},
+	"PW": {Code: "PW", Name: "Palau", Continent: ContinentOceania},
+	"PY": {Code: "PY", Name: "Paraguay", Continent: ContinentSouthAmerica},
+	"QA": {Code: "QA", Name: "Qatar", Continent: ContinentAsia},
+	"RE": {Code: "RE", Name: "Réunion", Continent: ContinentAfrica},
+	"RO": {Code: "RO", Name: "Romania", Continent: ContinentEurope},
+	"RS": {Code: "RS", Name: "Serbia", Continent: ContinentEurope},
+	"RU": {Code: "RU", Name: "Russia", Continent: ContinentEurope},
+	"RW": {Code: "RW", Name: "Rwanda", Continent: ContinentAfrica},
+	"SA": {Code: "SA", Name: "Saudi Arabia", Continent: ContinentAsia},
+	"SB": {Code: "SB", Name: "Solomon Islands", Continent: ContinentOceania},
+	"SC": {Code: "SC", Name: "Seychelles", Continent: ContinentAfrica},
+	"SD": {Code: "SD", Name: "Sudan", Continent: ContinentAfrica},
+	"SE": {Code: "SE", Name: "Sweden", Continent: ContinentEurope},
+	"SG": {Code: "SG", Name: "Singapore", Continent: ContinentAsia},
+	"SH": {Code: "SH", Name: "Saint Helena, Ascension and Tristan da Cunha", Continent: ContinentAfrica},
+	"SI": {Code: "SI", Name: "Slovenia", Continent: ContinentEurope},
+	"SJ": {Code: "SJ", Name: "Svalbard and Jan Mayen", Continent: ContinentEurope},
+	"SK": {Code: "SK", Name: "Slovakia", Continent: ContinentEurope},
+	"SL": {Code: "SL", Name: "Sierra Leone", Continent: ContinentAfrica},
+	"SM": {Code: "SM", Name: "San Marino", Continent: ContinentEurope},
+	"SN": {Code: "SN", Name: "Senegal", Continent: ContinentAfrica},
+	"SO": {Code: "SO", Name: "Somalia", Continent: ContinentAfrica},
+	"SR": {Code: "SR", Name: "Suriname", Continent: ContinentSouthAmerica},
+	"SS": {Code: "SS", Name: "South Sudan", Continent: ContinentAfrica},
+	"ST": {Code: "ST", Name: "Sao Tome and Principe", Continent: ContinentAfrica},
+	"SV": {Code: "SV", Name: "El Salvador", Continent: ContinentNorthAmerica},
+	"SX": {Code: "SX", Name: "Sint Maarten", Continent: ContinentNorthAmerica},
+	"SY": {Code: "SY", Name: "Syria", Continent: ContinentAsia},
+	"SZ": {Code: "SZ", Name: "Eswatini", Continent: ContinentAfrica},
+	"TC": {Code: "TC", Name: "Turks and Caicos Islands", Continent: ContinentNorthAmerica},
+	"TD": {Code: "TD", Name: "Chad", Continent: ContinentAfrica},
+	"TF": {Code: "TF", Name: "French Southern Territories", Continent: ContinentAntarctica},
+	"TG": {Code: "TG", Name: "Togo", Continent: ContinentAfrica},
+	"TH": {Code: "TH", Name: "Thailand", Continent: ContinentAsia},
+	"TJ": {Code: "TJ", Name: "Tajikistan", Continent: ContinentAsia},
+	"TK": {Code: "TK", Name: "Tokelau", Continent: ContinentOceania},
+	"TL": {Code: "TL", Name: "Timor-Leste", Continent: ContinentOceania},
+	"TM": {Code: "TM", Name: "Turkmenistan", Continent: ContinentAsia},
+	"TN": {Code: "TN", Name: "Tunisia", Continent: ContinentAfrica},
+	"TO": {Code: "TO", Name: "Tonga", Continent: ContinentOceania},
+	"TR": {Code: "TR", Name: "Turkey", Continent: ContinentAsia},
+	"TT": {Code: "TT", Name: "Trinidad and Tobago", Continent: ContinentNorthAmerica},
+	"TV": {Code: "TV", Name: "Tuvalu", Continent: ContinentOceania},
+	"TW": {Code: "TW", Name: "Taiwan", Continent: ContinentAsia},
+	"TZ": {Code: "TZ", Name: "Tanzania", Continent: ContinentAfrica},
+	"UA": {Code: "UA", Name: "Ukraine", Continent: ContinentEurope},
+	"UG": {Code: "UG", Name: "Uganda", Continent: ContinentAfrica},
+	"UM": {Code: "UM", Name: "United States Minor Outlying Islands", Continent: ContinentOceania},
+	"US": {Code: "US", Name: "United States", Continent: ContinentNorthAmerica},
+	"UY": {Code: "UY", Name: "Uruguay", Continent: ContinentSouthAmerica},
+	"UZ": {Code: "UZ", Name: "Uzbekistan", Continent: ContinentAsia},
+	"VA": {Code: "VA", Name: "Vatican City", Continent: ContinentEurope},
+	"VC": {Code: "VC", Name: "Saint Vincent and the Grenadines", Continent: ContinentNorthAmerica},
+	"VE": {Code: "VE", Name: "Venezuela", Continent: ContinentSouthAmerica},
+	"VG": {Code: "VG", Name: "British Virgin Islands", Continent: ContinentNorthAmerica},
+	"VI": {Code: "VI", Name: "U.S. Virgin Islands", Continent: ContinentNorthAmerica},
+	"VN": {Code: "VN", Name: "Vietnam", Continent: ContinentAsia},
+	"VU": {Code: "VU", Name: "Vanuatu", Continent: ContinentOceania},
+	"WF": {Code: "WF", Name: "Wallis and Futuna", Continent: ContinentOceania},
+	"WS": {Code: "WS", Name: "Samoa", Continent: ContinentOceania},
+	"YE": {Code: "YE", Name: "Yemen", Continent: ContinentAsia},
+	"YT": {Code: "YT", Name: "Mayotte", Continent: ContinentAfrica},
+	"ZA": {Code: "ZA", Name: "South Africa", Continent: ContinentAfrica},
+	"ZM": {Code: "ZM", Name: "Zambia", Continent: ContinentAfrica},
+	"ZW": {Code: "ZW", Name: "Zimbabwe", Continent: ContinentAfrica},
+}
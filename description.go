@@ -0,0 +1,53 @@
+package libdnsrage4
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// DescriptionOf returns the Rage4 description of a record read from
+// Rage4 or set with WithDescription, and whether it has one.
+func DescriptionOf(record libdns.Record) (string, bool) {
+	data, _ := rage4Data(record)
+	if data.Description == nil {
+		return "", false
+	}
+	return *data.Description, true
+}
+
+// WithDescription returns record with its Rage4 description set,
+// keeping its other Rage4 settings, for passing to AppendRecords or
+// SetRecords. An empty description removes the record's description,
+// while records that set none keep the one they have in Rage4. Records
+// of types libdns has no struct for cannot carry settings and are
+// rejected.
+func WithDescription(record libdns.Record, description string) (libdns.Record, error) {
+	described, err := withSetting(record, settingDescription, func(data *Rage4Record) {
+		data.Description = &description
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot be described: %w", record.RR().Type, err)
+	}
+	return described, nil
+}
+
+// withDescription returns record with its Rage4 description set,
+// keeping its other settings. Records of types libdns has no struct for
+// cannot carry a description and are returned unchanged.
+func withDescription(record libdns.Record, description string) libdns.Record {
+	described, err := WithDescription(record, description)
+	if err != nil {
+		return record
+	}
+	return described
+}
+
+// keepDescription returns s with the description of existing if s sets
+// none: descriptions are only changed by records that set one
+func (s recordSettings) keepDescription(existing recordSettings) recordSettings {
+	if s.Description == nil {
+		s.Description = existing.Description
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRecordDescriptions(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+	p.RecordDescription = "managed-by:libdns"
+	p.ZoneDefaults = map[string]ZoneDefaults{"example.org.": {Description: "team-b"}}
+
+	www, err := WithDescription(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}, "web frontend")
+	if err != nil {
+		t.Fatalf("WithDescription failed: %v", err)
+	}
+	mail := libdns.RR{Name: "mail", Type: "A", Data: "192.0.2.2", TTL: time.Hour}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{www, mail}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.org.", []libdns.Record{mail}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	descriptions := make(map[string]string)
+	for _, zone := range []string{"example.com.", "example.org."} {
+		records, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+		for _, r := range records {
+			if description, ok := DescriptionOf(r); ok {
+				descriptions[r.RR().Name+"."+zone] = description
+			}
+		}
+	}
+	if descriptions["www.example.com."] != "web frontend" ||
+		descriptions["mail.example.com."] != "managed-by:libdns" ||
+		descriptions["mail.example.org."] != "team-b" {
+		t.Errorf("unexpected descriptions: %v", descriptions)
+	}
+
+	// records that set no description keep the one they have, also when
+	// they carry other settings
+	geo, err := WithGeo(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.10", TTL: time.Hour}, Geo{RegionID: 1})
+	if err != nil {
+		t.Fatalf("WithGeo failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{geo}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	stored := f.domainRecords(1)
+	if stored[0].Content != "192.0.2.10" || stored[0].Description == nil || *stored[0].Description != "web frontend" {
+		t.Errorf("expected the description to be kept, got %+v", stored[0])
+	}
+
+	updates := f.calls("UpdateRecord")
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{geo}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if f.calls("UpdateRecord") != updates {
+		t.Error("expected a record matching but for its kept description to be left alone")
+	}
+
+	// an empty description removes it
+	cleared, err := WithDescription(geo, "")
+	if err != nil {
+		t.Fatalf("WithDescription failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{cleared}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].Description == nil || *stored[0].Description != "" {
+		t.Errorf("expected the description to be cleared, got %+v", stored[0])
+	}
+
+	if _, err := WithDescription(libdns.RR{Name: "www", Type: "LOC", Data: "52 22 23 N 4 53 32 E -2m"}, "office"); err == nil {
+		t.Errorf("expected an error for a record type without provider data")
+	}
+}
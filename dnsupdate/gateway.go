@@ -0,0 +1,383 @@
+// Package dnsupdate implements a gateway that accepts RFC 2136 DNS
+// UPDATE messages, optionally authenticated with TSIG (RFC 8945), and
+// translates them into libdns calls. It lets systems that only speak
+// dynamic DNS, such as DHCP servers and Windows clients, update zones
+// hosted on Rage4 without modification.
+package dnsupdate
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// Provider is the set of libdns operations used by the gateway.
+// *libdnsrage4.Provider satisfies this interface.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordDeleter
+}
+
+// Gateway is a dns.Handler that applies UPDATE messages through a
+// libdns provider. Updates are not atomic: the provider deletes records
+// before adding the new ones, and an update that fails part way is
+// answered with SERVFAIL and leaves the changes already made in place.
+type Gateway struct {
+	// Provider applies the updates
+	Provider Provider
+
+	// Zones, if not empty, restricts updates to the listed zones
+	Zones []string
+
+	// AllowUnsigned accepts updates without a TSIG signature. Only
+	// enable this on trusted networks.
+	AllowUnsigned bool
+
+	// Timeout bounds the provider calls made for a single update; it
+	// defaults to 30 seconds
+	Timeout time.Duration
+
+	// OnError, if set, is called with failures that cannot be reported
+	// to the client beyond a response code, such as a recovered
+	// *libdnsrage4.PanicError or an *UpdateError
+	OnError func(err error)
+}
+
+// NewServer returns a DNS server for g listening on addr over network
+// ("udp" or "tcp"). secrets maps fully-qualified TSIG key names to their
+// base64-encoded secrets.
+func NewServer(addr, network string, g *Gateway, secrets map[string]string) *dns.Server {
+	return &dns.Server{
+		Addr:          addr,
+		Net:           network,
+		Handler:       g,
+		TsigSecret:    secrets,
+		MsgAcceptFunc: AcceptFunc,
+	}
+}
+
+// AcceptFunc is a dns.MsgAcceptFunc that, unlike the default, lets
+// UPDATE messages through. Use it for servers running a Gateway.
+func AcceptFunc(dh dns.Header) dns.MsgAcceptAction {
+	const qr = 1 << 15
+	if dh.Bits&qr != 0 {
+		return dns.MsgIgnore
+	}
+	if opcode := int(dh.Bits>>11) & 0xF; opcode != dns.OpcodeUpdate {
+		return dns.MsgRejectNotImplemented
+	}
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+	return dns.MsgAccept
+}
+
+// ServeDNS implements dns.Handler.
+func (g *Gateway) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetRcode(req, g.safeHandle(w, req))
+
+	// sign the response with the key of the request
+	if t := req.IsTsig(); t != nil && w.TsigStatus() == nil {
+		m.SetTsig(t.Hdr.Name, t.Algorithm, 300, time.Now().Unix())
+	}
+	w.WriteMsg(m)
+}
+
+// safeHandle calls handle, answering SERVFAIL instead of crashing the
+// server if processing a malformed update panics
+func (g *Gateway) safeHandle(w dns.ResponseWriter, req *dns.Msg) (rcode int) {
+	defer func() {
+		if v := recover(); v != nil {
+			g.reportError(&libdnsrage4.PanicError{Value: v, Stack: debug.Stack()})
+			rcode = dns.RcodeServerFailure
+		}
+	}()
+	return g.handle(w, req)
+}
+
+// handle processes an update and returns the response code
+func (g *Gateway) handle(w dns.ResponseWriter, req *dns.Msg) int {
+	if req.Opcode != dns.OpcodeUpdate {
+		return dns.RcodeNotImplemented
+	}
+	if len(req.Question) != 1 || req.Question[0].Qtype != dns.TypeSOA {
+		return dns.RcodeFormatError
+	}
+
+	if req.IsTsig() != nil {
+		if w.TsigStatus() != nil {
+			return dns.RcodeNotAuth
+		}
+	} else if !g.AllowUnsigned {
+		return dns.RcodeRefused
+	}
+
+	// NOTAUTH would be the RFC 2136 answer, but signed clients read it
+	// as a TSIG failure, so unmanaged zones are refused instead
+	zone := dns.CanonicalName(req.Question[0].Name)
+	if len(g.Zones) > 0 && !slices.ContainsFunc(g.Zones, func(z string) bool {
+		return dns.CanonicalName(z) == zone
+	}) {
+		return dns.RcodeRefused
+	}
+
+	// every name must be within the zone (RFC 2136 section 3.1.2 and 3.4.1.3)
+	for _, rr := range append(slices.Clone(req.Answer), req.Ns...) {
+		if !dns.IsSubDomain(zone, dns.CanonicalName(rr.Header().Name)) {
+			return dns.RcodeNotZone
+		}
+	}
+
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	existing, err := g.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return dns.RcodeServerFailure
+	}
+
+	if rcode := checkPrerequisites(req.Answer, existing, zone); rcode != dns.RcodeSuccess {
+		return rcode
+	}
+
+	adds, deletes, rcode := planUpdates(req.Ns, existing, zone)
+	if rcode != dns.RcodeSuccess {
+		return rcode
+	}
+
+	// the provider has no atomic multi-record change, so the deletions
+	// are made first and the additions after them; a failure part way
+	// is answered with SERVFAIL and reported with what was applied
+	var deleted []libdns.Record
+	if len(deletes) > 0 {
+		if deleted, err = g.Provider.DeleteRecords(ctx, zone, deletes); err != nil {
+			g.reportError(&UpdateError{Zone: zone, Deleted: deleted, Err: err})
+			return dns.RcodeServerFailure
+		}
+	}
+	if len(adds) > 0 {
+		if added, err := g.Provider.AppendRecords(ctx, zone, adds); err != nil {
+			g.reportError(&UpdateError{Zone: zone, Deleted: deleted, Added: added, Err: err})
+			return dns.RcodeServerFailure
+		}
+	}
+
+	return dns.RcodeSuccess
+}
+
+// reportError passes err to OnError, if set
+func (g *Gateway) reportError(err error) {
+	if g.OnError != nil {
+		g.OnError(err)
+	}
+}
+
+// UpdateError reports an update that failed after the provider was
+// called. Updates are not atomic: the records deleted and added before
+// the failure stay changed, and are listed so that they can be repaired.
+type UpdateError struct {
+	Zone    string
+	Deleted []libdns.Record
+	Added   []libdns.Record
+	Err     error
+}
+
+func (e *UpdateError) Error() string {
+	return fmt.Sprintf("update of %s failed after deleting %d and adding %d records: %v",
+		e.Zone, len(e.Deleted), len(e.Added), e.Err)
+}
+
+func (e *UpdateError) Unwrap() error { return e.Err }
+
+// checkPrerequisites evaluates the prerequisite section of an update
+// against the current records (RFC 2136 section 3.2)
+func checkPrerequisites(prereqs []dns.RR, existing []libdns.Record, zone string) int {
+	for _, rr := range prereqs {
+		hdr := rr.Header()
+		name := relativeName(hdr.Name, zone)
+		rrset := matching(existing, name, hdr.Rrtype)
+
+		switch hdr.Class {
+		case dns.ClassANY:
+			if hdr.Rrtype == dns.TypeANY {
+				if len(rrset) == 0 {
+					return dns.RcodeNameError
+				}
+			} else if len(rrset) == 0 {
+				return dns.RcodeNXRrset
+			}
+
+		case dns.ClassNONE:
+			if hdr.Rrtype == dns.TypeANY {
+				if len(rrset) > 0 {
+					return dns.RcodeYXDomain
+				}
+			} else if len(rrset) > 0 {
+				return dns.RcodeYXRrset
+			}
+
+		case dns.ClassINET:
+			// value-dependent: the RRset must match exactly
+			var want []libdns.Record
+			for _, p := range prereqs {
+				if p.Header().Class == dns.ClassINET && p.Header().Rrtype == hdr.Rrtype &&
+					dns.CanonicalName(p.Header().Name) == dns.CanonicalName(hdr.Name) {
+					record, err := libdnsrage4.RecordFromRR(p, zone)
+					if err != nil {
+						return dns.RcodeFormatError
+					}
+					want = append(want, record)
+				}
+			}
+			if !sameValues(rrset, want) {
+				return dns.RcodeNXRrset
+			}
+
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+	return dns.RcodeSuccess
+}
+
+// planUpdates turns the update section into records to add and existing
+// records to delete (RFC 2136 section 3.4). Updates are applied in
+// order: a later addition cancels an earlier deletion of the same
+// record, and a later deletion drops earlier additions it covers.
+func planUpdates(updates []dns.RR, existing []libdns.Record, zone string) ([]libdns.Record, []libdns.Record, int) {
+	var adds, deletes []libdns.Record
+	deleted := make(map[libdns.RR]bool)
+	remove := func(records []libdns.Record) {
+		for _, r := range records {
+			if !deleted[r.RR()] {
+				deleted[r.RR()] = true
+				deletes = append(deletes, r)
+			}
+		}
+	}
+	restore := func(record libdns.Record) {
+		if deleted[record.RR()] {
+			delete(deleted, record.RR())
+			deletes = slices.DeleteFunc(deletes, func(d libdns.Record) bool { return d.RR() == record.RR() })
+		}
+	}
+
+	for _, rr := range updates {
+		hdr := rr.Header()
+		name := relativeName(hdr.Name, zone)
+
+		switch hdr.Class {
+		case dns.ClassINET:
+			record, err := libdnsrage4.RecordFromRR(rr, zone)
+			if err != nil {
+				// SOA and apex NS changes are managed by Rage4 and ignored
+				continue
+			}
+			// a duplicate replaces the record with the same value, which
+			// may differ in TTL (RFC 2136 section 3.4.2.2)
+			adds = slices.DeleteFunc(adds, func(a libdns.Record) bool { return sameValue(a, record) })
+			i := slices.IndexFunc(existing, func(e libdns.Record) bool { return sameValue(e, record) })
+			switch {
+			case i < 0:
+				adds = append(adds, record)
+			case existing[i].RR().TTL == record.RR().TTL:
+				restore(existing[i])
+			default:
+				remove(existing[i : i+1])
+				adds = append(adds, record)
+			}
+
+		case dns.ClassANY:
+			if hdr.Rrtype == dns.TypeANY {
+				remove(slices.DeleteFunc(matching(existing, name, dns.TypeANY), func(r libdns.Record) bool {
+					return name == "@" && (r.RR().Type == "SOA" || r.RR().Type == "NS")
+				}))
+			} else if !(name == "@" && (hdr.Rrtype == dns.TypeSOA || hdr.Rrtype == dns.TypeNS)) {
+				remove(matching(existing, name, hdr.Rrtype))
+			}
+			adds = slices.DeleteFunc(adds, func(a libdns.Record) bool {
+				return len(matching([]libdns.Record{a}, name, hdr.Rrtype)) > 0
+			})
+
+		case dns.ClassNONE:
+			rr = dns.Copy(rr)
+			rr.Header().Class = dns.ClassINET
+			record, err := libdnsrage4.RecordFromRR(rr, zone)
+			if err != nil {
+				continue
+			}
+			remove(slices.DeleteFunc(matching(existing, name, hdr.Rrtype), func(e libdns.Record) bool {
+				return !sameValue(e, record)
+			}))
+			adds = slices.DeleteFunc(adds, func(a libdns.Record) bool { return sameValue(a, record) })
+
+		default:
+			return nil, nil, dns.RcodeFormatError
+		}
+	}
+
+	return adds, deletes, dns.RcodeSuccess
+}
+
+// matching returns the records with the given relative name and type;
+// TypeANY matches every type
+func matching(records []libdns.Record, name string, rrtype uint16) []libdns.Record {
+	var out []libdns.Record
+	for _, r := range records {
+		rr := r.RR()
+		if relativeName(rr.Name, "") == name && (rrtype == dns.TypeANY || rr.Type == dns.TypeToString[rrtype]) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// sameValue reports whether two records carry the same data, ignoring
+// TTLs
+func sameValue(a, b libdns.Record) bool {
+	ra, rb := a.RR(), b.RR()
+	return relativeName(ra.Name, "") == relativeName(rb.Name, "") &&
+		ra.Type == rb.Type && ra.Data == rb.Data
+}
+
+// sameValues reports whether two record sets contain the same data,
+// ignoring order and TTLs
+func sameValues(a, b []libdns.Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, r := range a {
+		if !slices.ContainsFunc(b, func(o libdns.Record) bool { return sameValue(r, o) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// relativeName returns name relative to zone in the form used by the
+// provider, with "@" for the apex; with an empty zone it only
+// normalizes the apex spelling
+func relativeName(name, zone string) string {
+	if zone != "" {
+		name = libdns.RelativeName(dns.CanonicalName(name), zone)
+	}
+	name = strings.ToLower(name)
+	if name == "" {
+		return "@"
+	}
+	return name
+}
@@ -0,0 +1,232 @@
+package dnsupdate
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+	"github.com/r6c/rage4/internal/memprovider"
+)
+
+const (
+	keyName = "dhcp-key."
+	secret  = "c2VjcmV0LXNoYXJlZC1ieS1kaGNwLWFuZC1nYXRld2F5"
+)
+
+func startGateway(t *testing.T, g *Gateway) string {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := NewServer("", "udp", g, map[string]string{keyName: secret})
+	s.PacketConn = pc
+	go s.ActivateAndServe()
+	t.Cleanup(func() { s.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func send(t *testing.T, addr string, m *dns.Msg, sign bool) int {
+	t.Helper()
+
+	c := &dns.Client{Timeout: 5 * time.Second}
+	if sign {
+		c.TsigSecret = map[string]string{keyName: secret}
+		m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+	}
+	in, _, err := c.Exchange(m, addr)
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	return in.Rcode
+}
+
+func mustRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("invalid record %q: %v", s, err)
+	}
+	return rr
+}
+
+func TestGatewayAddAndDelete(t *testing.T) {
+	p := &memprovider.Provider{}
+	addr := startGateway(t, &Gateway{Provider: p, Zones: []string{"example.com"}})
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert([]dns.RR{
+		mustRR(t, "host1.example.com. 300 IN A 192.0.2.10"),
+		mustRR(t, "host1.example.com. 300 IN TXT \"dhcid\""),
+	})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeSuccess {
+		t.Fatalf("insert failed: %s", dns.RcodeToString[rcode])
+	}
+	records, _ := p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 2 || records[0].RR().Name != "host1" || records[0].RR().Data != "192.0.2.10" {
+		t.Fatalf("unexpected records after insert: %+v", records)
+	}
+
+	// prerequisite: name must not exist yet
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.NameNotUsed([]dns.RR{mustRR(t, "host1.example.com. 0 IN A 0.0.0.0")})
+	m.Insert([]dns.RR{mustRR(t, "host1.example.com. 300 IN A 192.0.2.11")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeYXDomain {
+		t.Errorf("expected YXDOMAIN, got %s", dns.RcodeToString[rcode])
+	}
+
+	// replace the A record: delete the RRset, then add
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.RemoveRRset([]dns.RR{mustRR(t, "host1.example.com. 0 IN A 0.0.0.0")})
+	m.Insert([]dns.RR{mustRR(t, "host1.example.com. 300 IN A 192.0.2.11")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeSuccess {
+		t.Fatalf("replace failed: %s", dns.RcodeToString[rcode])
+	}
+	records, _ = p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 2 || records[1].RR().Data != "192.0.2.11" {
+		t.Errorf("unexpected records after replace: %+v", records)
+	}
+
+	// replace the A record with the same value, as a lease renewal does
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.RemoveRRset([]dns.RR{mustRR(t, "host1.example.com. 0 IN A 0.0.0.0")})
+	m.Insert([]dns.RR{mustRR(t, "host1.example.com. 300 IN A 192.0.2.11")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeSuccess {
+		t.Fatalf("same-value replace failed: %s", dns.RcodeToString[rcode])
+	}
+	records, _ = p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 2 || records[1].RR().Data != "192.0.2.11" {
+		t.Errorf("unexpected records after same-value replace: %+v", records)
+	}
+
+	// a duplicate with another TTL replaces the record
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert([]dns.RR{mustRR(t, "host1.example.com. 600 IN A 192.0.2.11")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeSuccess {
+		t.Fatalf("TTL change failed: %s", dns.RcodeToString[rcode])
+	}
+	records, _ = p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 2 || records[1].RR().Data != "192.0.2.11" || records[1].RR().TTL != 10*time.Minute {
+		t.Errorf("unexpected records after TTL change: %+v", records)
+	}
+
+	// delete a specific record
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Remove([]dns.RR{mustRR(t, "host1.example.com. 0 IN TXT \"dhcid\"")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeSuccess {
+		t.Fatalf("remove failed: %s", dns.RcodeToString[rcode])
+	}
+	records, _ = p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 1 || records[0].RR().Type != "A" {
+		t.Errorf("unexpected records after remove: %+v", records)
+	}
+}
+
+func TestGatewayRejects(t *testing.T) {
+	p := &memprovider.Provider{}
+	addr := startGateway(t, &Gateway{Provider: p, Zones: []string{"example.com."}})
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert([]dns.RR{mustRR(t, "host.example.com. 300 IN A 192.0.2.1")})
+	if rcode := send(t, addr, m, false); rcode != dns.RcodeRefused {
+		t.Errorf("expected REFUSED for unsigned update, got %s", dns.RcodeToString[rcode])
+	}
+
+	m = new(dns.Msg)
+	m.SetUpdate("example.net.")
+	m.Insert([]dns.RR{mustRR(t, "host.example.net. 300 IN A 192.0.2.1")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeRefused {
+		t.Errorf("expected REFUSED for foreign zone, got %s", dns.RcodeToString[rcode])
+	}
+
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert([]dns.RR{mustRR(t, "host.example.net. 300 IN A 192.0.2.1")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeNotZone {
+		t.Errorf("expected NOTZONE for out-of-zone record, got %s", dns.RcodeToString[rcode])
+	}
+
+	records, _ := p.GetRecords(context.Background(), "example.com.")
+	if len(records) != 0 {
+		t.Errorf("rejected updates must not change the zone: %+v", records)
+	}
+}
+
+// panickingProvider fails every lookup with a panic
+type panickingProvider struct {
+	memprovider.Provider
+}
+
+func (p *panickingProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	panic("malformed record")
+}
+
+func TestGatewayRecoversPanics(t *testing.T) {
+	reported := make(chan error, 1)
+	g := &Gateway{
+		Provider: &panickingProvider{},
+		OnError:  func(err error) { reported <- err },
+	}
+	addr := startGateway(t, g)
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Insert([]dns.RR{mustRR(t, "host.example.com. 300 IN A 192.0.2.1")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeServerFailure {
+		t.Errorf("expected SERVFAIL, got %s", dns.RcodeToString[rcode])
+	}
+
+	var perr *libdnsrage4.PanicError
+	if err := <-reported; !errors.As(err, &perr) || perr.Value != "malformed record" {
+		t.Errorf("expected the panic to be reported, got %v", err)
+	}
+}
+
+// appendFailingProvider fails every addition
+type appendFailingProvider struct {
+	memprovider.Provider
+}
+
+func (p *appendFailingProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return nil, errors.New("rate limited")
+}
+
+func TestGatewayReportsPartialUpdates(t *testing.T) {
+	p := &appendFailingProvider{}
+	p.Add("example.com.", libdns.Address{Name: "host1", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.10")})
+	reported := make(chan error, 1)
+	addr := startGateway(t, &Gateway{Provider: p, OnError: func(err error) { reported <- err }})
+
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.RemoveRRset([]dns.RR{mustRR(t, "host1.example.com. 0 IN A 0.0.0.0")})
+	m.Insert([]dns.RR{mustRR(t, "host1.example.com. 300 IN A 192.0.2.11")})
+	if rcode := send(t, addr, m, true); rcode != dns.RcodeServerFailure {
+		t.Errorf("expected SERVFAIL, got %s", dns.RcodeToString[rcode])
+	}
+
+	var uerr *UpdateError
+	select {
+	case err := <-reported:
+		if !errors.As(err, &uerr) || len(uerr.Deleted) != 1 || len(uerr.Added) != 0 {
+			t.Errorf("expected an UpdateError with one deleted record, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("partial update was not reported")
+	}
+}
@@ -0,0 +1,152 @@
+package libdnsrage4
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultDomainCacheTTL is how long domain IDs are cached when
+// DomainCacheTTL is not set
+const defaultDomainCacheTTL = 5 * time.Minute
+
+// domainCache maps zone names, without trailing dots, to the domains
+// holding them. The zero value is empty; it is filled whenever domains
+// are looked up or the domain list is read, and cleared by
+// InvalidateDomainCache.
+type domainCache struct {
+	mu  sync.Mutex
+	ids map[string]cachedDomain
+
+	// noLookupByName is set once Rage4 turns out not to offer
+	// GetDomainByName, so that the domain list is read instead
+	noLookupByName bool
+
+	// refreshing holds the zones being looked up in the background
+	refreshing map[string]bool
+}
+
+// cachedDomain is the domain holding a zone, which is the zone itself
+// or one of its parents, and when it was read
+type cachedDomain struct {
+	id      int64
+	name    string
+	fetched time.Time
+}
+
+// InvalidateDomainCache forgets the cached domain IDs, so that the next
+// operation reads the domain list again. Zones created or deleted through
+// the provider are picked up without it; it is only needed after zones
+// are changed elsewhere, for example in the Rage4 control panel.
+func (p *Provider) InvalidateDomainCache() {
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+
+	p.domains.ids = nil
+	p.domains.noLookupByName = false
+}
+
+// cachedDomain returns the cached domain holding a zone, if it is known
+// and the cache has not expired, and whether it is only served because
+// of DomainCacheStale and should be refreshed
+func (p *Provider) cachedDomain(zoneName string) (_ cachedDomain, ok, stale bool) {
+	p.mu.RLock()
+	ttl, staleFor := p.DomainCacheTTL, p.DomainCacheStale
+	p.mu.RUnlock()
+	if ttl == 0 {
+		ttl = defaultDomainCacheTTL
+	}
+
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+
+	cached, ok := p.domains.ids[zoneName]
+	if ttl < 0 || !ok {
+		return cachedDomain{}, false, false
+	}
+	switch age := time.Since(cached.fetched); {
+	case age <= ttl:
+		return cached, true, false
+	case staleFor > 0 && age <= ttl+staleFor:
+		return cached, true, true
+	}
+	return cachedDomain{}, false, false
+}
+
+// refreshDomain looks a zone served stale from the cache up again in
+// the background, once at a time per zone. The refresh is an operation
+// of its own, which Close cancels and waits for. If it fails, the entry
+// is left to expire, except that a zone found to be gone is forgotten at
+// once.
+func (p *Provider) refreshDomain(zoneName string) {
+	p.domains.mu.Lock()
+	if p.domains.refreshing[zoneName] {
+		p.domains.mu.Unlock()
+		return
+	}
+	if p.domains.refreshing == nil {
+		p.domains.refreshing = make(map[string]bool)
+	}
+	p.domains.refreshing[zoneName] = true
+	p.domains.mu.Unlock()
+
+	finish := func(gone bool) {
+		p.domains.mu.Lock()
+		defer p.domains.mu.Unlock()
+		delete(p.domains.refreshing, zoneName)
+		if gone {
+			delete(p.domains.ids, zoneName)
+		}
+	}
+
+	ctx, done, err := p.beginOp(background(p.backgroundContext()), "RefreshDomain", zoneName+".")
+	if err != nil {
+		finish(false)
+		return
+	}
+	go func() {
+		_, _, err := p.lookupDomain(ctx, zoneName)
+		finish(errors.Is(err, ErrZoneNotFound))
+		done(&err)
+	}()
+}
+
+// cacheDomains replaces the cached domain IDs with those of a freshly
+// read domain list
+func (p *Provider) cacheDomains(domains []DomainResponse) {
+	now := time.Now()
+	ids := make(map[string]cachedDomain, len(domains))
+	for _, domain := range domains {
+		ids[domain.Name] = cachedDomain{id: domain.ID, name: domain.Name, fetched: now}
+	}
+
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+
+	p.domains.ids = ids
+}
+
+// cacheDomain adds the domain found to hold a zone to the cache
+func (p *Provider) cacheDomain(zoneName string, domain DomainResponse) {
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+
+	if p.domains.ids == nil {
+		p.domains.ids = make(map[string]cachedDomain)
+	}
+	p.domains.ids[zoneName] = cachedDomain{id: domain.ID, name: domain.Name, fetched: time.Now()}
+}
+
+// lookupByName reports whether domains should be looked up by name
+func (p *Provider) lookupByName() bool {
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+	return !p.domains.noLookupByName
+}
+
+// disableLookupByName makes later lookups read the domain list
+func (p *Provider) disableLookupByName() {
+	p.domains.mu.Lock()
+	defer p.domains.mu.Unlock()
+	p.domains.noLookupByName = true
+}
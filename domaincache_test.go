@@ -0,0 +1,127 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDomainCache(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	for range 3 {
+		if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+	}
+	if n := f.calls("GetDomainByName"); n != 1 {
+		t.Errorf("expected the domain to be looked up once, got %d", n)
+	}
+
+	// a zone missing from the cache is looked up again
+	f.mu.Lock()
+	f.domains = append(f.domains, DomainResponse{ID: 2, Name: "example.org"})
+	f.mu.Unlock()
+	if _, err := p.GetRecords(ctx, "example.org."); err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if n := f.calls("GetDomainByName"); n != 2 {
+		t.Errorf("expected a cache miss to look the domain up, got %d lookups", n)
+	}
+
+	p.InvalidateDomainCache()
+	p.GetRecords(ctx, "example.com.")
+	if n := f.calls("GetDomainByName"); n != 3 {
+		t.Errorf("expected InvalidateDomainCache to force a lookup, got %d lookups", n)
+	}
+
+	p.DomainCacheTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	p.GetRecords(ctx, "example.com.")
+	if n := f.calls("GetDomainByName"); n != 4 {
+		t.Errorf("expected an expired cache to be refreshed, got %d lookups", n)
+	}
+
+	p.DomainCacheTTL = -1
+	p.GetRecords(ctx, "example.com.")
+	p.GetRecords(ctx, "example.com.")
+	if n := f.calls("GetDomainByName"); n != 6 {
+		t.Errorf("expected a negative TTL to disable the cache, got %d lookups", n)
+	}
+}
+
+func TestDomainLookupFallback(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+	f.noLookupByName = true
+
+	for _, zone := range []string{"example.com.", "example.org."} {
+		if _, err := p.GetRecords(ctx, zone); err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+	}
+	if _, err := p.GetRecords(ctx, "missing.example."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound, got %v", err)
+	}
+	if n, m := f.calls("GetDomainByName"), f.calls("GetDomains"); n != 1 || m != 2 {
+		t.Errorf("expected one failed lookup and then domain list reads, got %d lookups and %d reads", n, m)
+	}
+}
+
+func TestDomainLookupNotFoundStatus(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.notFoundStatus = true
+
+	// the subzone candidates are answered with 404 "domain not found"
+	if _, err := p.GetRecords(ctx, "a.b.example.com."); err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if _, err := p.GetRecords(ctx, "missing.example."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound, got %v", err)
+	}
+	if n, m := f.calls("GetDomainByName"), f.calls("GetDomains"); n != 4 || m != 0 {
+		t.Errorf("expected lookups by name only, got %d lookups and %d reads", n, m)
+	}
+}
+
+func TestDomainCacheStale(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.DomainCacheTTL = time.Nanosecond
+	p.DomainCacheStale = time.Hour
+
+	if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+
+	// the stale ID is used while the zone is looked up in the background,
+	// which finds it gone
+	f.mu.Lock()
+	f.domains = nil
+	f.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatalf("expected the stale domain ID to be used, got %v", err)
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		p.domains.mu.Lock()
+		refreshing := len(p.domains.refreshing)
+		p.domains.mu.Unlock()
+		if refreshing == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background lookup did not finish")
+		}
+	}
+	if n := f.calls("GetDomainByName"); n != 2 {
+		t.Errorf("expected one background lookup, got %d lookups", n)
+	}
+
+	if _, err := p.GetRecords(ctx, "example.com."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected the deleted zone to be forgotten, got %v", err)
+	}
+}
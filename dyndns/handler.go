@@ -0,0 +1,213 @@
+// Package dyndns implements the de-facto dyndns2 update protocol on top
+// of a libdns provider, so that routers, NAS boxes and other devices
+// with built-in dynamic DNS clients can update records hosted on Rage4.
+//
+// Clients send authenticated requests of the form
+//
+//	GET /nic/update?hostname=home.example.com&myip=192.0.2.1
+//
+// and receive one plain-text status line per hostname, such as
+// "good 192.0.2.1" or "nochg 192.0.2.1".
+package dyndns
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// maxHosts is the maximum number of hostnames accepted per request, as
+// in the original protocol
+const maxHosts = 20
+
+// Provider is the set of libdns operations used by the handler.
+// *libdnsrage4.Provider satisfies this interface.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordSetter
+}
+
+// User is an account allowed to update records.
+type User struct {
+	// Password authenticates the user
+	Password string
+
+	// Hostnames lists the fully-qualified names the user may update. An
+	// entry of the form "*.example.com" allows every name below
+	// example.com.
+	Hostnames []string
+}
+
+// Handler serves dyndns2 update requests.
+type Handler struct {
+	// Provider applies the updates
+	Provider Provider
+
+	// Zones lists the zones that updated names may belong to; each
+	// hostname is matched to the longest zone it falls within
+	Zones []string
+
+	// Users maps usernames to accounts
+	Users map[string]User
+
+	// TTL is the TTL of updated records; it defaults to 60 seconds
+	TTL time.Duration
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	username, password, ok := r.BasicAuth()
+	user, known := h.Users[username]
+	if !ok || !known || subtle.ConstantTimeCompare([]byte(password), []byte(user.Password)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="dyndns"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, "badauth")
+		return
+	}
+
+	hostnames := strings.Split(r.URL.Query().Get("hostname"), ",")
+	if len(hostnames) > maxHosts {
+		fmt.Fprintln(w, "numhost")
+		return
+	}
+
+	ips, err := requestIPs(r)
+	if err != nil {
+		fmt.Fprintln(w, "911")
+		return
+	}
+
+	for _, hostname := range hostnames {
+		fmt.Fprintln(w, h.update(r.Context(), user, strings.ToLower(strings.TrimSuffix(hostname, ".")), ips))
+	}
+}
+
+// update updates a single hostname and returns its status line
+func (h *Handler) update(ctx context.Context, user User, hostname string, ips []netip.Addr) string {
+	if !strings.Contains(hostname, ".") {
+		return "notfqdn"
+	}
+	if !allowed(user, hostname) {
+		return "nohost"
+	}
+
+	zone := h.zoneFor(hostname)
+	if zone == "" {
+		return "nohost"
+	}
+	name := libdns.RelativeName(hostname, zone)
+	if name == "" {
+		name = "@"
+	}
+
+	existing, err := h.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return "dnserr"
+	}
+
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = 60 * time.Second
+	}
+
+	var changes []libdns.Record
+	for _, ip := range ips {
+		record := libdns.Address{Name: name, TTL: ttl, IP: ip}
+		if !upToDate(existing, record) {
+			changes = append(changes, record)
+		}
+	}
+
+	status := "nochg"
+	if len(changes) > 0 {
+		if _, err := h.Provider.SetRecords(ctx, zone, changes); err != nil {
+			return "dnserr"
+		}
+		status = "good"
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return status + " " + strings.Join(addrs, ",")
+}
+
+// zoneFor returns the longest configured zone containing hostname
+func (h *Handler) zoneFor(hostname string) string {
+	best := ""
+	for _, zone := range h.Zones {
+		zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+		if (hostname == zone || strings.HasSuffix(hostname, "."+zone)) && len(zone) > len(best) {
+			best = zone
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return best + "."
+}
+
+// allowed reports whether user may update hostname
+func allowed(user User, hostname string) bool {
+	return slices.ContainsFunc(user.Hostnames, func(pattern string) bool {
+		pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			return strings.HasSuffix(hostname, "."+suffix)
+		}
+		return pattern == hostname
+	})
+}
+
+// upToDate reports whether the zone already holds exactly record as the
+// only record of its name and type
+func upToDate(existing []libdns.Record, record libdns.Record) bool {
+	want := record.RR()
+	var found []libdns.RR
+	for _, e := range existing {
+		if rr := e.RR(); rr.Name == want.Name && rr.Type == want.Type {
+			found = append(found, rr)
+		}
+	}
+	return len(found) == 1 && found[0].Data == want.Data
+}
+
+// requestIPs returns the addresses to update to: the comma-separated
+// myip parameter, or the client address if it is absent. At most one
+// IPv4 and one IPv6 address are accepted.
+func requestIPs(r *http.Request) ([]netip.Addr, error) {
+	param := r.URL.Query().Get("myip")
+	if param == "" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return nil, err
+		}
+		param = host
+	}
+
+	var ips []netip.Addr
+	var have4, have6 bool
+	for _, s := range strings.Split(param, ",") {
+		ip, err := netip.ParseAddr(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		ip = ip.Unmap()
+		if (ip.Is4() && have4) || (ip.Is6() && have6) {
+			return nil, fmt.Errorf("more than one address per family")
+		}
+		have4, have6 = have4 || ip.Is4(), have6 || ip.Is6()
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
@@ -0,0 +1,85 @@
+package dyndns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r6c/rage4/internal/memprovider"
+)
+
+func newTestHandler() (*Handler, *memprovider.Provider) {
+	p := &memprovider.Provider{}
+	return &Handler{
+		Provider: p,
+		Zones:    []string{"example.com.", "dyn.example.com"},
+		Users: map[string]User{
+			"router": {Password: "hunter2", Hostnames: []string{"home.example.com", "*.dyn.example.com"}},
+		},
+	}, p
+}
+
+func update(t *testing.T, h http.Handler, query, user, password string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/nic/update?"+query, nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestUpdate(t *testing.T) {
+	h, p := newTestHandler()
+
+	_, body := update(t, h, "hostname=home.example.com&myip=192.0.2.1", "router", "hunter2")
+	if body != "good 192.0.2.1" {
+		t.Fatalf("unexpected response: %q", body)
+	}
+	if got := p.Records("example.com."); len(got) != 1 || got[0].RR().Name != "home" || got[0].RR().Type != "A" {
+		t.Fatalf("unexpected records: %+v", got)
+	}
+
+	_, body = update(t, h, "hostname=home.example.com&myip=192.0.2.1", "router", "hunter2")
+	if body != "nochg 192.0.2.1" || p.Calls("SetRecords") != 1 {
+		t.Errorf("expected nochg without an update, got %q after %d sets", body, p.Calls("SetRecords"))
+	}
+
+	// dual-stack update in the deepest matching zone
+	_, body = update(t, h, "hostname=nas.dyn.example.com&myip=192.0.2.2,2001:db8::2", "router", "hunter2")
+	if body != "good 192.0.2.2,2001:db8::2" {
+		t.Fatalf("unexpected response: %q", body)
+	}
+	if got := p.Records("dyn.example.com."); len(got) != 2 || got[0].RR().Name != "nas" || got[1].RR().Type != "AAAA" {
+		t.Errorf("unexpected records: %+v", got)
+	}
+
+	// without myip the client address is used
+	_, body = update(t, h, "hostname=home.example.com", "router", "hunter2")
+	if body != "good 203.0.113.7" {
+		t.Errorf("unexpected response: %q", body)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	h, p := newTestHandler()
+
+	if code, body := update(t, h, "hostname=home.example.com", "router", "wrong"); code != http.StatusUnauthorized || body != "badauth" {
+		t.Errorf("expected badauth, got %d %q", code, body)
+	}
+	if _, body := update(t, h, "hostname=mail.example.com&myip=192.0.2.1", "router", "hunter2"); body != "nohost" {
+		t.Errorf("expected nohost, got %q", body)
+	}
+	if _, body := update(t, h, "hostname=localhost&myip=192.0.2.1", "router", "hunter2"); body != "notfqdn" {
+		t.Errorf("expected notfqdn, got %q", body)
+	}
+	if _, body := update(t, h, "hostname=home.example.com&myip=not-an-ip", "router", "hunter2"); body != "911" {
+		t.Errorf("expected 911, got %q", body)
+	}
+	if p.Calls("SetRecords") != 0 {
+		t.Errorf("failed requests must not update records")
+	}
+}
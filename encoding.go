@@ -0,0 +1,178 @@
+package libdnsrage4
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder serializes snapshots and exports, such as a *Snapshot or an
+// *Inventory, so that they can be handed to pipelines in the format they
+// already consume.
+type Encoder interface {
+	// Encode writes v to w
+	Encode(w io.Writer, v any) error
+}
+
+// The built-in encoders.
+var (
+	// JSONEncoder writes indented JSON, the format used throughout the
+	// package
+	JSONEncoder Encoder = jsonEncoder{}
+
+	// YAMLEncoder writes YAML with the same structure and field names
+	// as the JSON encoding
+	YAMLEncoder Encoder = yamlEncoder{}
+
+	// ZoneFileEncoder writes BIND zone files. It only encodes values
+	// that consist of records: a *Snapshot, or an *Inventory as one zone
+	// after another. Records that cannot be written in zone file syntax
+	// are listed in comments.
+	ZoneFileEncoder Encoder = zoneFileEncoder{}
+)
+
+// EncoderFor returns the built-in encoder for a format name: "json",
+// "yaml" or "zone".
+func EncoderFor(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "json", "":
+		return JSONEncoder, nil
+	case "yaml", "yml":
+		return YAMLEncoder, nil
+	case "zone", "bind":
+		return ZoneFileEncoder, nil
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// jsonEncoder implements JSONEncoder
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// yamlEncoder implements YAMLEncoder. Values are encoded to JSON first,
+// so that the JSON methods of types such as Records apply, and the JSON
+// is then rewritten as YAML in block style, keeping the order of fields.
+type yamlEncoder struct{}
+
+func (yamlEncoder) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to convert to YAML: %w", err)
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow style and quoting that JSON syntax gives
+// the node and its children; strings that need quotes to stay strings
+// keep them
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// zoneFileEncoder implements ZoneFileEncoder
+type zoneFileEncoder struct{}
+
+func (zoneFileEncoder) Encode(w io.Writer, v any) error {
+	var b bytes.Buffer
+	switch v := v.(type) {
+	case *Snapshot:
+		fmt.Fprintf(&b, "; %s taken %s\n", v.Zone, v.Taken.Format("2006-01-02T15:04:05Z07:00"))
+		writeZoneFile(&b, v.Zone, v.Records)
+
+	case *Inventory:
+		for i, zone := range v.Zones {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			records := make([]libdns.Record, 0, len(zone.Records))
+			for _, r := range zone.Records {
+				rr, err := fromRage4RR(r, zone.Name)
+				if err != nil {
+					fmt.Fprintf(&b, "; skipped %s %s record %d: %v\n", r.Name, r.Type, r.ID, err)
+					continue
+				}
+				records = append(records, withRage4Data(parseRecord(rr), r))
+			}
+			writeZoneFile(&b, zone.Name, records)
+		}
+
+	default:
+		return fmt.Errorf("cannot write %T as a zone file", v)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeZoneFile writes the records of zone in zone file syntax, with
+// their descriptions as comments
+func writeZoneFile(b *bytes.Buffer, zone string, records []libdns.Record) {
+	origin := dns.Fqdn(zone)
+	fmt.Fprintf(b, "$ORIGIN %s\n", origin)
+	for _, record := range records {
+		rr, err := safely(func() (dns.RR, error) {
+			return RecordToRR(record, origin)
+		})
+		if err != nil {
+			r := record.RR()
+			fmt.Fprintf(b, "; skipped %s %s %q: %v\n", normalizeName(r.Name), r.Type, r.Data, err)
+			continue
+		}
+		b.WriteString(rr.String())
+		if data, ok := rage4Data(record); ok && data.Description != nil && *data.Description != "" {
+			b.WriteString(" " + descriptionPrefix + quoteDescription(*data.Description))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// descriptionPrefix starts the comment that carries the description of
+// a record in zone files. The description follows as a quoted Go
+// string, so that it can hold any character.
+const descriptionPrefix = "; description: "
+
+// quoteDescription quotes a description for a zone file comment. Zone
+// file parsers split comments at semicolons, so those are escaped too.
+func quoteDescription(description string) string {
+	return strings.ReplaceAll(strconv.Quote(description), ";", `\x3b`)
+}
+
+// parseDescriptionComment returns the description in a comment written
+// by writeZoneFile; other comments, such as Cloudflare's cf_tags, are
+// not descriptions
+func parseDescriptionComment(comment string) (string, bool) {
+	quoted, ok := strings.CutPrefix(comment, descriptionPrefix)
+	if !ok {
+		return "", false
+	}
+	description, err := strconv.Unquote(strings.TrimSpace(quoted))
+	return description, err == nil
+}
@@ -0,0 +1,125 @@
+package libdnsrage4
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestEncoders(t *testing.T) {
+	s := &Snapshot{
+		Zone:  "example.com.",
+		Taken: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Records: Records{
+			recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}),
+			libdns.RR{Name: "@", Type: "MX", Data: "10 mail.example.com", TTL: time.Hour},
+			libdns.RR{Name: "odd", Type: "MX", Data: "mail.example.com", TTL: time.Hour},
+		},
+	}
+
+	for format, want := range map[string]string{
+		"json": `{
+  "zone": "example.com.",
+  "taken": "2024-01-01T00:00:00Z",
+  "records": [
+    {
+      "id": "1",
+      "name": "www",
+`,
+		"yaml": `zone: example.com.
+taken: "2024-01-01T00:00:00Z"
+records:
+  - id: "1"
+    name: www
+    ttl: 3600000000000
+    type: A
+    data: 192.0.2.1
+`,
+		"zone": `; example.com. taken 2024-01-01T00:00:00Z
+$ORIGIN example.com.
+www.example.com.	3600	IN	A	192.0.2.1
+example.com.	3600	IN	MX	10 mail.example.com.
+; skipped odd MX "mail.example.com": malformed MX data "mail.example.com"; expected '<preference> <target>'
+`,
+	} {
+		enc, err := EncoderFor(format)
+		if err != nil {
+			t.Fatalf("EncoderFor(%q) failed: %v", format, err)
+		}
+		var b strings.Builder
+		if err := enc.Encode(&b, s); err != nil {
+			t.Fatalf("%s: Encode failed: %v", format, err)
+		}
+		if !strings.HasPrefix(b.String(), want) {
+			t.Errorf("unexpected %s encoding:\n%s\nwant prefix:\n%s", format, b.String(), want)
+		}
+	}
+
+	if _, err := EncoderFor("xml"); err == nil {
+		t.Error("expected unknown formats to be rejected")
+	}
+	if err := ZoneFileEncoder.Encode(&strings.Builder{}, &ZoneDiff{}); err == nil {
+		t.Error("expected values without records to be rejected by the zone file encoder")
+	}
+}
+
+func TestInventoryZoneFile(t *testing.T) {
+	inv := &Inventory{Zones: []InventoryZone{
+		{ID: 1, Name: "example.com", Records: []Rage4Record{{ID: 7, Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300}}},
+		{ID: 2, Name: "example.org", Records: []Rage4Record{{ID: 8, Name: "example.org", Type: "TXT", Content: "hello", TTL: 300}}},
+	}}
+
+	var b strings.Builder
+	if err := inv.Write(&b, InventoryZoneFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	want := `$ORIGIN example.com.
+www.example.com.	300	IN	A	192.0.2.1
+
+$ORIGIN example.org.
+example.org.	300	IN	TXT	"hello"
+`
+	if b.String() != want {
+		t.Errorf("unexpected zone files:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestZoneFileDescriptions(t *testing.T) {
+	description := `owned by "web"; see runbook`
+	inv := &Inventory{Zones: []InventoryZone{{ID: 1, Name: "example.com", Records: []Rage4Record{
+		{ID: 7, Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300, Description: &description},
+		{ID: 8, Name: "example.com", Type: "TXT", Content: "hello", TTL: 300},
+	}}}}
+
+	var b strings.Builder
+	if err := inv.Write(&b, InventoryZoneFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	want := `$ORIGIN example.com.
+www.example.com.	300	IN	A	192.0.2.1 ; description: "owned by \"web\"\x3b see runbook"
+example.com.	300	IN	TXT	"hello"
+`
+	if b.String() != want {
+		t.Fatalf("unexpected zone file:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// the description is read back, and other comments are not taken
+	// for descriptions
+	result, err := ParseBIND(strings.NewReader(b.String()+"api 300 IN A 192.0.2.2 ; cf_tags=cf-proxied:true\n"), "example.com.")
+	if err != nil {
+		t.Fatalf("ParseBIND failed: %v", err)
+	}
+	if len(result.Records) != 3 {
+		t.Fatalf("expected 3 records, got %+v", result.Records)
+	}
+	if data, ok := rage4Data(result.Records[0]); !ok || data.Description == nil || *data.Description != description {
+		t.Errorf("expected the description to be read back, got %q", *data.Description)
+	}
+	for _, r := range result.Records[1:] {
+		if _, ok := rage4Data(r); ok {
+			t.Errorf("expected no description, got %+v", r)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package libdnsrage4
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors for the common ways a request fails, to be matched with
+// errors.Is. The *APIError reported by Rage4 matches the one that
+// describes it.
+var (
+	// ErrZoneNotFound is returned for zones that are not in the account
+	ErrZoneNotFound = errors.New("zone not found")
+
+	// ErrRecordNotFound is returned for records that do not exist, such
+	// as when updating or deleting a record by a stale ID
+	ErrRecordNotFound = errors.New("record not found")
+
+	// ErrAuthenticationFailed is returned when Rage4 rejects the email
+	// and API key
+	ErrAuthenticationFailed = errors.New("authentication failed")
+
+	// ErrRateLimited is returned when Rage4 still rejects a request for
+	// exceeding the rate limit after every allowed attempt
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is an error reported by the Rage4 API, either with a non-200
+// response or with an error object in a 200 response.
+type APIError struct {
+	// Method is the API method, such as "CreateRecord"
+	Method string `json:"method"`
+
+	StatusCode int `json:"status_code"`
+
+	// Message is the error Rage4 reported, or the response body if it
+	// did not report one
+	Message string `json:"message"`
+
+	// Body is the raw response body, cut at maxErrorBody bytes
+	Body string `json:"body,omitempty"`
+
+	// RequestID is the ID the response carried in an X-Request-Id
+	// header, if any, to quote when contacting Rage4 support
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// maxErrorBody is the most of a response body an APIError keeps
+const maxErrorBody = 4 << 10
+
+func (e *APIError) Error() string {
+	var msg string
+	if e.StatusCode != http.StatusOK {
+		msg = fmt.Sprintf("%s: received non-200 response: %d %s", e.Method, e.StatusCode, e.Message)
+	} else {
+		msg = fmt.Sprintf("%s: API returned error: %s", e.Method, e.Message)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return msg
+}
+
+// newAPIError returns the error for a failed response to method, with
+// message as reported by Rage4
+func newAPIError(resp *http.Response, method string, body []byte, message string) *APIError {
+	raw := string(bytes.TrimSpace(body))
+	if len(raw) > maxErrorBody {
+		raw = raw[:maxErrorBody]
+	}
+	return &APIError{
+		Method:     method,
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		Body:       raw,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+}
+
+// errorMessage returns the error of a non-200 response body, which is
+// either an envelope with an error message or plain text
+func errorMessage(body []byte) string {
+	if message, ok := envelopeError(body); ok {
+		return message
+	}
+	return string(bytes.TrimSpace(body))
+}
+
+// envelopeError returns the error message of body if it is an envelope
+// carrying one
+func envelopeError(body []byte) (string, bool) {
+	trimmed := bytes.TrimSpace(body)
+	var env envelope
+	if len(trimmed) > 0 && trimmed[0] == '{' && json.Unmarshal(trimmed, &env) == nil && env.Error != "" {
+		return env.Error, true
+	}
+	return "", false
+}
+
+// Is reports whether the error is described by one of ErrZoneNotFound,
+// ErrRecordNotFound, ErrAuthenticationFailed and ErrRateLimited.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrAuthenticationFailed:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrZoneNotFound:
+		return e.notFound() == "domain"
+	case ErrRecordNotFound:
+		return e.notFound() == "record"
+	}
+	return false
+}
+
+// notFound returns "domain" or "record" if the error reports that a
+// domain or a record does not exist, and "" otherwise. Rage4 names what
+// is missing in its message; if it does not, the method tells.
+func (e *APIError) notFound() string {
+	message := strings.ToLower(e.Message)
+	if e.StatusCode != http.StatusNotFound && !strings.Contains(message, "not found") {
+		return ""
+	}
+	switch {
+	case strings.Contains(message, "domain"), strings.Contains(message, "zone"):
+		return "domain"
+	case strings.Contains(message, "record"):
+		return "record"
+	case strings.Contains(e.Method, "Record"):
+		return "record"
+	case strings.Contains(e.Method, "Domain"):
+		return "domain"
+	}
+	return ""
+}
+
+// missingMethod reports whether the error is the answer of a server that
+// does not know the method: a 404, 405 or 501 without a Rage4 error
+// envelope, which Rage4 sends with the errors of methods it knows
+func (e *APIError) missingMethod() bool {
+	switch e.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		_, ok := envelopeError([]byte(e.Body))
+		return !ok
+	}
+	return false
+}
+
+// RateLimitError is returned when Rage4 rejects a request because the
+// account exceeded its rate limit, once the provider has given up
+// retrying it. It is usually wrapped in a *RetryError.
+type RateLimitError struct {
+	*APIError
+
+	// RetryAfter is how long Rage4 asked to wait before trying again,
+	// or zero if it did not say
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: rate limited, retry after %s", e.Method, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s: rate limited", e.Method)
+}
+
+// Unwrap returns the underlying APIError.
+func (e *RateLimitError) Unwrap() error {
+	return e.APIError
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a
+// number of seconds or an HTTP date, into the time to wait from now
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// envelope is the wrapper around the results of some endpoints. Every
+// endpoint may answer with an envelope carrying an error instead of its
+// usual result, even with status 200.
+type envelope struct {
+	Status *bool           `json:"status"`
+	Error  string          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// get calls an API method that only reads
+func (p *Provider) get(ctx context.Context, method string, params url.Values, out any) error {
+	return p.call(ctx, http.MethodGet, method, params, out)
+}
+
+// post calls an API method that makes changes, after checking the
+// quota of the tenant making them, and journaling the change if
+// JournalStore is set
+func (p *Provider) post(ctx context.Context, method string, params url.Values, out any) error {
+	counted, err := p.checkQuota(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	complete, err := p.journal(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	err = p.call(ctx, http.MethodPost, method, params, out)
+	complete(err)
+	counted(err)
+	return err
+}
+
+// call sends a request for an API method and decodes the result into
+// out, which may be nil. Errors reported by the API, in whatever form,
+// are returned as *APIError. Every endpoint goes through call, so none
+// can miss an error object, and every request is retried the same way;
+// see retryable.
+func (p *Provider) call(ctx context.Context, httpMethod, method string, params url.Values, out any) error {
+	p.mu.RLock()
+	maxAttempts, backoff := p.MaxAttempts, p.RetryBackoff
+	p.mu.RUnlock()
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	var (
+		attempts []Attempt
+		delay    time.Duration
+	)
+	for {
+		attempt := Attempt{Time: time.Now()}
+		attempt.StatusCode, attempt.Err = p.callOnce(ctx, httpMethod, method, params, out, delay)
+		attempts = append(attempts, attempt)
+		if attempt.Err == nil {
+			return nil
+		}
+
+		if len(attempts) == maxAttempts || !retryable(httpMethod, attempt.StatusCode, attempt.Err) || ctx.Err() != nil {
+			if len(attempts) == 1 {
+				return attempt.Err
+			}
+			return &RetryError{Method: method, Attempts: attempts}
+		}
+
+		delay = retryDelay(backoff, len(attempts))
+		if rateLimit := (*RateLimitError)(nil); errors.As(attempt.Err, &rateLimit) && rateLimit.RetryAfter > 0 {
+			delay = rateLimit.RetryAfter
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				// waiting cannot help before the caller gives up
+				return &RetryError{Method: method, Attempts: attempts}
+			}
+		}
+		attempts[len(attempts)-1].Wait = delay
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), &RetryError{Method: method, Attempts: attempts})
+		case <-timer.C:
+		}
+	}
+}
+
+// callOnce makes one attempt of call, after the given backoff delay,
+// and returns the status code of the response, or zero if none was
+// received
+func (p *Provider) callOnce(ctx context.Context, httpMethod, method string, params url.Values, out any, delay time.Duration) (int, error) {
+	req, err := p.newRequest(ctx, httpMethod, method, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.send(req, delay)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := decodeResponse(resp, method, out)
+	if err == nil {
+		p.checkSchema(method, data, out)
+	}
+	return resp.StatusCode, err
+}
+
+// decodeResponse reads the response to an API method into out, and
+// returns the data it was decoded from
+func decodeResponse(resp *http.Response, method string, out any) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{
+			APIError:   newAPIError(resp, method, body, errorMessage(body)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, method, body, errorMessage(body))
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var env envelope
+		if err := json.Unmarshal(trimmed, &env); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if env.Status != nil && !*env.Status {
+			message := env.Error
+			if message == "" {
+				message = "request failed"
+			}
+			return nil, newAPIError(resp, method, body, message)
+		}
+		if len(env.Data) > 0 && string(env.Data) != "null" {
+			body = env.Data
+		}
+	}
+
+	if out == nil {
+		return nil, nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,102 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCallEnvelopes(t *testing.T) {
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"/rapi/GetDomains":      {200, `{"status":true,"data":[{"id":7,"name":"example.com"}]}`},
+		"/rapi/GetRecords":      {200, `{"status":false,"error":"access denied"}`},
+		"/rapi/ListRecordTypes": {503, "maintenance\n"},
+		"/rapi/GetGeoRegions":   {400, `{"status":false,"error":"invalid parameters"}`},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := responses[r.URL.Path]
+		if r.URL.Path == "/rapi/GetGeoRegions" {
+			w.Header().Set("X-Request-Id", "abc123")
+		}
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	p := &Provider{Email: "user@example.com", APIKey: "secret", Endpoint: srv.URL + "/rapi"}
+
+	domains, err := p.listDomains(ctx)
+	if err != nil || len(domains) != 1 || domains[0].ID != 7 {
+		t.Errorf("expected the enveloped result to be unwrapped, got %+v, %v", domains, err)
+	}
+
+	var apiErr *APIError
+	_, err = p.GetRecords(ctx, "example.com.")
+	if !errors.As(err, &apiErr) || apiErr.Method != "GetRecords" || apiErr.StatusCode != 200 || apiErr.Message != "access denied" {
+		t.Errorf("expected an error object with status 200 to fail, got %v", err)
+	}
+
+	_, err = p.ListRecordTypes(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 || apiErr.Message != "maintenance" {
+		t.Errorf("expected a non-200 response to fail, got %v", err)
+	}
+
+	err = p.get(ctx, "GetGeoRegions", nil, nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Message != "invalid parameters" ||
+		apiErr.Body != `{"status":false,"error":"invalid parameters"}` || apiErr.RequestID != "abc123" {
+		t.Fatalf("expected the error details of a non-200 envelope, got %+v", apiErr)
+	}
+	if want := "GetGeoRegions: received non-200 response: 400 invalid parameters (request ID abc123)"; apiErr.Error() != want {
+		t.Errorf("expected %q, got %q", want, apiErr.Error())
+	}
+}
+
+func TestErrorValues(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+
+	if _, err := p.GetRecords(ctx, "missing.example."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound, got %v", err)
+	}
+
+	stale := libdns.Address{Name: "gone", IP: netip.MustParseAddr("192.0.2.1"), ProviderData: Rage4Record{ID: 999}}
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{stale}); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	p.APIKey = "wrong"
+	p.InvalidateDomainCache()
+	if _, err := p.GetRecords(ctx, "example.com."); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+
+	tests := []struct {
+		err    *APIError
+		target error
+		want   bool
+	}{
+		{&APIError{Method: "GetDomain", StatusCode: 200, Message: "domain not found"}, ErrZoneNotFound, true},
+		{&APIError{Method: "GetRecords", StatusCode: 200, Message: "Domain not found"}, ErrRecordNotFound, false},
+		{&APIError{Method: "UpdateRecord", StatusCode: 404}, ErrRecordNotFound, true},
+		{&APIError{Method: "UpdateRecord", StatusCode: 200, Message: "invalid content"}, ErrRecordNotFound, false},
+		{&APIError{Method: "GetDomains", StatusCode: 403}, ErrAuthenticationFailed, true},
+		{&APIError{Method: "GetDomains", StatusCode: 500}, ErrAuthenticationFailed, false},
+	}
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+		}
+	}
+	if !errors.Is(&RetryError{Method: "GetDomains", Attempts: []Attempt{{Err: &RateLimitError{APIError: &APIError{StatusCode: 429}}}}}, ErrRateLimited) {
+		t.Error("expected a rate limited RetryError to match ErrRateLimited")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// acme presents or cleans up the DNS-01 challenge token of domain, a
+// name in zone, as an ACME client's DNS hook would. With -wait, present
+// returns once the API lists the record.
+func acme(ctx context.Context, p *libdnsrage4.Provider, args []string, out io.Writer) (err error) {
+	fs := flag.NewFlagSet("acme", flag.ContinueOnError)
+	wait := fs.Duration("wait", 0, "how long to wait for the record to be listed")
+	metricsFile := fs.String("metrics-file", "", "write metrics to this file when done")
+	if len(args) == 0 {
+		return errUsage
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		return errUsage
+	}
+	defer writeMetricsFile(p, *metricsFile, &err)
+	zone, domain, token := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+
+	zoneName := strings.TrimSuffix(zone, ".") + "."
+	record := libdns.TXT{
+		Name: libdns.RelativeName("_acme-challenge."+strings.TrimSuffix(domain, ".")+".", zoneName),
+		TTL:  time.Minute,
+		Text: token,
+	}
+
+	switch action {
+	case "present":
+		if _, err := p.AppendRecords(ctx, zoneName, []libdns.Record{record}); err != nil {
+			return fmt.Errorf("failed to present challenge: %w", err)
+		}
+		if *wait > 0 {
+			ctx, cancel := context.WithTimeout(ctx, *wait)
+			defer cancel()
+			if _, err := p.GetRecordsConsistent(ctx, zoneName, libdnsrage4.ExpectPresent(record)); err != nil {
+				return fmt.Errorf("challenge not listed yet: %w", err)
+			}
+		}
+		fmt.Fprintf(out, "presented %s TXT %q\n", record.Name, token)
+
+	case "cleanup":
+		record.TTL = 0
+		if _, err := p.DeleteRecords(ctx, zoneName, []libdns.Record{record}); err != nil {
+			return fmt.Errorf("failed to clean up challenge: %w", err)
+		}
+		fmt.Fprintf(out, "cleaned up %s TXT %q\n", record.Name, token)
+
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// ddns points name in zone at the given addresses, as a dynamic DNS
+// updater run from cron would: the A and AAAA record sets of name are
+// replaced by one record per address, and left alone if they match.
+func ddns(ctx context.Context, p *libdnsrage4.Provider, args []string, out io.Writer) (err error) {
+	fs := flag.NewFlagSet("ddns", flag.ContinueOnError)
+	metricsFile := fs.String("metrics-file", "", "write metrics to this file when done")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if args = fs.Args(); len(args) < 3 {
+		return errUsage
+	}
+	defer writeMetricsFile(p, *metricsFile, &err)
+	zone, name := strings.TrimSuffix(args[0], ".")+".", args[1]
+
+	var records []libdns.Record
+	for _, arg := range args[2:] {
+		ip, err := netip.ParseAddr(arg)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", arg, err)
+		}
+		records = append(records, libdns.Address{Name: name, TTL: 5 * time.Minute, IP: ip.Unmap()})
+	}
+
+	cs, err := p.Plan(ctx, zone, records)
+	if err != nil {
+		return fmt.Errorf("failed to plan update: %w", err)
+	}
+	if cs.Empty() {
+		fmt.Fprintf(out, "%s is up to date\n", name)
+		return nil
+	}
+	if err := p.Apply(ctx, cs); err != nil {
+		return fmt.Errorf("failed to update %s: %w", name, err)
+	}
+	fmt.Fprintf(out, "updated %s to %s\n", name, strings.Join(args[2:], ", "))
+	return nil
+}
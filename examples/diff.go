@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// diff prints, as JSON, the changes that would make the record sets of
+// a BIND zone file live in zone, for review in a pull request or a
+// change management system. Nothing is changed.
+func diff(ctx context.Context, p *libdnsrage4.Provider, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	zone, path := args[0], args[1]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	result, err := libdnsrage4.ParseBIND(f, zone)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	cs, err := p.Plan(ctx, zone, result.Records)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s with %s: %w", path, zone, err)
+	}
+	return printJSON(out, cs)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// importZone imports a BIND zone file, such as an export from another
+// provider, replacing the record sets it contains. With -dry-run, it
+// prints the changes instead of making them.
+func importZone(ctx context.Context, p *libdnsrage4.Provider, args []string, out io.Writer) (err error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the changes without making them")
+	metricsFile := fs.String("metrics-file", "", "write metrics to this file when done")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	defer writeMetricsFile(p, *metricsFile, &err)
+	zone, path := fs.Arg(0), fs.Arg(1)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	result, err := libdnsrage4.ParseBIND(f, zone)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, s := range result.Skipped {
+		fmt.Fprintf(out, "skipped %s: %s\n", s.Record, s.Reason)
+	}
+
+	var cs *libdnsrage4.ChangeSet
+	if *dryRun {
+		cs, err = p.Plan(ctx, zone, result.Records)
+	} else {
+		cs, err = p.Import(ctx, zone, result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	verb := "were"
+	if *dryRun {
+		verb = "would be"
+	}
+	fmt.Fprintf(out, "%d records %s deleted and %d created\n", len(cs.Delete), verb, len(cs.Create))
+	return nil
+}
@@ -0,0 +1,99 @@
+// Command examples shows the provider at work in end-to-end flows, one
+// subcommand each:
+//
+//	examples acme present|cleanup [-wait 2m] [-metrics-file path] <zone> <domain> <token>
+//	examples import [-dry-run] [-metrics-file path] <zone> <zone file>
+//	examples ddns [-metrics-file path] <zone> <name> <address>...
+//	examples diff <zone> <zone file>
+//
+// The subcommands that make changes take -metrics-file, which writes the
+// provider's metrics to a file for the node exporter's textfile
+// collector once they are done, so that runs from cron or an ACME client
+// can be monitored.
+//
+// Credentials are read from RAGE4_EMAIL and RAGE4_API_KEY. Setting
+// RAGE4_ENDPOINT points the commands at another API, such as a
+// rage4test server, which is how the smoke tests run them.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// errUsage is returned for command lines that do not match a subcommand
+var errUsage = errors.New("usage: examples acme|import|ddns|diff [flags] <args>")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the subcommand named by args[0], writing its output to out
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	commands := map[string]func(context.Context, *libdnsrage4.Provider, []string, io.Writer) error{
+		"acme":   acme,
+		"import": importZone,
+		"ddns":   ddns,
+		"diff":   diff,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return errUsage
+	}
+
+	p, err := newProvider()
+	if err != nil {
+		return err
+	}
+	defer p.Close(context.WithoutCancel(ctx))
+	return command(ctx, p, args[1:], out)
+}
+
+// newProvider returns a provider configured from the environment
+func newProvider() (*libdnsrage4.Provider, error) {
+	p := &libdnsrage4.Provider{
+		Email:    os.Getenv("RAGE4_EMAIL"),
+		APIKey:   os.Getenv("RAGE4_API_KEY"),
+		Endpoint: os.Getenv("RAGE4_ENDPOINT"),
+	}
+	if p.Email == "" || p.APIKey == "" {
+		return nil, fmt.Errorf("RAGE4_EMAIL and RAGE4_API_KEY are required")
+	}
+	return p, nil
+}
+
+// writeMetricsFile writes the metrics of p to path, if set, once a
+// subcommand is done, whether or not it succeeded. A failure to write
+// them is returned in *err unless the subcommand failed itself.
+func writeMetricsFile(p *libdnsrage4.Provider, path string, err *error) {
+	if path == "" {
+		return
+	}
+	if werr := p.WriteMetricsFile(path); werr != nil && *err == nil {
+		*err = werr
+	}
+}
+
+// printJSON writes v to out as indented JSON
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
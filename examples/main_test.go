@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/r6c/rage4/rage4test"
+)
+
+// zoneFile is example.com as served by the default fixtures, with the
+// apex address changed
+const zoneFile = `$ORIGIN example.com.
+@     3600 IN A     192.0.2.2
+www   3600 IN AAAA  2001:db8::1
+blog  300  IN CNAME example.github.io.
+`
+
+// startAPI points the commands at a server replaying the default
+// fixtures, and returns it
+func startAPI(t *testing.T) *rage4test.Server {
+	t.Helper()
+	s, err := rage4test.NewServer(rage4test.Default(), rage4test.PublicKey)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	t.Cleanup(s.Close)
+
+	t.Setenv("RAGE4_EMAIL", "ci@example.com")
+	t.Setenv("RAGE4_API_KEY", "unused")
+	t.Setenv("RAGE4_ENDPOINT", s.Endpoint)
+	return s
+}
+
+func TestExamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.com.zone")
+	if err := os.WriteFile(path, []byte(zoneFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    []string
+		want    string
+		methods []string
+	}{
+		{[]string{"diff", "example.com.", path}, `"data": "192.0.2.2"`, nil},
+		{[]string{"import", "-dry-run", "example.com.", path}, "1 records would be deleted and 1 created", nil},
+		{[]string{"import", "example.com.", path}, "1 records were deleted and 1 created", []string{"DeleteRecord", "CreateRecord"}},
+		{[]string{"ddns", "example.com", "home", "192.0.2.7"}, "updated home to 192.0.2.7", []string{"CreateRecord"}},
+		{[]string{"acme", "present", "example.com.", "shop.example.com", "token"}, `presented _acme-challenge.shop TXT "token"`, []string{"CreateRecord"}},
+		{[]string{"acme", "cleanup", "example.com.", "shop.example.com", "token"}, "cleaned up", nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args[:2], " "), func(t *testing.T) {
+			s := startAPI(t)
+			var out bytes.Buffer
+			if err := run(context.Background(), tt.args, &out); err != nil {
+				t.Fatalf("%v failed: %v", tt.args, err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("%v: expected output containing %q, got %q", tt.args, tt.want, out.String())
+			}
+			for _, method := range tt.methods {
+				if !slices.Contains(s.Requests(), method) {
+					t.Errorf("%v: expected a %s request, got %v", tt.args, method, s.Requests())
+				}
+			}
+		})
+	}
+}
+
+func TestExamplesUsage(t *testing.T) {
+	startAPI(t)
+	for _, args := range [][]string{nil, {"unknown"}, {"acme", "renew", "example.com.", "example.com", "token"}, {"diff", "example.com."}} {
+		if err := run(context.Background(), args, &bytes.Buffer{}); !errors.Is(err, errUsage) {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+
+	t.Setenv("RAGE4_API_KEY", "")
+	if err := run(context.Background(), []string{"diff", "example.com.", "example.com.zone"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected missing credentials to be reported")
+	}
+}
+
+func TestExamplesMetricsFile(t *testing.T) {
+	startAPI(t)
+	path := filepath.Join(t.TempDir(), "rage4.prom")
+
+	args := []string{"ddns", "-metrics-file", path, "example.com", "home", "192.0.2.7"}
+	if err := run(context.Background(), args, &bytes.Buffer{}); err != nil {
+		t.Fatalf("%v failed: %v", args, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected metrics to be written: %v", err)
+	}
+	if !strings.Contains(string(data), `rage4_operations_total{operation="Apply"} 1`) {
+		t.Errorf("unexpected metrics: %s", data)
+	}
+}
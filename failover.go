@@ -0,0 +1,62 @@
+package libdnsrage4
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// Failover is the active/passive failover configuration of a record:
+// while Rage4's health checks find the record's own content down, Rage4
+// answers with Content instead, or stops answering with the record if
+// Withdraw is set.
+type Failover struct {
+	// Content is the passive answer, such as the address of a standby
+	// server
+	Content string `json:"content,omitempty"`
+
+	// Withdraw makes Rage4 leave the record out of answers while it is
+	// down, so that the other records of the set take over
+	Withdraw bool `json:"withdraw,omitempty"`
+
+	// Active reports whether the record is failed over, that is, whether
+	// Rage4 currently serves Content. It is only read from Rage4 and
+	// ignored by WithFailover.
+	Active bool `json:"active,omitempty"`
+}
+
+// FailoverOf returns the failover configuration and state of a record
+// read from Rage4 or set with WithFailover, and whether failover is
+// enabled for it.
+func FailoverOf(record libdns.Record) (Failover, bool) {
+	data, _ := rage4Data(record)
+	if !data.FailoverEnabled {
+		return Failover{}, false
+	}
+	f := Failover{Withdraw: data.FailoverWithdraw, Active: data.FailoverActive}
+	if data.FailoverContent != nil {
+		f.Content = *data.FailoverContent
+	}
+	return f, true
+}
+
+// WithFailover returns record with failover enabled as configured by f,
+// or disabled if f is nil, keeping its other Rage4 settings, for passing
+// to AppendRecords or SetRecords. Records of types libdns has no struct
+// for cannot carry settings and are rejected.
+func WithFailover(record libdns.Record, f *Failover) (libdns.Record, error) {
+	configured, err := withSetting(record, settingFailover, func(data *Rage4Record) {
+		data.FailoverEnabled, data.FailoverContent, data.FailoverWithdraw = false, nil, false
+		if f != nil {
+			content := f.Content
+			data.FailoverEnabled, data.FailoverWithdraw = true, f.Withdraw
+			if content != "" {
+				data.FailoverContent = &content
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot fail over: %w", record.RR().Type, err)
+	}
+	return configured, nil
+}
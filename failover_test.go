@@ -0,0 +1,68 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestFailoverRecords(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	primary, err := WithFailover(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}, &Failover{Content: "192.0.2.99"})
+	if err != nil {
+		t.Fatalf("WithFailover failed: %v", err)
+	}
+	secondary, err := WithFailover(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}, &Failover{Withdraw: true})
+	if err != nil {
+		t.Fatalf("WithFailover failed: %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{primary, secondary}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	// Rage4 fails the primary over
+	f.mu.Lock()
+	f.records[0].FailoverActive = true
+	f.mu.Unlock()
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	failovers := make(map[string]Failover)
+	for _, r := range records {
+		if fo, ok := FailoverOf(r); ok {
+			failovers[r.RR().Data] = fo
+		}
+	}
+	if fo := failovers["192.0.2.1"]; fo.Content != "192.0.2.99" || fo.Withdraw || !fo.Active {
+		t.Errorf("unexpected primary failover: %+v", fo)
+	}
+	if fo := failovers["192.0.2.2"]; fo.Content != "" || !fo.Withdraw || fo.Active {
+		t.Errorf("unexpected secondary failover: %+v", fo)
+	}
+
+	// disabling failover keeps the record's other settings
+	description := "web"
+	data, _ := rage4Data(records[0])
+	data.Description = &description
+	withDescription, err := WithFailover(withRage4Data(records[0], data), nil)
+	if err != nil {
+		t.Fatalf("WithFailover failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{withDescription, records[1]}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	stored := f.domainRecords(1)
+	if stored[0].FailoverEnabled || stored[0].FailoverContent != nil || stored[0].Description == nil || !stored[1].FailoverWithdraw {
+		t.Errorf("expected failover to be disabled on the primary only, got %+v", stored)
+	}
+
+	if _, err := WithFailover(libdns.RR{Name: "www", Type: "LOC", Data: "52 22 23 N 4 53 32 E -2m"}, &Failover{}); err == nil {
+		t.Errorf("expected an error for a record type without provider data")
+	}
+}
@@ -0,0 +1,440 @@
+package libdnsrage4
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeRage4 is an in-memory implementation of the subset of the Rage4
+// API used by the provider
+type fakeRage4 struct {
+	mu       sync.Mutex
+	email    string
+	apiKey   string
+	domains  []DomainResponse
+	records  []Rage4Record
+	webhooks []Webhook
+	nextID   int64
+	requests []string // method names, in order
+
+	// numericTypes makes GetRecords return numeric type IDs
+	numericTypes bool
+
+	// noLookupByName makes GetDomainByName unknown, as on older APIs
+	noLookupByName bool
+
+	// notFoundStatus makes GetDomainByName answer missing domains with
+	// a 404 instead of a 200, both with an error envelope
+	notFoundStatus bool
+
+	// domainSettings holds the parameters of the request that created
+	// each domain, or of its last UpdateDomain request
+	domainSettings map[int64]url.Values
+
+	// throttle, if set, rejects requests beyond its rate
+	throttle *fakeThrottle
+
+	// noGeo makes record requests with geo settings fail, as on plans
+	// without geo-routing
+	noGeo bool
+}
+
+// fakeThrottle makes a fake API answer 429 Too Many Requests to
+// requests beyond rate requests per second, with bursts of up to burst
+// requests, as Rage4 does. It sends no Retry-After, so that clients
+// fall back to their backoff, which keeps tests fast.
+type fakeThrottle struct {
+	rate  float64
+	burst int
+
+	tokens   float64
+	last     time.Time
+	accepted []time.Time // arrival times of the requests let through
+	rejected int
+}
+
+// allow reports whether a request arriving now is let through
+func (th *fakeThrottle) allow(now time.Time) bool {
+	if th.last.IsZero() {
+		th.tokens = float64(th.burst)
+	} else {
+		th.tokens = min(float64(th.burst), th.tokens+now.Sub(th.last).Seconds()*th.rate)
+	}
+	th.last = now
+
+	if th.tokens < 1 {
+		th.rejected++
+		return false
+	}
+	th.tokens--
+	th.accepted = append(th.accepted, now)
+	return true
+}
+
+// peak returns the most requests let through within any window
+func (th *fakeThrottle) peak(window time.Duration) int {
+	peak, start := 0, 0
+	for end, t := range th.accepted {
+		for t.Sub(th.accepted[start]) >= window {
+			start++
+		}
+		peak = max(peak, end-start+1)
+	}
+	return peak
+}
+
+// fakeRecordTypes is the type table served by ListRecordTypes
+var fakeRecordTypes = []RecordType{
+	{Name: "NS", ID: 1}, {Name: "A", ID: 2}, {Name: "AAAA", ID: 3}, {Name: "CNAME", ID: 4},
+	{Name: "MX", ID: 5}, {Name: "TXT", ID: 6}, {Name: "SRV", ID: 7},
+}
+
+// fakeGeoRegions is the region table served by ListGeoRegions
+var fakeGeoRegions = []GeoRegion{{Name: "Europe", ID: 1}, {Name: "North America", ID: 2}}
+
+// newFakeRage4 starts a fake API serving the given zones and returns it
+// with a provider configured to use it
+func newFakeRage4(t *testing.T, zones ...string) (*fakeRage4, *Provider) {
+	t.Helper()
+
+	f := &fakeRage4{email: "user@example.com", apiKey: "secret", nextID: 1000}
+	for i, zone := range zones {
+		f.domains = append(f.domains, DomainResponse{ID: int64(i + 1), Name: strings.TrimSuffix(zone, "."), Email: f.email})
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return f, &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi"}
+}
+
+// addRecord stores a record in the domain with the given ID and returns
+// its record ID
+func (f *fakeRage4) addRecord(domainID int64, r Rage4Record) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	r.ID = f.nextID
+	r.DomainID = domainID
+	r.IsActive = true
+	f.records = append(f.records, r)
+	return r.ID
+}
+
+// domainRecords returns the records of the domain with the given ID
+func (f *fakeRage4) domainRecords(domainID int64) []Rage4Record {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var records []Rage4Record
+	for _, r := range f.records {
+		if r.DomainID == domainID {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// calls returns how many requests were made to an API method
+func (f *fakeRage4) calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, m := range f.requests {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeRage4) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	method := strings.TrimPrefix(r.URL.Path, "/rapi/")
+	f.requests = append(f.requests, method)
+
+	if email, apiKey, ok := r.BasicAuth(); !ok || email != f.email || apiKey != f.apiKey {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if f.throttle != nil && !f.throttle.allow(time.Now()) {
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	if !strings.HasPrefix(method, "Get") && !strings.HasPrefix(method, "List") && r.Method != http.MethodPost {
+		http.Error(w, "mutations must be sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+
+	if f.noGeo && (method == "CreateRecord" || method == "UpdateRecord") &&
+		(r.Form.Has("geozone") || r.Form.Has("geolat") || r.Form.Has("geoasnum")) {
+		writeFakeJSON(w, CommonResponse{Error: "Geo features are not available on your plan"})
+		return
+	}
+
+	switch method {
+	case "GetDomains":
+		writeFakeJSON(w, f.domains)
+
+	case "GetDomainByName":
+		if f.noLookupByName {
+			http.NotFound(w, r)
+			return
+		}
+		for _, d := range f.domains {
+			if d.Name == r.FormValue("name") {
+				writeFakeJSON(w, d)
+				return
+			}
+		}
+		if f.notFoundStatus {
+			http.Error(w, `{"status":false,"error":"domain not found"}`, http.StatusNotFound)
+			return
+		}
+		writeFakeJSON(w, CommonResponse{Error: "domain not found"})
+
+	case "GetDomain":
+		for _, d := range f.domains {
+			if d.ID == id {
+				writeFakeJSON(w, d)
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "domain not found"})
+
+	case "CreateRegularDomain", "CreateRegularDomainExt", "CreateReverseDomain4", "CreateReverseDomain6":
+		name := r.FormValue("name")
+		for _, d := range f.domains {
+			if d.Name == name {
+				writeFakeJSON(w, CommonResponse{Error: "domain already exists"})
+				return
+			}
+		}
+		f.nextID++
+		domain := DomainResponse{ID: f.nextID, Name: name, Email: r.FormValue("email")}
+		f.domains = append(f.domains, domain)
+		if f.domainSettings == nil {
+			f.domainSettings = make(map[int64]url.Values)
+		}
+		f.domainSettings[domain.ID] = r.Form
+		nameservers := RegularNameservers
+		if r.FormValue("enablevanity") == "true" {
+			nameservers = VanityNameservers{Domain: r.FormValue("nsname"), Prefix: r.FormValue("nsprefix")}.hosts()
+		}
+		for _, ns := range nameservers {
+			f.nextID++
+			f.records = append(f.records, Rage4Record{
+				ID: f.nextID, DomainID: domain.ID, Name: name, Type: "NS", Content: ns, TTL: 86400, IsActive: true, IsSystem: true,
+			})
+		}
+		writeFakeJSON(w, CommonResponse{Status: true, ID: domain.ID})
+
+	case "UpdateDomain":
+		for i, d := range f.domains {
+			if d.ID == id {
+				if f.domainSettings == nil {
+					f.domainSettings = make(map[int64]url.Values)
+				}
+				f.domainSettings[id] = r.Form
+				f.domains[i].Email = r.FormValue("email")
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "domain not found"})
+
+	case "DeleteDomain":
+		for i, d := range f.domains {
+			if d.ID == id {
+				f.domains = append(f.domains[:i], f.domains[i+1:]...)
+				var kept []Rage4Record
+				for _, rec := range f.records {
+					if rec.DomainID != id {
+						kept = append(kept, rec)
+					}
+				}
+				f.records = kept
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "domain not found"})
+
+	case "GetRecords":
+		records := []any{}
+		for _, rec := range f.records {
+			if rec.DomainID != id {
+				continue
+			}
+			if !f.numericTypes {
+				records = append(records, rec)
+				continue
+			}
+			// shadow the mnemonic with the numeric ID
+			type plain Rage4Record
+			numeric := struct {
+				plain
+				Type int `json:"type"`
+			}{plain: plain(rec)}
+			for _, t := range fakeRecordTypes {
+				if t.Name == rec.Type {
+					numeric.Type = t.ID
+				}
+			}
+			records = append(records, numeric)
+		}
+		writeFakeJSON(w, records)
+
+	case "ListRecordTypes":
+		writeFakeJSON(w, fakeRecordTypes)
+
+	case "ListGeoRegions":
+		writeFakeJSON(w, fakeGeoRegions)
+
+	case "ListWebhooks":
+		webhooks := []Webhook{}
+		writeFakeJSON(w, append(webhooks, f.webhooks...))
+
+	case "CreateWebhook":
+		f.nextID++
+		f.webhooks = append(f.webhooks, Webhook{
+			ID:          f.nextID,
+			Name:        r.FormValue("name"),
+			URL:         r.FormValue("url"),
+			Payload:     r.FormValue("payload"),
+			ContentType: r.FormValue("contenttype"),
+		})
+		writeFakeJSON(w, CommonResponse{Status: true, ID: f.nextID})
+
+	case "DeleteWebhook":
+		for i, wh := range f.webhooks {
+			if wh.ID == id {
+				f.webhooks = append(f.webhooks[:i], f.webhooks[i+1:]...)
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "webhook not found"})
+
+	case "CreateRecord":
+		ttl, _ := strconv.Atoi(r.FormValue("ttl"))
+		priority, _ := strconv.Atoi(r.FormValue("priority"))
+		f.nextID++
+		rec := Rage4Record{
+			ID:       f.nextID,
+			DomainID: id,
+			Name:     r.FormValue("name"),
+			Content:  r.FormValue("content"),
+			Type:     r.FormValue("type"),
+			TTL:      ttl,
+			Priority: priority,
+			IsActive: true,
+		}
+		setFakeRecordOptions(&rec, r)
+		f.records = append(f.records, rec)
+		writeFakeJSON(w, CommonResponse{Status: true, ID: f.nextID})
+
+	case "UpdateRecord":
+		for i, rec := range f.records {
+			if rec.ID == id {
+				ttl, _ := strconv.Atoi(r.FormValue("ttl"))
+				priority, _ := strconv.Atoi(r.FormValue("priority"))
+				rec.Name = r.FormValue("name")
+				rec.Content = r.FormValue("content")
+				rec.TTL = ttl
+				rec.Priority = priority
+				setFakeRecordOptions(&rec, r)
+				f.records[i] = rec
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "record not found"})
+
+	case "SetRecordState":
+		for i, rec := range f.records {
+			if rec.ID == id {
+				f.records[i].IsActive = r.FormValue("active") == "true"
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "record not found"})
+
+	case "DeleteRecord":
+		for i, rec := range f.records {
+			if rec.ID == id {
+				f.records = append(f.records[:i], f.records[i+1:]...)
+				writeFakeJSON(w, CommonResponse{Status: true, ID: id})
+				return
+			}
+		}
+		writeFakeJSON(w, CommonResponse{Error: "record not found"})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// recordWithID returns rr in the typed form the provider returns, as if
+// read from Rage4 with the given record ID
+func recordWithID(id int64, rr libdns.RR) libdns.Record {
+	return withRage4Data(parseRecord(rr), Rage4Record{ID: id})
+}
+
+// setFakeRecordOptions sets the Rage4-specific settings of a created or
+// updated record from the request. Settings missing from the request
+// are reset, so that tests catch settings the provider fails to resend.
+func setFakeRecordOptions(rec *Rage4Record, r *http.Request) {
+	rec.GeoRegionID, _ = strconv.Atoi(r.FormValue("geozone"))
+	rec.GeoLat, rec.GeoLong, rec.GeoAsNum = nil, nil, nil
+	if r.Form.Has("geolat") && r.Form.Has("geolong") {
+		lat, _ := strconv.ParseFloat(r.FormValue("geolat"), 64)
+		long, _ := strconv.ParseFloat(r.FormValue("geolong"), 64)
+		rec.GeoLat, rec.GeoLong = &lat, &long
+	}
+	if r.Form.Has("geoasnum") {
+		asnum, _ := strconv.ParseInt(r.FormValue("geoasnum"), 10, 64)
+		rec.GeoAsNum = &asnum
+	}
+	rec.FailoverEnabled = r.FormValue("failover") == "true"
+	rec.FailoverWithdraw = r.FormValue("failoverwithdraw") == "true"
+	rec.FailoverContent, rec.Description = nil, nil
+	if r.Form.Has("failovercontent") {
+		content := r.FormValue("failovercontent")
+		rec.FailoverContent = &content
+	}
+	if r.Form.Has("description") {
+		description := r.FormValue("description")
+		rec.Description = &description
+	}
+	rec.Weight, _ = strconv.Atoi(r.FormValue("weight"))
+	rec.UDPLimit = r.FormValue("udplimit") == "true"
+	rec.WebhookID = nil
+	if r.Form.Has("webhookid") {
+		webhookID, _ := strconv.ParseInt(r.FormValue("webhookid"), 10, 64)
+		rec.WebhookID = &webhookID
+	}
+}
@@ -0,0 +1,170 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Feature is an optional Rage4 feature of records, which not every plan
+// offers.
+type Feature string
+
+const (
+	// FeatureGeo is geo-routing: geo regions, coordinates and AS
+	// numbers
+	FeatureGeo Feature = "geo"
+
+	// FeatureFailover is active/passive failover
+	FeatureFailover Feature = "failover"
+)
+
+// ErrFeatureUnavailable is returned, with StrictFeatures, for records
+// whose settings use a feature the account does not offer.
+var ErrFeatureUnavailable = errors.New("feature not available on this plan")
+
+// Degradation reports a record written without its settings of a
+// feature the account does not offer.
+type Degradation struct {
+	Feature Feature `json:"feature"`
+
+	// Zone is the zone of the record, without the trailing dot, and
+	// Name its full name
+	Zone string `json:"zone"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// featureTracker remembers the features Rage4 turned out not to offer
+// the account. The zero value assumes every feature is offered; it is
+// cleared by Reload.
+type featureTracker struct {
+	mu          sync.Mutex
+	unavailable map[Feature]bool
+}
+
+// isUnavailable reports whether feature is known not to be offered
+func (ft *featureTracker) isUnavailable(feature Feature) bool {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return ft.unavailable[feature]
+}
+
+// markUnavailable records that feature is not offered, and reports
+// whether that was news
+func (ft *featureTracker) markUnavailable(feature Feature) bool {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	if ft.unavailable[feature] {
+		return false
+	}
+	if ft.unavailable == nil {
+		ft.unavailable = make(map[Feature]bool)
+	}
+	ft.unavailable[feature] = true
+	return true
+}
+
+// reset forgets what was learned, for another account
+func (ft *featureTracker) reset() {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.unavailable = nil
+}
+
+// postRecord sends r with a CreateRecord or UpdateRecord request, its
+// settings added to params. Settings of features the account does not
+// offer are left out of r, with a call to OnDegraded, or fail the
+// request with StrictFeatures. A request Rage4 rejects because of such
+// a feature teaches the provider that the feature is unavailable, and
+// is sent again accordingly.
+func (p *Provider) postRecord(ctx context.Context, method, zoneName string, params url.Values, r *Rage4Record, result any) error {
+	for {
+		if err := p.degrade(zoneName, r); err != nil {
+			return err
+		}
+
+		withSettings := maps.Clone(params)
+		setRecordOptions(withSettings, *r)
+		err := p.post(ctx, method, withSettings, result)
+
+		feature, ok := unavailableFeature(err)
+		if !ok || !usesFeature(*r, feature) || !p.features.markUnavailable(feature) {
+			return err
+		}
+	}
+}
+
+// degrade strips the settings of unavailable features from r, or fails
+// with StrictFeatures
+func (p *Provider) degrade(zoneName string, r *Rage4Record) error {
+	p.mu.RLock()
+	strict, onDegraded := p.StrictFeatures, p.OnDegraded
+	p.mu.RUnlock()
+
+	for _, feature := range []Feature{FeatureGeo, FeatureFailover} {
+		if !usesFeature(*r, feature) || !p.features.isUnavailable(feature) {
+			continue
+		}
+		if strict {
+			return fmt.Errorf("%w: %s settings of %s %s", ErrFeatureUnavailable, feature, r.Name, r.Type)
+		}
+
+		withoutFeature(r, feature)
+		if onDegraded != nil {
+			onDegraded(Degradation{Feature: feature, Zone: zoneName, Name: r.Name, Type: r.Type})
+		}
+	}
+	return nil
+}
+
+// usesFeature reports whether r has settings of feature
+func usesFeature(r Rage4Record, feature Feature) bool {
+	switch feature {
+	case FeatureGeo:
+		return r.GeoRegionID != 0 || r.GeoLat != nil || r.GeoLong != nil || r.GeoAsNum != nil
+	case FeatureFailover:
+		return r.FailoverEnabled || r.FailoverContent != nil || r.FailoverWithdraw
+	}
+	return false
+}
+
+// withoutFeature clears the settings of feature on r
+func withoutFeature(r *Rage4Record, feature Feature) {
+	switch feature {
+	case FeatureGeo:
+		r.GeoRegionID, r.GeoLat, r.GeoLong, r.GeoAsNum = 0, nil, nil, nil
+	case FeatureFailover:
+		r.FailoverEnabled, r.FailoverContent, r.FailoverWithdraw = false, nil, false
+	}
+}
+
+// unavailableFeature returns the feature that an error Rage4 reported
+// says the account does not offer, if it says so: a 402 Payment
+// Required, or a message naming the feature as unavailable
+func unavailableFeature(err error) (Feature, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return "", false
+	}
+
+	msg := strings.ToLower(apiErr.Message)
+	if apiErr.StatusCode != http.StatusPaymentRequired &&
+		!strings.Contains(msg, "not available") && !strings.Contains(msg, "not enabled") &&
+		!strings.Contains(msg, "not supported") && !strings.Contains(msg, "upgrade") {
+		return "", false
+	}
+	switch {
+	case strings.Contains(msg, "failover"):
+		return FeatureFailover, true
+	case strings.Contains(msg, "geo"):
+		return FeatureGeo, true
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestFeatureDegradation(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.noGeo = true
+	var degraded []Degradation
+	p.OnDegraded = func(d Degradation) { degraded = append(degraded, d) }
+
+	var records []libdns.Record
+	for _, data := range []string{"192.0.2.1", "192.0.2.2"} {
+		r, err := WithGeo(libdns.RR{Name: "www", Type: "A", Data: data, TTL: time.Hour}, Geo{RegionID: 1})
+		if err != nil {
+			t.Fatalf("WithGeo failed: %v", err)
+		}
+		records = append(records, r)
+	}
+	created, err := p.AppendRecords(ctx, "example.com.", records)
+	if err != nil {
+		t.Fatalf("expected the records to be created without geo settings, got %v", err)
+	}
+	if len(created) != 2 || f.calls("CreateRecord") != 3 {
+		t.Errorf("expected one rejected request and two creations, got %d records after %d requests", len(created), f.calls("CreateRecord"))
+	}
+	if g, ok := GeoOf(created[0]); ok {
+		t.Errorf("expected the created record not to claim geo settings, got %+v", g)
+	}
+	if len(degraded) != 2 || degraded[0] != (Degradation{Feature: FeatureGeo, Zone: "example.com", Name: "www.example.com", Type: "A"}) {
+		t.Errorf("unexpected degradations: %+v", degraded)
+	}
+
+	caps, err := p.Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if caps.Geo || !caps.Failover {
+		t.Errorf("expected only geo to be reported unavailable, got %+v", caps)
+	}
+
+	p.StrictFeatures = true
+	if _, err := p.AppendRecords(ctx, "example.com.", records[:1]); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Errorf("expected ErrFeatureUnavailable, got %v", err)
+	}
+	if n := f.calls("CreateRecord"); n != 3 {
+		t.Errorf("expected the strict request not to be sent, got %d requests", n)
+	}
+}
@@ -0,0 +1,51 @@
+package libdnsrage4
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// Geo is the GeoDNS targeting of a record: Rage4 answers queries with
+// the records whose target best matches the client, by geo region, by
+// distance to a coordinate, or by the client's autonomous system. The
+// zero value targets everyone.
+type Geo struct {
+	// RegionID is the Rage4 geo region, as returned by GeoRegionID
+	RegionID int `json:"region_id,omitempty"`
+
+	// Lat and Long, if both set, target the clients closest to the
+	// coordinate
+	Lat  *float64 `json:"lat,omitempty"`
+	Long *float64 `json:"long,omitempty"`
+
+	// ASNum, if set, targets the clients in the autonomous system
+	ASNum *int64 `json:"asnum,omitempty"`
+}
+
+// IsZero reports whether g targets everyone.
+func (g Geo) IsZero() bool {
+	return g.RegionID == 0 && g.Lat == nil && g.Long == nil && g.ASNum == nil
+}
+
+// GeoOf returns the geo targeting of a record read from Rage4 or set
+// with WithGeo, and whether it is geo-targeted at all.
+func GeoOf(record libdns.Record) (Geo, bool) {
+	data, _ := rage4Data(record)
+	g := Geo{RegionID: data.GeoRegionID, Lat: data.GeoLat, Long: data.GeoLong, ASNum: data.GeoAsNum}
+	return g, !g.IsZero()
+}
+
+// WithGeo returns record with its geo targeting set to g, keeping its
+// other Rage4 settings, for passing to AppendRecords or SetRecords.
+// Records of types libdns has no struct for cannot carry settings and
+// are rejected.
+func WithGeo(record libdns.Record, g Geo) (libdns.Record, error) {
+	targeted, err := withSetting(record, settingGeo, func(data *Rage4Record) {
+		data.GeoRegionID, data.GeoLat, data.GeoLong, data.GeoAsNum = g.RegionID, g.Lat, g.Long, g.ASNum
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot be geo-targeted: %w", record.RR().Type, err)
+	}
+	return targeted, nil
+}
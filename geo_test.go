@@ -0,0 +1,70 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestGeoRecords(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+
+	europe, err := p.GeoRegionID(ctx, "Europe")
+	if err != nil {
+		t.Fatalf("GeoRegionID failed: %v", err)
+	}
+	lat, long, asnum := 52.37, 4.89, int64(64496)
+
+	regional, err := WithGeo(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}, Geo{RegionID: europe})
+	if err != nil {
+		t.Fatalf("WithGeo failed: %v", err)
+	}
+	nearby, err := WithGeo(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}, Geo{Lat: &lat, Long: &long, ASNum: &asnum})
+	if err != nil {
+		t.Fatalf("WithGeo failed: %v", err)
+	}
+	fallback := libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3", TTL: time.Hour}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{regional, nearby, fallback}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	geos := make(map[string]Geo)
+	for _, r := range records {
+		if g, ok := GeoOf(r); ok {
+			geos[r.RR().Data] = g
+		}
+	}
+	if len(geos) != 2 || geos["192.0.2.1"].RegionID != europe {
+		t.Fatalf("expected two geo-targeted records, got %+v", geos)
+	}
+	if g := geos["192.0.2.2"]; g.Lat == nil || *g.Lat != lat || *g.Long != long || g.ASNum == nil || *g.ASNum != asnum {
+		t.Errorf("expected the coordinate and AS number to be read back, got %+v", g)
+	}
+
+	// the AS number survives JSON like the other settings
+	encoded, err := json.Marshal(Records(records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Records
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to decode %s: %v", encoded, err)
+	}
+	for _, r := range decoded {
+		if g, _ := GeoOf(r); r.RR().Data == "192.0.2.2" && (g.ASNum == nil || *g.ASNum != asnum) {
+			t.Errorf("expected the AS number to survive JSON, got %s", encoded)
+		}
+	}
+
+	if _, err := WithGeo(libdns.RR{Name: "www", Type: "LOC", Data: "52 22 23 N 4 53 32 E -2m"}, Geo{RegionID: europe}); err == nil {
+		t.Errorf("expected an error for a record type without provider data")
+	}
+}
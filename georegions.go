@@ -0,0 +1,129 @@
+package libdnsrage4
+
+//go:generate go run ./internal/geogen -o countries_gen.go
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// GeoRegion is a Rage4 geo region, which records can be limited to by
+// setting its ID as their GeoRegionID.
+type GeoRegion struct {
+	Name string `json:"name"`
+	ID   int    `json:"value"`
+}
+
+// geoRegionCache holds the geo regions of the account. The zero value
+// is empty; it is filled on first use and cleared by Reload.
+type geoRegionCache struct {
+	mu      sync.Mutex
+	regions []GeoRegion
+}
+
+// ListGeoRegions returns the geo regions supported by Rage4. The list is
+// fetched once and cached; callers get a copy of it.
+func (p *Provider) ListGeoRegions(ctx context.Context) ([]GeoRegion, error) {
+	p.geoRegions.mu.Lock()
+	defer p.geoRegions.mu.Unlock()
+
+	if p.geoRegions.regions != nil {
+		return slices.Clone(p.geoRegions.regions), nil
+	}
+
+	var regions []GeoRegion
+	if err := p.get(ctx, "ListGeoRegions", nil, &regions); err != nil {
+		return nil, fmt.Errorf("failed to list geo regions: %w", err)
+	}
+
+	p.geoRegions.regions = regions
+	return slices.Clone(regions), nil
+}
+
+// GeoRegionID returns the ID of a geo region by name, so that code
+// configuring geo records can name regions instead of repeating their
+// IDs. Names match ignoring case, spaces, hyphens and underscores, so
+// "US-East" finds the region "US East".
+func (p *Provider) GeoRegionID(ctx context.Context, name string) (int, error) {
+	regions, err := p.ListGeoRegions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	key := geoRegionKey(name)
+	for _, r := range regions {
+		if geoRegionKey(r.Name) == key {
+			return r.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown geo region: %s", name)
+}
+
+// GeoRegionName returns the name of a geo region ID.
+func (p *Provider) GeoRegionName(ctx context.Context, id int) (string, error) {
+	regions, err := p.ListGeoRegions(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range regions {
+		if r.ID == id {
+			return r.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown geo region ID: %d", id)
+}
+
+// Continent is a continent, spelled as geo regions covering it are
+// named. The constants are generated from internal/geogen/countries.tsv.
+type Continent string
+
+// Country is a country or territory with an ISO 3166-1 code, and the
+// continent it belongs to.
+type Country struct {
+	Code      string
+	Name      string
+	Continent Continent
+}
+
+// LookupCountry returns the country with the given ISO 3166-1 alpha-2
+// code, such as "DE", in either case.
+func LookupCountry(code string) (Country, bool) {
+	c, ok := countries[strings.ToUpper(code)]
+	return c, ok
+}
+
+// GeoRegionForCountry returns the geo region serving the country with
+// the given ISO 3166-1 alpha-2 code: the region named after the country
+// if Rage4 offers one, and the region of its continent otherwise.
+func (p *Provider) GeoRegionForCountry(ctx context.Context, code string) (GeoRegion, error) {
+	country, ok := LookupCountry(code)
+	if !ok {
+		return GeoRegion{}, fmt.Errorf("unknown country code: %s", code)
+	}
+	regions, err := p.ListGeoRegions(ctx)
+	if err != nil {
+		return GeoRegion{}, err
+	}
+	for _, name := range []string{country.Name, string(country.Continent)} {
+		key := geoRegionKey(name)
+		if i := slices.IndexFunc(regions, func(r GeoRegion) bool { return geoRegionKey(r.Name) == key }); i >= 0 {
+			return regions[i], nil
+		}
+	}
+	return GeoRegion{}, fmt.Errorf("no geo region for %s or %s", country.Name, country.Continent)
+}
+
+// geoRegionKey returns the form of a region name that GeoRegionID
+// matches: lower case, without separators
+func geoRegionKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
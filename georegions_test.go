@@ -0,0 +1,64 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGeoRegions(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t)
+
+	if id, err := p.GeoRegionID(ctx, "north america"); err != nil || id != 2 {
+		t.Errorf("unexpected ID for North America: %d, %v", id, err)
+	}
+	for _, name := range []string{"North-America", "north_america", " NorthAmerica"} {
+		if id, err := p.GeoRegionID(ctx, name); err != nil || id != 2 {
+			t.Errorf("unexpected ID for %q: %d, %v", name, id, err)
+		}
+	}
+	if name, err := p.GeoRegionName(ctx, 1); err != nil || name != "Europe" {
+		t.Errorf("unexpected name for region 1: %q, %v", name, err)
+	}
+	if _, err := p.GeoRegionID(ctx, "Atlantis"); err == nil {
+		t.Error("expected an unknown region to be rejected")
+	}
+	if n := f.calls("ListGeoRegions"); n != 1 {
+		t.Errorf("expected the region table to be fetched once, got %d", n)
+	}
+}
+
+func TestGeoRegionForCountry(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t)
+
+	if c, ok := LookupCountry("pl"); !ok || c.Name != "Poland" || c.Continent != ContinentEurope {
+		t.Errorf("unexpected country for pl: %+v, %v", c, ok)
+	}
+	if r, err := p.GeoRegionForCountry(ctx, "DE"); err != nil || r.ID != 1 {
+		t.Errorf("expected Germany to be served by Europe, got %+v, %v", r, err)
+	}
+	if r, err := p.GeoRegionForCountry(ctx, "us"); err != nil || r.ID != 2 {
+		t.Errorf("expected the United States to be served by North America, got %+v, %v", r, err)
+	}
+	if _, err := p.GeoRegionForCountry(ctx, "JP"); err == nil {
+		t.Error("expected a country without a region to be reported")
+	}
+	if _, err := p.GeoRegionForCountry(ctx, "XX"); err == nil {
+		t.Error("expected an unknown country code to be rejected")
+	}
+}
+
+func TestListGeoRegionsReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t)
+
+	regions, err := p.ListGeoRegions(ctx)
+	if err != nil {
+		t.Fatalf("ListGeoRegions failed: %v", err)
+	}
+	regions[0].Name = "Changed"
+	if name, err := p.GeoRegionName(ctx, regions[0].ID); err != nil || name == "Changed" {
+		t.Errorf("expected the cached regions to be unaffected, got %q, %v", name, err)
+	}
+}
@@ -0,0 +1,60 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthFreshness is how long a successful operation vouches for the
+// provider, sparing Healthy an API request
+const healthFreshness = time.Minute
+
+// Healthy reports whether the provider can currently reach Rage4 with
+// valid credentials. It returns nil without contacting Rage4 if the
+// most recent operation succeeded within the last minute, and otherwise
+// probes the API with a lightweight request, so frequent liveness and
+// readiness probes cost at most one request per minute while things
+// work. A closed provider is unhealthy.
+func (p *Provider) Healthy(ctx context.Context) (err error) {
+	p.opsMu.Lock()
+	closed := p.closed
+	p.opsMu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	total := p.Stats().Total
+	if time.Since(total.LastSuccess) < healthFreshness && !total.LastError.After(total.LastSuccess) {
+		return nil
+	}
+
+	ctx, done, err := p.beginOp(ctx, "Healthy", "")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	// listing domains exercises the network path and the credentials
+	if _, err := p.listDomains(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
+// HealthHandler returns an HTTP handler for probes that answers 200 if
+// check succeeds and 503 with the error otherwise, for example
+//
+//	mux.Handle("GET /healthz", libdnsrage4.HealthHandler(provider.Healthy))
+func HealthHandler(check func(ctx context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := check(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,57 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthy(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	if err := p.Healthy(ctx); err != nil {
+		t.Fatalf("expected a healthy provider, got %v", err)
+	}
+	if err := p.Healthy(ctx); err != nil {
+		t.Fatalf("expected a healthy provider, got %v", err)
+	}
+	if n := f.calls("GetDomains"); n != 1 {
+		t.Errorf("expected a recent success to spare the probe, got %d probes", n)
+	}
+
+	// a failure forces a probe, which detects the bad credentials
+	p.APIKey = "wrong"
+	if _, err := p.GetRecords(ctx, "example.com."); err == nil {
+		t.Fatal("expected bad credentials to fail")
+	}
+	if err := p.Healthy(ctx); err == nil {
+		t.Error("expected bad credentials to be unhealthy")
+	}
+
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := p.Healthy(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("down"), http.StatusServiceUnavailable},
+	} {
+		h := HealthHandler(func(context.Context) error { return tc.err })
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+		if rec.Code != tc.code {
+			t.Errorf("expected %d for %v, got %d", tc.code, tc.err, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrNoSnapshot is returned by History when no snapshot old enough to
+// answer a query has been recorded.
+var ErrNoSnapshot = errors.New("no snapshot available")
+
+// History records periodic snapshots of zones so that past states can be
+// queried, since Rage4 itself keeps no point-in-time history.
+type History struct {
+	// Provider is used to capture snapshots
+	Provider *Provider
+
+	// Store persists the captured snapshots, under keys of the form
+	// "snapshots/<zone>/<time>"
+	Store Store
+
+	// Interval is the time between snapshots taken by Run
+	Interval time.Duration
+
+	// OnError, if set, is called when Run fails to capture or store a
+	// snapshot; Run keeps going regardless
+	OnError func(zone string, err error)
+}
+
+// Capture takes a snapshot of zone and stores it. Snapshots are taken
+// with PriorityBackground unless ctx carries a priority.
+func (h *History) Capture(ctx context.Context, zone string) (*Snapshot, error) {
+	ctx = background(ctx)
+
+	s, err := h.Provider.Snapshot(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture snapshot: %w", err)
+	}
+
+	if err := h.save(ctx, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Run captures a snapshot of every zone immediately and then once per
+// Interval, until ctx is cancelled.
+func (h *History) Run(ctx context.Context, zones []string) error {
+	if h.Interval <= 0 {
+		return fmt.Errorf("history interval must be positive")
+	}
+
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, zone := range zones {
+			if _, err := h.Capture(ctx, zone); err != nil && h.OnError != nil {
+				h.OnError(zone, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// At returns the most recent snapshot of zone taken at or before t.
+func (h *History) At(ctx context.Context, zone string, t time.Time) (*Snapshot, error) {
+	keys, err := h.Store.List(ctx, snapshotPrefix(zone))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	// keys sort chronologically, so the newest match is the last one not
+	// after t
+	limit := snapshotKey(zone, t)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i] > limit {
+			continue
+		}
+		data, err := h.Store.Get(ctx, keys[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		var s Snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot %s: %w", keys[i], err)
+		}
+		return &s, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s at %s", ErrNoSnapshot, zone, t.Format(time.RFC3339))
+}
+
+// RecordAt returns the records with the given relative name and type as
+// they were at time t. An empty recordType matches every type.
+func (h *History) RecordAt(ctx context.Context, zone, name, recordType string, t time.Time) ([]libdns.Record, error) {
+	s, err := h.At(ctx, zone, t)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []libdns.Record
+	for _, r := range s.Records {
+		if rr := r.RR(); normalizeName(rr.Name) == normalizeName(name) && (recordType == "" || rr.Type == recordType) {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// Diff compares the state of zone at two points in time.
+func (h *History) Diff(ctx context.Context, zone string, from, to time.Time) (*ZoneDiff, error) {
+	a, err := h.At(ctx, zone, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := h.At(ctx, zone, to)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(a, b), nil
+}
+
+// save stores a snapshot
+func (h *History) save(ctx context.Context, s *Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := h.Store.Put(ctx, snapshotKey(s.Zone, s.Taken), data); err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return nil
+}
+
+// snapshotPrefix returns the storage key prefix of the snapshots of zone
+func snapshotPrefix(zone string) string {
+	return "snapshots/" + strings.TrimSuffix(zone, ".") + "/"
+}
+
+// snapshotKey returns the storage key of a snapshot of zone taken at t.
+// The fixed-width UTC timestamp makes keys sort chronologically.
+func snapshotKey(zone string, t time.Time) string {
+	return snapshotPrefix(zone) + t.UTC().Format("20060102T150405.000000000Z")
+}
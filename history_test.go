@@ -0,0 +1,65 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestHistoryAt(t *testing.T) {
+	ctx := context.Background()
+	h := &History{Store: &MemoryStore{}}
+
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(24 * time.Hour)
+
+	// stored out of order on purpose
+	h.save(ctx, &Snapshot{
+		Zone:    "example.com",
+		Taken:   tuesday,
+		Records: []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2"}},
+	})
+	h.save(ctx, &Snapshot{
+		Zone:    "example.com.",
+		Taken:   monday,
+		Records: []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}, libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"}},
+	})
+
+	records, err := h.RecordAt(ctx, "example.com.", "www", "A", tuesday.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Data != "192.0.2.1" {
+		t.Errorf("expected Monday's record, got %+v", records)
+	}
+
+	records, err = h.RecordAt(ctx, "example.com.", "www", "", tuesday.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Data != "192.0.2.2" {
+		t.Errorf("expected Tuesday's record, got %+v", records)
+	}
+
+	if _, err := h.At(ctx, "example.com.", monday.Add(-time.Hour)); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("expected ErrNoSnapshot, got %v", err)
+	}
+
+	d, err := h.Diff(ctx, "example.com.", monday, tuesday)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Removed) != 2 || len(d.Added) != 1 {
+		t.Errorf("unexpected diff: %+v", d)
+	}
+}
+
+func TestHistoryRunRequiresInterval(t *testing.T) {
+	h := &History{Store: &MemoryStore{}}
+	if err := h.Run(context.Background(), []string{"example.com."}); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
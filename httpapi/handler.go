@@ -0,0 +1,250 @@
+// Package httpapi exposes a libdns provider over a small REST API, so
+// that services not written in Go can share a single, controlled path to
+// Rage4 instead of each holding the account credentials.
+//
+// The API consists of a single resource per zone:
+//
+//	GET    /zones/{zone}/records   list records
+//	POST   /zones/{zone}/records   append the records in the body
+//	PUT    /zones/{zone}/records   set the records in the body
+//	DELETE /zones/{zone}/records   delete the records in the body
+//
+// Request and response bodies are JSON arrays of Record. Errors are
+// JSON objects with an "error" message, with the status telling their
+// cause: 404 for unknown zones and records, 400 for records Rage4 does
+// not accept, 401 when Rage4 rejects the credentials, 429 when a rate
+// limit or quota is exhausted, and 502 when Rage4 fails. When some
+// records of a request were processed before the failure, the object
+// lists them under "records", as the changes they stand for were made.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// Provider is the set of libdns operations exposed by the handler.
+// *libdnsrage4.Provider satisfies this interface.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+	libdns.RecordDeleter
+}
+
+// Record is the JSON representation of a DNS record in the API. Data is
+// the record data as in a zone file, such as "10 mail.example.com" for
+// an MX record. TTL is expressed in seconds.
+type Record struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+	TTL  int    `json:"ttl,omitempty"`
+}
+
+// Handler serves the REST API. The zero value is ready to use once
+// Provider is set.
+type Handler struct {
+	// Provider performs the record operations
+	Provider Provider
+
+	// Zones, if not empty, restricts the API to the listed zones
+	Zones []string
+
+	// Authorize, if set, is called for every request after the zone has
+	// been determined; returning an error rejects the request with 403
+	Authorize func(r *http.Request, zone string) error
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+// NewHandler returns a handler serving the API for p.
+func NewHandler(p Provider) *Handler {
+	return &Handler{Provider: p}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.mux = http.NewServeMux()
+		h.mux.HandleFunc("GET /zones/{zone}/records", h.zoneHandler(h.getRecords))
+		h.mux.HandleFunc("POST /zones/{zone}/records", h.zoneHandler(h.appendRecords))
+		h.mux.HandleFunc("PUT /zones/{zone}/records", h.zoneHandler(h.setRecords))
+		h.mux.HandleFunc("DELETE /zones/{zone}/records", h.zoneHandler(h.deleteRecords))
+	})
+	h.mux.ServeHTTP(w, r)
+}
+
+// zoneFunc performs an operation on a zone and returns the records to
+// respond with
+type zoneFunc func(ctx context.Context, zone string, body []libdns.Record) ([]libdns.Record, error)
+
+// zoneHandler wraps a zoneFunc with zone authorization and JSON encoding
+func (h *Handler) zoneHandler(fn zoneFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zone := strings.TrimSuffix(r.PathValue("zone"), ".") + "."
+
+		if len(h.Zones) > 0 && !slices.ContainsFunc(h.Zones, func(z string) bool {
+			return strings.TrimSuffix(z, ".")+"." == zone
+		}) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("zone %s is not managed by this API", zone))
+			return
+		}
+		if h.Authorize != nil {
+			if err := h.Authorize(r, zone); err != nil {
+				writeError(w, http.StatusForbidden, err)
+				return
+			}
+		}
+
+		var body []libdns.Record
+		if r.Method != http.MethodGet {
+			var err error
+			if body, err = decodeRecords(r); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		records, err := fn(r.Context(), zone, body)
+		out := make([]Record, 0, len(records))
+		for _, record := range records {
+			out = append(out, fromLibdns(record))
+		}
+		if err != nil {
+			var rateLimitErr *libdnsrage4.RateLimitError
+			if errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+			}
+			writeErrorResponse(w, statusCode(err), errorResponse{Error: err.Error(), Records: out})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	}
+}
+
+// statusCode returns the HTTP status for a provider error. Only
+// transport failures and server errors from Rage4 are reported as 502.
+func statusCode(err error) int {
+	var (
+		apiErr   *libdnsrage4.APIError
+		convErr  *libdnsrage4.ConversionError
+		validErr *libdnsrage4.ValidationError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, libdnsrage4.ErrZoneNotFound), errors.Is(err, libdnsrage4.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, libdnsrage4.ErrAuthenticationFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, libdnsrage4.ErrRateLimited), errors.Is(err, libdnsrage4.ErrBudgetExceeded),
+		errors.Is(err, libdnsrage4.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, libdnsrage4.ErrUnsupportedRecordType), errors.Is(err, libdnsrage4.ErrTTLNotAllowed),
+		errors.As(err, &convErr), errors.As(err, &validErr):
+		return http.StatusBadRequest
+	case errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError:
+		// rejected by Rage4 rather than failed on its side
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+func (h *Handler) getRecords(ctx context.Context, zone string, _ []libdns.Record) ([]libdns.Record, error) {
+	return h.Provider.GetRecords(ctx, zone)
+}
+
+func (h *Handler) appendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return h.Provider.AppendRecords(ctx, zone, records)
+}
+
+func (h *Handler) setRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return h.Provider.SetRecords(ctx, zone, records)
+}
+
+func (h *Handler) deleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return h.Provider.DeleteRecords(ctx, zone, records)
+}
+
+// decodeRecords reads and validates the records in a request body
+func decodeRecords(r *http.Request) ([]libdns.Record, error) {
+	var in []Record
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	if len(in) == 0 {
+		return nil, errors.New("request body contains no records")
+	}
+
+	records := make([]libdns.Record, 0, len(in))
+	for i, record := range in {
+		if record.Type == "" || record.Name == "" {
+			return nil, fmt.Errorf("record %d: type and name are required", i)
+		}
+		if record.TTL < 0 {
+			return nil, fmt.Errorf("record %d: ttl must not be negative", i)
+		}
+		converted, err := record.toLibdns()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		records = append(records, converted)
+	}
+	return records, nil
+}
+
+func (r Record) toLibdns() (libdns.Record, error) {
+	return libdns.RR{
+		Name: r.Name,
+		TTL:  time.Duration(r.TTL) * time.Second,
+		Type: r.Type,
+		Data: r.Data,
+	}.Parse()
+}
+
+func fromLibdns(record libdns.Record) Record {
+	r := record.RR()
+	return Record{
+		Type: r.Type,
+		Name: r.Name,
+		Data: r.Data,
+		TTL:  int(r.TTL.Seconds()),
+	}
+}
+
+// errorResponse is the body of an error response. Records are the
+// records processed before the failure, if any.
+type errorResponse struct {
+	Error   string   `json:"error"`
+	Records []Record `json:"records,omitempty"`
+}
+
+// writeError responds with a JSON error object
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeErrorResponse(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, resp errorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,166 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+	"github.com/r6c/rage4/internal/memprovider"
+)
+
+func newTestHandler() (*Handler, *memprovider.Provider) {
+	p := &memprovider.Provider{}
+	return NewHandler(p), p
+}
+
+func do(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerCRUD(t *testing.T) {
+	h, p := newTestHandler()
+
+	rec := do(t, h, http.MethodPost, "/zones/example.com/records", `[{"type":"A","name":"www","data":"192.0.2.1","ttl":300}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("append failed: %d %s", rec.Code, rec.Body)
+	}
+	if got := p.Records("example.com."); len(got) != 1 || got[0].RR().TTL.Seconds() != 300 {
+		t.Fatalf("record not stored as expected: %+v", got)
+	}
+
+	rec = do(t, h, http.MethodGet, "/zones/example.com./records", "")
+	var records []Record
+	if err := json.Unmarshal(rec.Body.Bytes(), &records); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(records) != 1 || records[0].Data != "192.0.2.1" || records[0].TTL != 300 {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	rec = do(t, h, http.MethodDelete, "/zones/example.com/records", `[{"type":"A","name":"www","data":"192.0.2.1"}]`)
+	if rec.Code != http.StatusOK || len(p.Records("example.com.")) != 0 {
+		t.Errorf("delete failed: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h, _ := newTestHandler()
+	h.Zones = []string{"example.com."}
+
+	if rec := do(t, h, http.MethodGet, "/zones/example.net/records", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for unmanaged zone, got %d", rec.Code)
+	}
+	if rec := do(t, h, http.MethodPost, "/zones/example.com/records", `[{"name":"www"}]`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing type, got %d", rec.Code)
+	}
+	if rec := do(t, h, http.MethodPost, "/zones/example.com/records", `[{"type":"A","name":"www","bogus":1}]`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown field, got %d", rec.Code)
+	}
+	h.Provider = &failingProvider{err: errors.New("upstream unavailable")}
+	if rec := do(t, h, http.MethodPut, "/zones/example.com/records", `[{"type":"A","name":"www","data":"192.0.2.1"}]`); rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for provider failure, got %d", rec.Code)
+	}
+
+	h.Authorize = func(r *http.Request, zone string) error {
+		return errors.New("token not valid for " + zone)
+	}
+	rec := do(t, h, http.MethodGet, "/zones/example.com/records", "")
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "token not valid for example.com.") {
+		t.Errorf("expected authorization failure, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerZeroValue(t *testing.T) {
+	h := &Handler{Provider: &memprovider.Provider{}}
+	if rec := do(t, h, http.MethodGet, "/zones/example.com/records", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the zero value to serve requests, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+// failingProvider fails every append and set with err, after
+// processing the given records
+type failingProvider struct {
+	memprovider.Provider
+	processed []libdns.Record
+	err       error
+}
+
+func (p *failingProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.processed, p.err
+}
+
+func (p *failingProvider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.processed, p.err
+}
+
+func TestHandlerErrorStatuses(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("failed to get domain ID: %w", libdnsrage4.ErrZoneNotFound), http.StatusNotFound},
+		{&libdnsrage4.APIError{Method: "CreateRecord", StatusCode: http.StatusUnauthorized}, http.StatusUnauthorized},
+		{fmt.Errorf("%w: 100 requests", libdnsrage4.ErrBudgetExceeded), http.StatusTooManyRequests},
+		{fmt.Errorf("%w: Rage4 does not support HINFO records on this plan", libdnsrage4.ErrUnsupportedRecordType), http.StatusBadRequest},
+		{&libdnsrage4.APIError{Method: "CreateRecord", StatusCode: http.StatusOK, Message: "invalid content"}, http.StatusBadRequest},
+		{&libdnsrage4.APIError{Method: "CreateRecord", StatusCode: http.StatusServiceUnavailable}, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		h := NewHandler(&failingProvider{err: tt.err})
+		rec := do(t, h, http.MethodPost, "/zones/example.com/records", `[{"type":"A","name":"www","data":"192.0.2.1"}]`)
+		if rec.Code != tt.want {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.want, rec.Code)
+		}
+	}
+
+	h := NewHandler(&failingProvider{err: &libdnsrage4.RateLimitError{
+		APIError:   &libdnsrage4.APIError{Method: "CreateRecord", StatusCode: http.StatusTooManyRequests},
+		RetryAfter: 1500 * time.Millisecond,
+	}})
+	rec := do(t, h, http.MethodPost, "/zones/example.com/records", `[{"type":"A","name":"www","data":"192.0.2.1"}]`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("expected 429 with Retry-After 2, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestHandlerPartialFailure(t *testing.T) {
+	processed := libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}
+	failed := libdns.RR{Name: "api", Type: "A", Data: "192.0.2.2"}
+	h := NewHandler(&failingProvider{
+		processed: []libdns.Record{processed},
+		err: &libdnsrage4.BatchError{Failed: []*libdnsrage4.RecordError{{
+			Record: failed,
+			Err:    &libdnsrage4.APIError{Method: "CreateRecord", StatusCode: http.StatusBadGateway},
+		}}},
+	})
+
+	rec := do(t, h, http.MethodPost, "/zones/example.com/records",
+		`[{"type":"A","name":"www","data":"192.0.2.1","ttl":300},{"type":"A","name":"api","data":"192.0.2.2"}]`)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+	var resp struct {
+		Error   string   `json:"error"`
+		Records []Record `json:"records"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Error == "" || len(resp.Records) != 1 || resp.Records[0].Name != "www" || resp.Records[0].Data != "192.0.2.1" {
+		t.Errorf("expected the error with the processed record, got %+v", resp)
+	}
+}
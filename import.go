@@ -0,0 +1,155 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// SkippedRecord describes a record from an export that was not imported.
+type SkippedRecord struct {
+	// Record is the record as it appeared in the source, in zone file syntax
+	Record string `json:"record"`
+
+	// Reason explains why the record was skipped
+	Reason string `json:"reason"`
+}
+
+// ImportResult holds the records parsed from another provider's export
+// together with any records that could not be converted.
+type ImportResult struct {
+	Records Records         `json:"records"`
+	Skipped []SkippedRecord `json:"skipped,omitempty"`
+
+	// Conflicts are the record sets that existed in the zone with
+	// different content, as resolved by ImportWithOptions
+	Conflicts []ImportConflict `json:"conflicts,omitempty"`
+}
+
+// ParseBIND parses a zone file in BIND format, as produced by
+// Cloudflare's "Export DNS records" feature. Records are made relative
+// to zone. Cloudflare's "automatic" TTL of 1 is mapped to the provider
+// default. Descriptions written by the "zone" Encoder, as a
+// "; description: ..." comment after a record, are read back into the
+// record's ProviderData.
+func ParseBIND(r io.Reader, zone string) (*ImportResult, error) {
+	origin := dns.Fqdn(zone)
+	zp := dns.NewZoneParser(r, origin, "")
+
+	result := &ImportResult{}
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		// Cloudflare uses a TTL of 1 to mean "automatic"
+		if rr.Header().Ttl == 1 {
+			rr.Header().Ttl = 0
+		}
+		if result.add(rr, origin) {
+			if description, ok := parseDescriptionComment(zp.Comment()); ok {
+				last := len(result.Records) - 1
+				result.Records[last] = withDescription(result.Records[last], description)
+			}
+		}
+	}
+	if err := zp.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse zone file: %w", err)
+	}
+
+	return result, nil
+}
+
+// route53RecordSet mirrors a resource record set in the output of
+// "aws route53 list-resource-record-sets"
+type route53RecordSet struct {
+	Name            string `json:"Name"`
+	Type            string `json:"Type"`
+	TTL             uint32 `json:"TTL"`
+	ResourceRecords []struct {
+		Value string `json:"Value"`
+	} `json:"ResourceRecords"`
+	AliasTarget *struct {
+		DNSName string `json:"DNSName"`
+	} `json:"AliasTarget"`
+}
+
+// ParseRoute53 parses the JSON output of Route53's
+// list-resource-record-sets, either as the full response object or as a
+// bare array of record sets. Alias record sets have no equivalent and are
+// reported as skipped.
+func ParseRoute53(r io.Reader, zone string) (*ImportResult, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	var sets []route53RecordSet
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(body, &sets)
+	} else {
+		var wrapped struct {
+			ResourceRecordSets []route53RecordSet `json:"ResourceRecordSets"`
+		}
+		err = json.Unmarshal(body, &wrapped)
+		sets = wrapped.ResourceRecordSets
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	origin := dns.Fqdn(zone)
+	result := &ImportResult{}
+	for _, set := range sets {
+		// Route53 escapes wildcards and other special characters as octal
+		name := strings.ReplaceAll(set.Name, `\052`, "*")
+
+		if set.AliasTarget != nil {
+			result.Skipped = append(result.Skipped, SkippedRecord{
+				Record: fmt.Sprintf("%s %s ALIAS %s", name, set.Type, set.AliasTarget.DNSName),
+				Reason: "alias record sets are not supported",
+			})
+			continue
+		}
+
+		for _, value := range set.ResourceRecords {
+			line := fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(name), set.TTL, set.Type, value.Value)
+			rr, err := dns.NewRR(line)
+			if err != nil || rr == nil {
+				result.Skipped = append(result.Skipped, SkippedRecord{
+					Record: line,
+					Reason: fmt.Sprintf("failed to parse: %v", err),
+				})
+				continue
+			}
+			result.add(rr, origin)
+		}
+	}
+
+	return result, nil
+}
+
+// Import applies the records of an ImportResult to zone. Record sets
+// that already exist in the zone with the same name and type are
+// replaced; everything else is left untouched. It returns the change set
+// that was applied. Imports run with PriorityBackground unless ctx
+// carries a priority. Use ImportWithOptions to decide conflicting record
+// sets one by one.
+func (p *Provider) Import(ctx context.Context, zone string, result *ImportResult) (*ChangeSet, error) {
+	return p.ImportWithOptions(ctx, zone, result, ImportOptions{})
+}
+
+// add converts rr and appends it to the result, recording it as skipped
+// if it cannot be represented. It reports whether rr was added.
+func (res *ImportResult) add(rr dns.RR, origin string) bool {
+	record, err := safely(func() (libdns.Record, error) {
+		return RecordFromRR(rr, origin)
+	})
+	if err != nil {
+		res.Skipped = append(res.Skipped, SkippedRecord{Record: rr.String(), Reason: err.Error()})
+		return false
+	}
+	res.Records = append(res.Records, record)
+	return true
+}
@@ -0,0 +1,127 @@
+package libdnsrage4
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const cloudflareExport = `;;
+;; Domain:     example.com.
+;; Exported:   2024-01-01 00:00:00
+;;
+$ORIGIN example.com.
+
+;; SOA Record
+example.com	3600	IN	SOA	ns1.cloudflare.com. dns.cloudflare.com. 2045 10000 2400 604800 3600
+
+;; NS Records
+example.com.	86400	IN	NS	ns1.cloudflare.com.
+
+;; A Records
+www.example.com.	1	IN	A	192.0.2.1 ; cf_tags=cf-proxied:true
+
+;; MX Records
+example.com.	300	IN	MX	10 mail.example.com.
+
+;; TXT Records
+example.com.	300	IN	TXT	"v=spf1 include:_spf.example.net ~all"
+
+;; SRV Records
+_sip._tcp.example.com.	300	IN	SRV	10 20 5060 sip.example.com.
+`
+
+func TestParseBIND(t *testing.T) {
+	result, err := ParseBIND(strings.NewReader(cloudflareExport), "example.com.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Skipped) != 2 {
+		t.Errorf("expected SOA and apex NS to be skipped, got %+v", result.Skipped)
+	}
+	if len(result.Records) != 4 {
+		t.Fatalf("expected 4 records, got %d: %+v", len(result.Records), result.Records)
+	}
+
+	www := result.Records[0]
+	if www.RR().Name != "www" || www.RR().Type != "A" || www.RR().Data != "192.0.2.1" || www.RR().TTL != 0 {
+		t.Errorf("unexpected A record: %+v", www)
+	}
+
+	mx := result.Records[1]
+	if mx.RR().Name != "@" || mx.RR().Data != "10 mail.example.com" || mx.RR().TTL != 300*time.Second {
+		t.Errorf("unexpected MX record: %+v", mx)
+	}
+
+	txt := result.Records[2]
+	if txt.RR().Data != "v=spf1 include:_spf.example.net ~all" {
+		t.Errorf("unexpected TXT value: %q", txt.RR().Data)
+	}
+
+	srv := result.Records[3]
+	if srv.RR().Name != "_sip._tcp" || srv.RR().Data != "10 20 5060 sip.example.com" {
+		t.Errorf("unexpected SRV record: %+v", srv)
+	}
+}
+
+const route53Export = `{
+    "ResourceRecordSets": [
+        {
+            "Name": "example.com.",
+            "Type": "SOA",
+            "TTL": 900,
+            "ResourceRecords": [{"Value": "ns-1.awsdns-00.org. awsdns-hostmaster.amazon.com. 1 7200 900 1209600 86400"}]
+        },
+        {
+            "Name": "\\052.example.com.",
+            "Type": "A",
+            "TTL": 300,
+            "ResourceRecords": [{"Value": "192.0.2.1"}, {"Value": "192.0.2.2"}]
+        },
+        {
+            "Name": "txt.example.com.",
+            "Type": "TXT",
+            "TTL": 60,
+            "ResourceRecords": [{"Value": "\"hello world\""}]
+        },
+        {
+            "Name": "cdn.example.com.",
+            "Type": "A",
+            "AliasTarget": {"DNSName": "d111111abcdef8.cloudfront.net."}
+        }
+    ]
+}`
+
+func TestParseRoute53(t *testing.T) {
+	result, err := ParseRoute53(strings.NewReader(route53Export), "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Skipped) != 2 {
+		t.Errorf("expected SOA and alias to be skipped, got %+v", result.Skipped)
+	}
+	if len(result.Records) != 3 {
+		t.Fatalf("expected 3 records, got %d: %+v", len(result.Records), result.Records)
+	}
+
+	if result.Records[0].RR().Name != "*" || result.Records[1].RR().Data != "192.0.2.2" {
+		t.Errorf("unexpected wildcard records: %+v", result.Records[:2])
+	}
+	if result.Records[2].RR().Data != "hello world" || result.Records[2].RR().TTL != 60*time.Second {
+		t.Errorf("unexpected TXT record: %+v", result.Records[2])
+	}
+}
+
+func TestParseRoute53BareArray(t *testing.T) {
+	input := `[{"Name": "www.example.com.", "Type": "CNAME", "TTL": 300, "ResourceRecords": [{"Value": "example.net."}]}]`
+
+	result, err := ParseRoute53(strings.NewReader(input), "example.com.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Records) != 1 || result.Records[0].RR().Data != "example.net" {
+		t.Errorf("unexpected records: %+v", result.Records)
+	}
+}
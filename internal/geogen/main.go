@@ -0,0 +1,87 @@
+// Command geogen generates the country table of the geo region helpers
+// from countries.tsv. It is run by go generate in the root package:
+//
+//	go generate ./...
+package main
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+)
+
+//go:embed countries.tsv
+var countriesTSV string
+
+// continents maps the continent codes of countries.tsv to the names of
+// the Continent constants and their values
+var continents = []struct{ code, ident, name string }{
+	{"AF", "ContinentAfrica", "Africa"},
+	{"AN", "ContinentAntarctica", "Antarctica"},
+	{"AS", "ContinentAsia", "Asia"},
+	{"EU", "ContinentEurope", "Europe"},
+	{"NA", "ContinentNorthAmerica", "North America"},
+	{"OC", "ContinentOceania", "Oceania"},
+	{"SA", "ContinentSouthAmerica", "South America"},
+}
+
+func main() {
+	out := flag.String("o", "countries_gen.go", "output file")
+	flag.Parse()
+
+	src, err := generate(countriesTSV)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted Go source of the country table read
+// from tsv
+func generate(tsv string) ([]byte, error) {
+	idents := make(map[string]string, len(continents))
+	for _, c := range continents {
+		idents[c.code] = c.ident
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by geogen from internal/geogen/countries.tsv; DO NOT EDIT.\n\n")
+	b.WriteString("package libdnsrage4\n\n")
+	b.WriteString("// Continents, which countries are grouped by.\n")
+	b.WriteString("const (\n")
+	for _, c := range continents {
+		fmt.Fprintf(&b, "%s Continent = %q\n", c.ident, c.name)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// countries maps ISO 3166-1 alpha-2 codes to their country\n")
+	b.WriteString("var countries = map[string]Country{\n")
+	scanner := bufio.NewScanner(strings.NewReader(tsv))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 3 || len(fields[0]) != 2 {
+			return nil, fmt.Errorf("countries.tsv:%d: malformed line %q", line, text)
+		}
+		ident, ok := idents[fields[2]]
+		if !ok {
+			return nil, fmt.Errorf("countries.tsv:%d: unknown continent %q", line, fields[2])
+		}
+		fmt.Fprintf(&b, "%q: {Code: %q, Name: %q, Continent: %s},\n", fields[0], fields[0], fields[1], ident)
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestGeneratedTableUpToDate(t *testing.T) {
+	want, err := generate(countriesTSV)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	got, err := os.ReadFile("../../countries_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("countries_gen.go is out of date; run go generate")
+	}
+}
+
+func TestGenerateRejectsMalformedLines(t *testing.T) {
+	for _, tsv := range []string{"DE\tGermany\n", "DE\tGermany\tXX\n", "DEU\tGermany\tEU\n"} {
+		if _, err := generate(tsv); err == nil {
+			t.Errorf("expected %q to be rejected", tsv)
+		}
+	}
+}
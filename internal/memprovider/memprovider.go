@@ -0,0 +1,141 @@
+// Package memprovider is an in-memory libdns provider, for the tests of
+// the packages that serve a libdns provider over another protocol.
+package memprovider
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// Provider keeps the records of its zones in memory and implements
+// libdns.RecordGetter, RecordAppender, RecordSetter and RecordDeleter
+// with the semantics libdns documents for them. Zones are told apart
+// without regard to case or the trailing dot. The zero value is a
+// provider without records.
+type Provider struct {
+	mu      sync.Mutex
+	records map[string][]libdns.Record
+	calls   map[string]int
+}
+
+// zoneKey returns the key of zone in records
+func zoneKey(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
+
+// called counts a call of method; p.mu must be held
+func (p *Provider) called(method string) {
+	if p.calls == nil {
+		p.calls = make(map[string]int)
+	}
+	p.calls[method]++
+}
+
+// Add adds records to zone without counting a call, to set up a test.
+func (p *Provider) Add(zone string, records ...libdns.Record) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.records == nil {
+		p.records = make(map[string][]libdns.Record)
+	}
+	p.records[zoneKey(zone)] = append(p.records[zoneKey(zone)], records...)
+}
+
+// Records returns the records of zone without counting a call.
+func (p *Provider) Records(zone string) []libdns.Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]libdns.Record(nil), p.records[zoneKey(zone)]...)
+}
+
+// Calls returns how often method, such as "SetRecords", was called.
+func (p *Provider) Calls(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[method]
+}
+
+// GetRecords returns the records of zone.
+func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.called("GetRecords")
+	return append([]libdns.Record(nil), p.records[zoneKey(zone)]...), nil
+}
+
+// AppendRecords adds records to zone.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.called("AppendRecords")
+	if p.records == nil {
+		p.records = make(map[string][]libdns.Record)
+	}
+	p.records[zoneKey(zone)] = append(p.records[zoneKey(zone)], records...)
+	return records, nil
+}
+
+// SetRecords replaces the records of zone that have the name and type
+// of one of records with records.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.called("SetRecords")
+	if p.records == nil {
+		p.records = make(map[string][]libdns.Record)
+	}
+	key := zoneKey(zone)
+	var kept []libdns.Record
+	for _, e := range p.records[key] {
+		replaced := false
+		for _, r := range records {
+			replaced = replaced || sameName(e, r) && e.RR().Type == r.RR().Type
+		}
+		if !replaced {
+			kept = append(kept, e)
+		}
+	}
+	p.records[key] = append(kept, records...)
+	return records, nil
+}
+
+// DeleteRecords deletes the records of zone that match one of records:
+// the name must be equal, while an empty type or data and a zero TTL
+// match any. It returns the records deleted.
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.called("DeleteRecords")
+	key := zoneKey(zone)
+	var kept, deleted []libdns.Record
+	for _, e := range p.records[key] {
+		matched := false
+		for _, r := range records {
+			matched = matched || matches(e, r)
+		}
+		if matched {
+			deleted = append(deleted, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	p.records[key] = kept
+	return deleted, nil
+}
+
+// sameName reports whether two records have the same name
+func sameName(a, b libdns.Record) bool {
+	return strings.EqualFold(a.RR().Name, b.RR().Name)
+}
+
+// matches reports whether record is matched by the deletion of want
+func matches(record, want libdns.Record) bool {
+	rr, w := record.RR(), want.RR()
+	return sameName(record, want) &&
+		(w.Type == "" || w.Type == rr.Type) &&
+		(w.Data == "" || w.Data == rr.Data) &&
+		(w.TTL == 0 || w.TTL == rr.TTL)
+}
@@ -0,0 +1,39 @@
+package memprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	p := &Provider{}
+	p.Add("example.com.",
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Minute},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Minute},
+		libdns.RR{Name: "www", Type: "TXT", Data: "hello", TTL: time.Minute},
+	)
+
+	// SetRecords replaces the whole A set, and zones ignore the trailing dot
+	if _, err := p.SetRecords(ctx, "example.com", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if records := p.Records("example.com."); len(records) != 2 || records[1].RR().Data != "192.0.2.3" {
+		t.Fatalf("unexpected records after SetRecords: %+v", records)
+	}
+
+	// an empty type and data match any
+	deleted, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www"}})
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("expected both records to be deleted, got %+v, %v", deleted, err)
+	}
+	if records, _ := p.GetRecords(ctx, "example.com."); len(records) != 0 {
+		t.Errorf("unexpected records after DeleteRecords: %+v", records)
+	}
+	if p.Calls("SetRecords") != 1 || p.Calls("DeleteRecords") != 1 || p.Calls("GetRecords") != 1 {
+		t.Errorf("unexpected call counts: %v", p.calls)
+	}
+}
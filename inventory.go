@@ -0,0 +1,175 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// InventoryFormat selects how an inventory is serialized.
+type InventoryFormat string
+
+const (
+	// InventoryJSON writes the inventory as a single JSON document
+	InventoryJSON InventoryFormat = "json"
+
+	// InventoryNDJSON writes one JSON object per record, one per line
+	InventoryNDJSON InventoryFormat = "ndjson"
+
+	// InventoryYAML writes the inventory as a YAML document with the
+	// structure of the JSON one
+	InventoryYAML InventoryFormat = "yaml"
+
+	// InventoryZoneFile writes the zones as BIND zone files, one after
+	// another; Rage4-specific metadata is left out
+	InventoryZoneFile InventoryFormat = "zone"
+)
+
+// InventoryZone is a zone of the account together with its records in
+// their native Rage4 representation.
+type InventoryZone struct {
+	ID         int64         `json:"id"`
+	Name       string        `json:"name"`
+	OwnerEmail string        `json:"owner_email"`
+	Records    []Rage4Record `json:"records"`
+}
+
+// Inventory is a point-in-time listing of every zone and record in the
+// account, suitable for ingestion into CMDB and asset systems.
+type Inventory struct {
+	Generated time.Time       `json:"generated"`
+	Zones     []InventoryZone `json:"zones"`
+}
+
+// inventoryLine is a single NDJSON inventory entry
+type inventoryLine struct {
+	Generated time.Time   `json:"generated"`
+	ZoneID    int64       `json:"zone_id"`
+	Zone      string      `json:"zone"`
+	Record    Rage4Record `json:"record"`
+}
+
+// Inventory collects every zone and record in the account, including
+// Rage4-specific metadata such as geo and failover settings. It runs
+// with PriorityBackground unless ctx carries a priority.
+func (p *Provider) Inventory(ctx context.Context) (*Inventory, error) {
+	ctx = background(ctx)
+
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	inv := &Inventory{Generated: time.Now().UTC()}
+	for _, domain := range domains {
+		records, err := p.getRage4Records(ctx, domain.ID, domain.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get records of %s: %w", domain.Name, err)
+		}
+
+		inv.Zones = append(inv.Zones, InventoryZone{
+			ID:         domain.ID,
+			Name:       domain.Name,
+			OwnerEmail: domain.Email,
+			Records:    records,
+		})
+	}
+
+	return inv, nil
+}
+
+// Write serializes the inventory to w in the given format. Formats other
+// than NDJSON are written with the Encoder for their name; see
+// EncoderFor.
+func (inv *Inventory) Write(w io.Writer, format InventoryFormat) error {
+	switch format {
+	case InventoryNDJSON:
+		enc := json.NewEncoder(w)
+		for _, zone := range inv.Zones {
+			for _, record := range zone.Records {
+				line := inventoryLine{
+					Generated: inv.Generated,
+					ZoneID:    zone.ID,
+					Zone:      zone.Name,
+					Record:    record,
+				}
+				if err := enc.Encode(line); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		enc, err := EncoderFor(string(format))
+		if err != nil {
+			return fmt.Errorf("unknown inventory format: %s", format)
+		}
+		return enc.Encode(w, inv)
+	}
+}
+
+// InventoryExporter periodically writes the account inventory.
+type InventoryExporter struct {
+	// Provider is used to collect the inventory
+	Provider *Provider
+
+	// Format is the serialization format, JSON by default
+	Format InventoryFormat
+
+	// Interval is the time between exports
+	Interval time.Duration
+
+	// Open returns the destination for an export generated at the given
+	// time, for example a timestamped file; it is closed after writing
+	Open func(generated time.Time) (io.WriteCloser, error)
+
+	// OnError, if set, is called when an export fails; Run keeps going
+	// regardless
+	OnError func(err error)
+}
+
+// Export collects and writes a single inventory.
+func (e *InventoryExporter) Export(ctx context.Context) error {
+	inv, err := e.Provider.Inventory(ctx)
+	if err != nil {
+		return err
+	}
+
+	w, err := e.Open(inv.Generated)
+	if err != nil {
+		return fmt.Errorf("failed to open inventory destination: %w", err)
+	}
+
+	if err := inv.Write(w, e.Format); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write inventory: %w", err)
+	}
+
+	return w.Close()
+}
+
+// Run exports the inventory immediately and then once per Interval,
+// until ctx is cancelled.
+func (e *InventoryExporter) Run(ctx context.Context) error {
+	if e.Interval <= 0 {
+		return fmt.Errorf("inventory interval must be positive")
+	}
+
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Export(ctx); err != nil && e.OnError != nil {
+			e.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
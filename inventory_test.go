@@ -0,0 +1,72 @@
+package libdnsrage4
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testInventory() *Inventory {
+	description := "web frontend"
+	return &Inventory{
+		Generated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Zones: []InventoryZone{
+			{
+				ID:   1,
+				Name: "example.com",
+				Records: []Rage4Record{
+					{ID: 10, DomainID: 1, Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600, Description: &description},
+					{ID: 11, DomainID: 1, Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: 10},
+				},
+			},
+			{
+				ID:      2,
+				Name:    "example.net",
+				Records: []Rage4Record{{ID: 20, DomainID: 2, Name: "example.net", Type: "A", Content: "192.0.2.2", TTL: 300}},
+			},
+		},
+	}
+}
+
+func TestInventoryWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testInventory().Write(&buf, InventoryNDJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per record, got %d", len(lines))
+	}
+
+	var line inventoryLine
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatalf("invalid NDJSON line: %v", err)
+	}
+	if line.Zone != "example.com" || line.ZoneID != 1 || line.Record.ID != 10 || *line.Record.Description != "web frontend" {
+		t.Errorf("unexpected line: %+v", line)
+	}
+}
+
+func TestInventoryWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testInventory().Write(&buf, InventoryJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(buf.Bytes(), &inv); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(inv.Zones) != 2 || len(inv.Zones[0].Records) != 2 {
+		t.Errorf("unexpected inventory: %+v", inv)
+	}
+}
+
+func TestInventoryWriteUnknownFormat(t *testing.T) {
+	if err := testInventory().Write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
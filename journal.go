@@ -0,0 +1,254 @@
+package libdnsrage4
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalPrefix is the Store prefix of journaled mutations
+const journalPrefix = "journal/"
+
+// Mutation is a change sent to Rage4, as journaled to JournalStore.
+type Mutation struct {
+	// Key is the Store key of the journal entry
+	Key string `json:"-"`
+
+	// Operation is the provider operation that sent the change, such as
+	// "AppendRecords", and OperationID tells apart its calls, so that the
+	// mutations of one batch can be grouped
+	Operation   string `json:"operation"`
+	OperationID string `json:"operation_id"`
+	Zone        string `json:"zone,omitempty"`
+
+	// Method and Params are the API request, such as "CreateRecord"
+	// with the record's name, type and content
+	Method string     `json:"method"`
+	Params url.Values `json:"params"`
+
+	Started time.Time `json:"started"`
+
+	// Status is set by ReconcileJournal
+	Status MutationStatus `json:"-"`
+}
+
+// MutationStatus tells whether a journaled mutation took effect.
+type MutationStatus int
+
+const (
+	// MutationUnknown is the status of mutations that cannot be checked,
+	// such as those of whole domains
+	MutationUnknown MutationStatus = iota
+
+	// MutationApplied is the status of mutations found in effect
+	MutationApplied
+
+	// MutationNotApplied is the status of mutations found not in effect,
+	// which may be sent again
+	MutationNotApplied
+)
+
+func (s MutationStatus) String() string {
+	switch s {
+	case MutationApplied:
+		return "applied"
+	case MutationNotApplied:
+		return "not applied"
+	}
+	return "unknown"
+}
+
+// newJournalID returns a random ID for journal entries and operations
+func newJournalID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate journal ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// journal saves a mutation to JournalStore before it is sent, and
+// returns the function that marks it complete once it has been sent.
+// Mutations are complete when Rage4 accepted them, or rejected them in
+// answer to the only attempt; after anything else, such as a timeout or
+// a retry, they may or may not have taken effect and stay in the
+// journal.
+func (p *Provider) journal(ctx context.Context, method string, params url.Values) (func(error), error) {
+	store := p.JournalStore
+	if store == nil {
+		return func(error) {}, nil
+	}
+
+	id, err := newJournalID()
+	if err != nil {
+		return nil, err
+	}
+	m := Mutation{Method: method, Params: params, Started: time.Now().UTC(), OperationID: id}
+	if op := p.operationFrom(ctx); op != nil {
+		m.Operation, m.Zone = op.name, strings.TrimSuffix(op.zone, ".")
+		if op.id != "" {
+			m.OperationID = op.id
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mutation: %w", err)
+	}
+	// keys sort by time, so the journal lists in the order sent
+	key := fmt.Sprintf("%s%020d-%s", journalPrefix, m.Started.UnixNano(), id)
+	if err := store.Put(ctx, key, data); err != nil {
+		return nil, fmt.Errorf("failed to journal %s: %w", method, err)
+	}
+
+	return func(err error) {
+		var apiErr *APIError
+		var retryErr *RetryError
+		if err != nil && (!errors.As(err, &apiErr) || errors.As(err, &retryErr)) {
+			return
+		}
+		// the journal entry is only a safeguard, so failing to remove it
+		// must not fail the mutation; ReconcileJournal clears it
+		_ = store.Delete(context.WithoutCancel(ctx), key)
+	}, nil
+}
+
+// PendingMutations returns the journaled mutations that were never
+// marked complete, oldest first: those of operations interrupted by a
+// crash, and those whose outcome was unclear, such as after a timeout.
+func (p *Provider) PendingMutations(ctx context.Context) ([]Mutation, error) {
+	if p.JournalStore == nil {
+		return nil, nil
+	}
+
+	keys, err := p.JournalStore.List(ctx, journalPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal: %w", err)
+	}
+
+	var pending []Mutation
+	for _, key := range keys {
+		data, err := p.JournalStore.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mutation: %w", err)
+		}
+		var m Mutation
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to decode mutation %s: %w", key, err)
+		}
+		m.Key = key
+		pending = append(pending, m)
+	}
+	return pending, nil
+}
+
+// ReconcileJournal checks the pending mutations against the zones they
+// were sent to, sets their Status, and removes them from the journal.
+// Record creations are looked up by name, type and content, updates,
+// deletions and state changes by record ID. The caller decides what to do with the
+// mutations that were not applied, for example send them again, and
+// with those whose status is unknown.
+func (p *Provider) ReconcileJournal(ctx context.Context) (_ []Mutation, err error) {
+	pending, err := p.PendingMutations(ctx)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	ctx, done, err := p.beginOp(ctx, "ReconcileJournal", "")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	// each zone is read once, after every pending mutation was sent
+	zones := make(map[string][]Rage4Record)
+	for i, m := range pending {
+		if m.Zone != "" && isRecordMutation(m.Method) {
+			records, ok := zones[m.Zone]
+			if !ok {
+				domainID, _, err := p.resolveDomain(ctx, m.Zone)
+				if err != nil && !errors.Is(err, ErrZoneNotFound) {
+					return nil, fmt.Errorf("failed to get domain ID: %w", err)
+				}
+				if err == nil {
+					if records, err = p.getRage4Records(ctx, domainID, m.Zone); err != nil {
+						return nil, fmt.Errorf("failed to get records: %w", err)
+					}
+				}
+				zones[m.Zone] = records
+			}
+			pending[i].Status = mutationStatus(m, records)
+		}
+
+		if err := p.JournalStore.Delete(ctx, m.Key); err != nil {
+			return nil, fmt.Errorf("failed to remove reconciled mutation: %w", err)
+		}
+	}
+	return pending, nil
+}
+
+// isRecordMutation reports whether method changes a single record
+func isRecordMutation(method string) bool {
+	return method == "CreateRecord" || method == "UpdateRecord" || method == "DeleteRecord" || method == "SetRecordState"
+}
+
+// mutationStatus returns whether m is in effect in the records of its
+// zone
+func mutationStatus(m Mutation, records []Rage4Record) MutationStatus {
+	id, _ := strconv.ParseInt(m.Params.Get("id"), 10, 64)
+	name, content := m.Params.Get("name"), m.Params.Get("content")
+
+	switch m.Method {
+	case "CreateRecord":
+		for _, r := range records {
+			if strings.EqualFold(strings.TrimSuffix(r.Name, "."), strings.TrimSuffix(name, ".")) &&
+				strings.EqualFold(r.Type, m.Params.Get("type")) && r.Content == content {
+				return MutationApplied
+			}
+		}
+		return MutationNotApplied
+
+	case "UpdateRecord":
+		for _, r := range records {
+			if r.ID == id {
+				if strings.EqualFold(strings.TrimSuffix(r.Name, "."), strings.TrimSuffix(name, ".")) &&
+					r.Content == content && strconv.Itoa(r.TTL) == m.Params.Get("ttl") {
+					return MutationApplied
+				}
+				return MutationNotApplied
+			}
+		}
+		// the record is gone, so the update can no longer apply
+		return MutationUnknown
+
+	case "DeleteRecord":
+		for _, r := range records {
+			if r.ID == id {
+				return MutationNotApplied
+			}
+		}
+		return MutationApplied
+
+	case "SetRecordState":
+		for _, r := range records {
+			if r.ID == id {
+				if strconv.FormatBool(r.IsActive) == m.Params.Get("active") {
+					return MutationApplied
+				}
+				return MutationNotApplied
+			}
+		}
+		return MutationUnknown
+	}
+	return MutationUnknown
+}
@@ -0,0 +1,109 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// droppingRage4 drops the connection of requests for records named
+// "lost" after applying them, and for records named "dropped" before,
+// as a crash or network failure would. Records named "rejected" are
+// refused.
+type droppingRage4 struct {
+	*fakeRage4
+}
+
+func (d droppingRage4) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.FormValue("name"), "lost."):
+		d.fakeRage4.ServeHTTP(httptest.NewRecorder(), r)
+		panic(http.ErrAbortHandler)
+	case strings.HasPrefix(r.FormValue("name"), "dropped."):
+		panic(http.ErrAbortHandler)
+	case strings.HasPrefix(r.FormValue("name"), "rejected."):
+		writeFakeJSON(w, CommonResponse{Error: "invalid record"})
+		return
+	}
+	d.fakeRage4.ServeHTTP(w, r)
+}
+
+func TestJournal(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newFakeRage4(t, "example.com.")
+	srv := httptest.NewServer(droppingRage4{f})
+	t.Cleanup(srv.Close)
+	journal := &MemoryStore{}
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi", JournalStore: journal}
+
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "ok", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "rejected", Type: "A", Data: "192.0.2.1"}}); err == nil {
+		t.Fatalf("expected the record to be rejected")
+	}
+	if pending, _ := p.PendingMutations(ctx); len(pending) != 0 {
+		t.Fatalf("expected completed mutations to leave the journal, got %+v", pending)
+	}
+
+	for _, name := range []string{"lost", "dropped"} {
+		if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: name, Type: "A", Data: "192.0.2.2"}}); err == nil {
+			t.Fatalf("expected the %s record to fail", name)
+		}
+	}
+
+	pending, err := p.PendingMutations(ctx)
+	if err != nil {
+		t.Fatalf("PendingMutations failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Params.Get("name") != "lost.example.com" || pending[1].Params.Get("name") != "dropped.example.com" {
+		t.Fatalf("expected the two interrupted creations, got %+v", pending)
+	}
+	if m := pending[0]; m.Method != "CreateRecord" || m.Operation != "AppendRecords" || m.Zone != "example.com" ||
+		m.OperationID == "" || m.OperationID == pending[1].OperationID {
+		t.Errorf("unexpected mutation: %+v", m)
+	}
+
+	reconciled, err := p.ReconcileJournal(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileJournal failed: %v", err)
+	}
+	if len(reconciled) != 2 || reconciled[0].Status != MutationApplied || reconciled[1].Status != MutationNotApplied {
+		t.Errorf("expected the lost record applied and the dropped one not, got %+v", reconciled)
+	}
+	if keys, _ := journal.List(ctx, ""); len(keys) != 0 {
+		t.Errorf("expected an empty journal, got %v", keys)
+	}
+}
+
+func TestMutationStatus(t *testing.T) {
+	records := []Rage4Record{{ID: 7, Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300}}
+
+	tests := []struct {
+		method string
+		params string
+		want   MutationStatus
+	}{
+		{"CreateRecord", "name=WWW.example.com&type=A&content=192.0.2.1", MutationApplied},
+		{"CreateRecord", "name=www.example.com&type=A&content=192.0.2.2", MutationNotApplied},
+		{"UpdateRecord", "id=7&name=www.example.com&content=192.0.2.1&ttl=300", MutationApplied},
+		{"UpdateRecord", "id=7&name=www.example.com&content=192.0.2.1&ttl=60", MutationNotApplied},
+		{"UpdateRecord", "id=8&name=www.example.com&content=192.0.2.1&ttl=300", MutationUnknown},
+		{"DeleteRecord", "id=7", MutationNotApplied},
+		{"DeleteRecord", "id=8", MutationApplied},
+		{"SetRecordState", "id=7&active=true", MutationNotApplied},
+		{"SetRecordState", "id=8&active=false", MutationUnknown},
+		{"DeleteDomain", "id=1", MutationUnknown},
+	}
+	for _, tt := range tests {
+		params, _ := url.ParseQuery(tt.params)
+		if got := mutationStatus(Mutation{Method: tt.method, Params: params}, records); got != tt.want {
+			t.Errorf("%s %s: expected %v, got %v", tt.method, tt.params, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by operations started after Close.
+var ErrClosed = errors.New("provider is closed")
+
+// opKey is the context key of the *operation a call belongs to. Nested
+// calls (Apply calling DeleteRecords, say) join the outermost operation,
+// so they are not counted twice or rejected halfway through by Close.
+type opKey struct{}
+
+// operation is the state of one logical operation, from the outermost
+// public call to its return
+type operation struct {
+	provider *Provider
+	id       string // groups the journaled mutations of the operation
+	name     string
+	zone     string
+	budget   int
+	start    time.Time
+	onTiming func(OperationTiming)
+
+	mu       sync.Mutex
+	requests []string        // API methods called, in order
+	timings  []RequestTiming // only recorded with onTiming
+
+	quotaRecords quotaRecords // only counted for tenants with MaxRecords
+}
+
+// operationFrom returns the operation of p that ctx belongs to, if any
+func (p *Provider) operationFrom(ctx context.Context) *operation {
+	op, _ := ctx.Value(opKey{}).(*operation)
+	if op == nil || op.provider != p {
+		return nil
+	}
+	return op
+}
+
+// Close shuts the provider down: it stops accepting new operations,
+// cancels the work the provider started on its own, such as refreshing
+// stale domain IDs in the background, and waits for the operations in
+// flight to finish, including their queued requests, so that no
+// mutation is abandoned halfway. It returns an error if ctx is done
+// first. There is no checkpoint left to save afterwards: journal
+// entries, soft deletions and other Store writes are made as the
+// operations run. Background subsystems such as History and
+// InventoryExporter stop with the context passed to their Run methods.
+func (p *Provider) Close(ctx context.Context) error {
+	p.opsMu.Lock()
+	p.closed = true
+	if p.stopBgWork != nil {
+		p.stopBgWork()
+	}
+	if p.inflight == 0 {
+		p.opsMu.Unlock()
+		return nil
+	}
+	if p.drained == nil {
+		p.drained = make(chan struct{})
+	}
+	drained := p.drained
+	p.opsMu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain in-flight operations: %w", ctx.Err())
+	}
+}
+
+// backgroundContext returns the context of the work the provider starts
+// on its own, which Close cancels
+func (p *Provider) backgroundContext() context.Context {
+	p.opsMu.Lock()
+	defer p.opsMu.Unlock()
+
+	if p.bgCtx == nil {
+		p.bgCtx, p.stopBgWork = context.WithCancel(context.Background())
+		if p.closed {
+			p.stopBgWork()
+		}
+	}
+	return p.bgCtx
+}
+
+// beginOp registers the start of the operation name on zone, which may
+// be empty for account-wide operations. The returned function must be
+// called with a pointer to the operation's error when it ends, typically
+// with "defer done(&err)". Nested operations are part of the outermost
+// one.
+func (p *Provider) beginOp(ctx context.Context, name, zone string) (context.Context, func(*error), error) {
+	if p.operationFrom(ctx) != nil {
+		return ctx, func(*error) {}, nil
+	}
+
+	var id string
+	if p.JournalStore != nil {
+		var err error
+		if id, err = newJournalID(); err != nil {
+			return ctx, nil, err
+		}
+	}
+
+	p.opsMu.Lock()
+	defer p.opsMu.Unlock()
+
+	if p.closed {
+		return ctx, nil, ErrClosed
+	}
+	p.inflight++
+
+	op := &operation{
+		provider: p,
+		id:       id,
+		name:     name,
+		zone:     zone,
+		budget:   requestBudget(ctx),
+		start:    time.Now(),
+		onTiming: p.OnTiming,
+	}
+	if op.budget == 0 {
+		p.mu.RLock()
+		op.budget = p.MaxRequestsPerOperation
+		p.mu.RUnlock()
+	}
+
+	done := func(err *error) {
+		var opErr error
+		if err != nil {
+			opErr = *err
+		}
+		p.stats.record(op.name, op.zone, opErr)
+		op.report(opErr)
+		p.endOp()
+	}
+	return context.WithValue(ctx, opKey{}, op), done, nil
+}
+
+// endOp registers the end of an operation
+func (p *Provider) endOp() {
+	p.opsMu.Lock()
+	defer p.opsMu.Unlock()
+
+	p.inflight--
+	if p.inflight == 0 && p.drained != nil {
+		close(p.drained)
+		p.drained = nil
+	}
+}
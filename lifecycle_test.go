@@ -0,0 +1,99 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCloseDrainsInFlightOperations(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	approving := make(chan struct{})
+	release := make(chan struct{})
+	p.Approver = func(ctx context.Context, cs *ChangeSet) error {
+		close(approving)
+		<-release
+		return nil
+	}
+
+	applied := make(chan error, 1)
+	go func() {
+		applied <- p.Apply(ctx, &ChangeSet{
+			Zone:   "example.com.",
+			Create: []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}},
+		})
+	}()
+	<-approving
+
+	closed := make(chan error, 1)
+	go func() { closed <- p.Close(ctx) }()
+
+	// wait for Close to take effect
+	for {
+		if _, err := p.GetRecords(ctx, "example.com."); errors.Is(err, ErrClosed) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned before the in-flight apply finished: %v", err)
+	default:
+	}
+
+	// the apply started before Close, so its nested calls must go through
+	close(release)
+	if err := <-applied; err != nil {
+		t.Fatalf("in-flight apply failed: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(f.domainRecords(1)) != 1 {
+		t.Errorf("expected the record to be created, got %+v", f.domainRecords(1))
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	p := &Provider{}
+	_, done, err := p.beginOp(context.Background(), "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer done(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+}
+
+func TestCloseStopsBackgroundRefresh(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p := &Provider{Email: "user@example.com", APIKey: "secret", Endpoint: srv.URL + "/rapi"}
+	p.refreshDomain("example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("expected Close to stop the background refresh, got %v", err)
+	}
+}
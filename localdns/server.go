@@ -0,0 +1,248 @@
+// Package localdns implements a small authoritative DNS server that
+// answers from an in-memory snapshot of a zone. It is intended for
+// integration tests of applications that need to resolve names hosted
+// on Rage4 without network access to, or load on, the real service.
+package localdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Source provides the records of a zone. *libdnsrage4.Provider
+// satisfies this interface.
+type Source interface {
+	RecordsAsRR(ctx context.Context, zone string) ([]dns.RR, error)
+}
+
+// Server answers queries for a single zone from a snapshot of its
+// records. It is safe for concurrent use; the snapshot can be replaced
+// while the server is running.
+type Server struct {
+	origin string
+
+	mu    sync.RWMutex
+	names map[string][]dns.RR
+	soa   *dns.SOA
+
+	udp *dns.Server
+	tcp *dns.Server
+}
+
+// New returns a server for zone answering from rrs.
+func New(zone string, rrs []dns.RR) *Server {
+	s := &Server{origin: dns.CanonicalName(zone)}
+	s.Update(rrs)
+	return s
+}
+
+// FromProvider takes a snapshot of zone from src and returns a server
+// answering from it.
+func FromProvider(ctx context.Context, src Source, zone string) (*Server, error) {
+	rrs, err := src.RecordsAsRR(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot zone: %w", err)
+	}
+	return New(zone, rrs), nil
+}
+
+// Update replaces the snapshot the server answers from. Records outside
+// of the zone are ignored. If the snapshot has no SOA record, a
+// placeholder is synthesized so negative answers remain well-formed.
+func (s *Server) Update(rrs []dns.RR) {
+	names := make(map[string][]dns.RR)
+	var soa *dns.SOA
+	for _, rr := range rrs {
+		name := dns.CanonicalName(rr.Header().Name)
+		if !dns.IsSubDomain(s.origin, name) {
+			continue
+		}
+		if v, ok := rr.(*dns.SOA); ok && name == s.origin {
+			soa = v
+		}
+		names[name] = append(names[name], rr)
+	}
+
+	if soa == nil {
+		soa = &dns.SOA{
+			Hdr:     dns.RR_Header{Name: s.origin, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+			Ns:      "localhost.",
+			Mbox:    "hostmaster." + s.origin,
+			Serial:  1,
+			Refresh: 3600,
+			Retry:   600,
+			Expire:  86400,
+			Minttl:  300,
+		}
+	}
+
+	s.mu.Lock()
+	s.names = names
+	s.soa = soa
+	s.mu.Unlock()
+}
+
+// Refresh takes a new snapshot of the zone from src.
+func (s *Server) Refresh(ctx context.Context, src Source) error {
+	rrs, err := src.RecordsAsRR(ctx, s.origin)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot zone: %w", err)
+	}
+	s.Update(rrs)
+	return nil
+}
+
+// Start listens on addr over both UDP and TCP. Use "127.0.0.1:0" to pick
+// a free port; the chosen address is available from Addr.
+func (s *Server) Start(addr string) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on UDP: %w", err)
+	}
+
+	// serve TCP on the same port that was assigned for UDP
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		return fmt.Errorf("failed to listen on TCP: %w", err)
+	}
+
+	s.udp = &dns.Server{PacketConn: pc, Handler: s}
+	s.tcp = &dns.Server{Listener: ln, Handler: s}
+	go s.udp.ActivateAndServe()
+	go s.tcp.ActivateAndServe()
+	return nil
+}
+
+// Addr returns the address the server is listening on, or an empty
+// string if it has not been started.
+func (s *Server) Addr() string {
+	if s.udp == nil {
+		return ""
+	}
+	return s.udp.PacketConn.LocalAddr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	if s.udp == nil {
+		return nil
+	}
+	udpErr := s.udp.Shutdown()
+	tcpErr := s.tcp.Shutdown()
+	if udpErr != nil {
+		return udpErr
+	}
+	return tcpErr
+}
+
+// ServeDNS implements dns.Handler.
+func (s *Server) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+
+	if len(req.Question) != 1 {
+		m.SetRcode(req, dns.RcodeFormatError)
+		w.WriteMsg(m)
+		return
+	}
+
+	q := req.Question[0]
+	name := dns.CanonicalName(q.Name)
+	if !dns.IsSubDomain(s.origin, name) {
+		m.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(m)
+		return
+	}
+	m.Authoritative = true
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	answer, exists := s.lookup(name, q.Qtype)
+	m.Answer = answer
+	if len(answer) == 0 {
+		if !exists {
+			m.Rcode = dns.RcodeNameError
+		}
+		m.Ns = []dns.RR{s.soa}
+	}
+
+	w.WriteMsg(m)
+}
+
+// lookup returns the answer for name and qtype, following CNAMEs within
+// the zone. The second result reports whether name exists at all.
+func (s *Server) lookup(name string, qtype uint16) ([]dns.RR, bool) {
+	var answer []dns.RR
+	for range 8 {
+		rrs, exists := s.rrset(name)
+		if !exists {
+			return answer, len(answer) > 0
+		}
+
+		var cname *dns.CNAME
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == qtype || qtype == dns.TypeANY {
+				answer = append(answer, synthesize(rr, name))
+			} else if v, ok := rr.(*dns.CNAME); ok {
+				cname = v
+			}
+		}
+
+		if len(answer) > 0 || cname == nil || qtype == dns.TypeCNAME {
+			return answer, true
+		}
+
+		answer = append(answer, synthesize(cname, name))
+		name = dns.CanonicalName(cname.Target)
+		if !dns.IsSubDomain(s.origin, name) {
+			return answer, true
+		}
+	}
+	return answer, true
+}
+
+// rrset returns the records owned by name, falling back to a wildcard
+// at the closest encloser. The second result reports whether name
+// exists, including as an empty non-terminal.
+func (s *Server) rrset(name string) ([]dns.RR, bool) {
+	if rrs, ok := s.names[name]; ok {
+		return rrs, true
+	}
+
+	for owner := range s.names {
+		if strings.HasSuffix(owner, "."+name) {
+			return nil, true
+		}
+	}
+
+	for encloser := name; encloser != s.origin; {
+		i := strings.Index(encloser, ".")
+		encloser = encloser[i+1:]
+		if rrs, ok := s.names["*."+encloser]; ok {
+			return rrs, true
+		}
+		if _, ok := s.names[encloser]; ok {
+			break
+		}
+	}
+
+	return nil, false
+}
+
+// synthesize returns a copy of rr owned by name, used to answer from
+// wildcard records
+func synthesize(rr dns.RR, name string) dns.RR {
+	if dns.CanonicalName(rr.Header().Name) == name {
+		return rr
+	}
+	c := dns.Copy(rr)
+	c.Header().Name = name
+	return c
+}
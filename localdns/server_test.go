@@ -0,0 +1,104 @@
+package localdns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+type staticSource []dns.RR
+
+func (s staticSource) RecordsAsRR(ctx context.Context, zone string) ([]dns.RR, error) {
+	return s, nil
+}
+
+func mustRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("invalid record %q: %v", s, err)
+	}
+	return rr
+}
+
+func startServer(t *testing.T, rrs ...dns.RR) *Server {
+	t.Helper()
+
+	s, err := FromProvider(context.Background(), staticSource(rrs), "example.com.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func query(t *testing.T, s *Server, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	in, err := dns.Exchange(m, s.Addr())
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	return in
+}
+
+func TestServerAnswers(t *testing.T) {
+	s := startServer(t,
+		mustRR(t, "www.example.com. 300 IN A 192.0.2.1"),
+		mustRR(t, "alias.example.com. 300 IN CNAME www.example.com."),
+		mustRR(t, "*.apps.example.com. 300 IN A 192.0.2.2"),
+		mustRR(t, "a.b.example.com. 300 IN TXT \"deep\""),
+	)
+
+	in := query(t, s, "www.example.com.", dns.TypeA)
+	if !in.Authoritative || len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "192.0.2.1" {
+		t.Errorf("unexpected answer for www: %v", in)
+	}
+
+	in = query(t, s, "alias.example.com.", dns.TypeA)
+	if len(in.Answer) != 2 {
+		t.Errorf("expected CNAME to be followed, got %v", in.Answer)
+	}
+
+	in = query(t, s, "foo.apps.example.com.", dns.TypeA)
+	if len(in.Answer) != 1 || in.Answer[0].Header().Name != "foo.apps.example.com." {
+		t.Errorf("expected wildcard answer, got %v", in.Answer)
+	}
+
+	in = query(t, s, "www.example.com.", dns.TypeAAAA)
+	if in.Rcode != dns.RcodeSuccess || len(in.Answer) != 0 || len(in.Ns) != 1 {
+		t.Errorf("expected NODATA with SOA, got %v", in)
+	}
+
+	in = query(t, s, "b.example.com.", dns.TypeA)
+	if in.Rcode != dns.RcodeSuccess {
+		t.Errorf("expected empty non-terminal to exist, got rcode %d", in.Rcode)
+	}
+
+	in = query(t, s, "missing.example.com.", dns.TypeA)
+	if in.Rcode != dns.RcodeNameError {
+		t.Errorf("expected NXDOMAIN, got rcode %d", in.Rcode)
+	}
+
+	in = query(t, s, "example.net.", dns.TypeA)
+	if in.Rcode != dns.RcodeRefused {
+		t.Errorf("expected REFUSED for foreign zone, got rcode %d", in.Rcode)
+	}
+}
+
+func TestServerUpdate(t *testing.T) {
+	s := startServer(t, mustRR(t, "www.example.com. 300 IN A 192.0.2.1"))
+
+	s.Update([]dns.RR{mustRR(t, "www.example.com. 300 IN A 192.0.2.9")})
+
+	in := query(t, s, "www.example.com.", dns.TypeA)
+	if len(in.Answer) != 1 || in.Answer[0].(*dns.A).A.String() != "192.0.2.9" {
+		t.Errorf("expected updated answer, got %v", in.Answer)
+	}
+}
@@ -0,0 +1,162 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrMaintenanceActive is returned by Maintenance.Enable for a set that
+// is already enabled in the zone.
+var ErrMaintenanceActive = errors.New("maintenance set is already enabled")
+
+// MaintenanceSet is a named group of record overrides, such as pointing
+// www at a status page. Each override replaces the records with its name
+// and type; a CNAME replaces every record at its name, and any record
+// set is replaced by a CNAME with the same name.
+type MaintenanceSet struct {
+	Name    string  `json:"name"`
+	Records Records `json:"records"`
+}
+
+// Maintenance enables and reverts maintenance sets, keeping the records
+// they replace in a Store until they are restored.
+type Maintenance struct {
+	// Provider manages the records
+	Provider *Provider
+
+	// Store keeps the replaced records, under keys of the form
+	// "maintenance/<zone>/<set>"
+	Store Store
+}
+
+// maintenanceState is what the Store keeps for an enabled set
+type maintenanceState struct {
+	Set      MaintenanceSet `json:"set"`
+	Original Records        `json:"original"`
+}
+
+// Enable applies the overrides of set to zone. The records they replace
+// are saved before anything is changed, exactly as read from the zone,
+// so that Disable can restore them.
+func (m *Maintenance) Enable(ctx context.Context, zone string, set MaintenanceSet) error {
+	key := maintenanceKey(zone, set.Name)
+	if _, err := m.Store.Get(ctx, key); err == nil {
+		return fmt.Errorf("%w: %s in %s", ErrMaintenanceActive, set.Name, zone)
+	} else if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to load maintenance state: %w", err)
+	}
+
+	existing, err := m.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to get existing records: %w", err)
+	}
+
+	state := maintenanceState{Set: set}
+	for _, e := range existing {
+		if overridden(e, set.Records) {
+			state.Original = append(state.Original, e)
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode maintenance state: %w", err)
+	}
+	if err := m.Store.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to save maintenance state: %w", err)
+	}
+
+	cs := &ChangeSet{Zone: zone, Delete: state.Original, Create: set.Records}
+	if err := m.Provider.Apply(ctx, cs); err != nil {
+		// a rejected change set left the zone untouched; otherwise the
+		// state is kept so that Disable can repair a partial change
+		if errors.Is(err, ErrNotApproved) {
+			m.Store.Delete(ctx, key)
+		}
+		return fmt.Errorf("failed to enable maintenance set %s: %w", set.Name, err)
+	}
+	return nil
+}
+
+// Disable reverts the maintenance set with the given name: the override
+// records still present are removed and the original records are
+// recreated with their original values, TTLs and priorities. Records
+// changed by others in the meantime are left alone.
+func (m *Maintenance) Disable(ctx context.Context, zone, name string) error {
+	key := maintenanceKey(zone, name)
+	data, err := m.Store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance state: %w", err)
+	}
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to decode maintenance state %s: %w", key, err)
+	}
+
+	existing, err := m.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to get existing records: %w", err)
+	}
+
+	cs := &ChangeSet{Zone: zone}
+	for _, e := range existing {
+		if slices.ContainsFunc(state.Set.Records, func(o libdns.Record) bool { return sameRecord(e, o) }) {
+			cs.Delete = append(cs.Delete, e)
+		}
+	}
+	for _, r := range state.Original {
+		if !containsRecord(existing, r) {
+			cs.Create = append(cs.Create, withoutID(r))
+		}
+	}
+
+	if err := m.Provider.Apply(ctx, cs); err != nil {
+		return fmt.Errorf("failed to disable maintenance set %s: %w", name, err)
+	}
+
+	if err := m.Store.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to remove maintenance state: %w", err)
+	}
+	return nil
+}
+
+// Enabled returns the names of the maintenance sets enabled in zone.
+func (m *Maintenance) Enabled(ctx context.Context, zone string) ([]string, error) {
+	prefix := maintenanceKey(zone, "")
+	keys, err := m.Store.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance sets: %w", err)
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = strings.TrimPrefix(key, prefix)
+	}
+	return names, nil
+}
+
+// maintenanceKey returns the Store key of a maintenance set
+func maintenanceKey(zone, name string) string {
+	return "maintenance/" + strings.TrimSuffix(zone, ".") + "./" + name
+}
+
+// overridden reports whether an existing record is replaced by overrides
+func overridden(e libdns.Record, overrides []libdns.Record) bool {
+	er := e.RR()
+	for _, o := range overrides {
+		or := o.RR()
+		if normalizeName(er.Name) != normalizeName(or.Name) {
+			continue
+		}
+		if er.Type == or.Type || er.Type == "CNAME" || or.Type == "CNAME" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestMaintenanceEnableDisable(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "AAAA", Content: "2001:db8::1", TTL: 600})
+	f.addRecord(1, Rage4Record{Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: 300})
+	before := f.domainRecords(1)
+
+	m := &Maintenance{Provider: p, Store: &MemoryStore{}}
+	set := MaintenanceSet{
+		Name:    "status-page",
+		Records: []libdns.Record{libdns.RR{Name: "www", Type: "CNAME", Data: "status.example.net"}},
+	}
+
+	if err := m.Enable(ctx, "example.com.", set); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	records := f.domainRecords(1)
+	if len(records) != 2 || records[0].Name != "api.example.com" || records[1].Type != "CNAME" {
+		t.Errorf("unexpected records in maintenance: %+v", records)
+	}
+	if names, _ := m.Enabled(ctx, "example.com."); !slices.Equal(names, []string{"status-page"}) {
+		t.Errorf("unexpected enabled sets: %v", names)
+	}
+	if err := m.Enable(ctx, "example.com.", set); !errors.Is(err, ErrMaintenanceActive) {
+		t.Errorf("expected ErrMaintenanceActive, got %v", err)
+	}
+
+	if err := m.Disable(ctx, "example.com.", "status-page"); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	after := f.domainRecords(1)
+	if len(after) != len(before) {
+		t.Fatalf("expected %d records after disabling, got %+v", len(before), after)
+	}
+	for _, b := range before {
+		if !slices.ContainsFunc(after, func(a Rage4Record) bool {
+			return a.Name == b.Name && a.Type == b.Type && a.Content == b.Content && a.TTL == b.TTL
+		}) {
+			t.Errorf("record not restored: %+v", b)
+		}
+	}
+	if names, _ := m.Enabled(ctx, "example.com."); len(names) != 0 {
+		t.Errorf("expected no enabled sets, got %v", names)
+	}
+}
+
+func TestMaintenanceDisableKeepsSettings(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	description := "web"
+	f.addRecord(1, Rage4Record{
+		Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300,
+		GeoRegionID: 7, Description: &description, Weight: 5,
+	})
+
+	m := &Maintenance{Provider: p, Store: &MemoryStore{}}
+	set := MaintenanceSet{
+		Name:    "status-page",
+		Records: []libdns.Record{libdns.RR{Name: "www", Type: "CNAME", Data: "status.example.net"}},
+	}
+	if err := m.Enable(ctx, "example.com.", set); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	if err := m.Disable(ctx, "example.com.", "status-page"); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+
+	records := f.domainRecords(1)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record after disabling, got %+v", records)
+	}
+	r := records[0]
+	if r.Content != "192.0.2.1" || r.GeoRegionID != 7 || r.Description == nil || *r.Description != "web" || r.Weight != 5 {
+		t.Errorf("settings not restored: %+v", r)
+	}
+}
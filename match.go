@@ -0,0 +1,67 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Matcher selects records for bulk operations such as DeleteMatching.
+type Matcher interface {
+	// Match reports whether record is selected. Record names are
+	// relative to the zone, with "@" for the apex.
+	Match(record libdns.Record) bool
+}
+
+// MatchFunc adapts a predicate to the Matcher interface.
+type MatchFunc func(record libdns.Record) bool
+
+// Match implements Matcher.
+func (f MatchFunc) Match(record libdns.Record) bool {
+	return f(record)
+}
+
+// DeleteMatching deletes every record of zone selected by m, reading the
+// zone once and deleting the records by ID, so there is no need to
+// construct exact record values. Records managed by Rage4 itself, such
+// as the apex NS records, are never deleted. It returns the deleted
+// records.
+func (p *Provider) DeleteMatching(ctx context.Context, zone string, m Matcher) (_ []libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "DeleteMatching", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	domainID, err := p.getDomainID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	raw, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+
+	var matched []libdns.Record
+	for _, r := range raw {
+		if r.IsSystem {
+			continue
+		}
+		record, err := fromRage4(r, zoneName)
+		if err != nil {
+			return nil, err
+		}
+		if m.Match(record) {
+			matched = append(matched, record)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	return p.deleteRecords(ctx, domainID, zoneName, matched, raw)
+}
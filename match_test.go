@@ -0,0 +1,35 @@
+package libdnsrage4
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestDeleteMatching(t *testing.T) {
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "NS", Content: "ns1.r4ns.com", TTL: 86400, IsSystem: true})
+	f.addRecord(1, Rage4Record{Name: "_acme-challenge.example.com", Type: "TXT", Content: `"token-1"`, TTL: 60})
+	f.addRecord(1, Rage4Record{Name: "_acme-challenge.www.example.com", Type: "TXT", Content: `"token-2"`, TTL: 60})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	deleted, err := p.DeleteMatching(context.Background(), "example.com.", MatchFunc(func(r libdns.Record) bool {
+		return r.RR().Type == "NS" || strings.HasPrefix(r.RR().Name, "_acme-challenge")
+	}))
+	if err != nil {
+		t.Fatalf("DeleteMatching failed: %v", err)
+	}
+
+	if len(deleted) != 2 {
+		t.Errorf("expected the two challenge records to be deleted, got %+v", deleted)
+	}
+	stored := f.domainRecords(1)
+	if len(stored) != 2 || stored[0].Type != "NS" || stored[1].Type != "A" {
+		t.Errorf("expected the system NS and the A record to remain, got %+v", stored)
+	}
+	if f.calls("GetRecords") != 1 {
+		t.Errorf("expected a single read, got %d", f.calls("GetRecords"))
+	}
+}
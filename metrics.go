@@ -0,0 +1,100 @@
+package libdnsrage4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteMetrics writes the provider's operation statistics in the
+// Prometheus text exposition format, so that long-running programs using
+// the provider can be monitored like any other exporter.
+func (p *Provider) WriteMetrics(w io.Writer) error {
+	s := p.Stats()
+
+	var b bytes.Buffer
+	writeMetricFamily(&b, "rage4_operations_total", "counter", "Provider operations by kind.",
+		"operation", s.Operations, func(s OperationStats) float64 { return float64(s.Operations) })
+	writeMetricFamily(&b, "rage4_operation_errors_total", "counter", "Failed provider operations by kind.",
+		"operation", s.Operations, func(s OperationStats) float64 { return float64(s.Errors) })
+	writeMetricFamily(&b, "rage4_zone_operations_total", "counter", "Provider operations by zone.",
+		"zone", s.Zones, func(s OperationStats) float64 { return float64(s.Operations) })
+	writeMetricFamily(&b, "rage4_zone_operation_errors_total", "counter", "Failed provider operations by zone.",
+		"zone", s.Zones, func(s OperationStats) float64 { return float64(s.Errors) })
+	writeMetricFamily(&b, "rage4_zone_last_success_timestamp_seconds", "gauge", "Time of the last successful operation by zone.",
+		"zone", s.Zones, func(s OperationStats) float64 { return unixSeconds(s.LastSuccess) })
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteMetricsFile writes the metrics to path for the node exporter's
+// textfile collector. The file is written to a temporary file first and
+// renamed into place, so the collector never reads a partial file.
+func (p *Provider) WriteMetricsFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := p.WriteMetrics(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics, to be
+// mounted at /metrics.
+func (p *Provider) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		p.WriteMetrics(w)
+	})
+}
+
+// writeMetricFamily writes one metric family with a sample for each
+// entry of stats, labeled with its key and sorted by it
+func writeMetricFamily(b *bytes.Buffer, name, kind, help, label string, stats map[string]OperationStats, value func(OperationStats) float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(b, "%s{%s=\"%s\"} %g\n", name, label, escapeLabel(key), value(stats[key]))
+	}
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// unixSeconds returns t in seconds since the epoch, or zero for the zero
+// time
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
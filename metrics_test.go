@@ -0,0 +1,49 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+
+	p.GetRecords(ctx, "example.com.")
+	p.GetRecords(ctx, "missing.example.")
+
+	var b strings.Builder
+	if err := p.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics failed: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE rage4_operations_total counter\n",
+		`rage4_operations_total{operation="GetRecords"} 2` + "\n",
+		`rage4_operation_errors_total{operation="GetRecords"} 1` + "\n",
+		`rage4_zone_operations_total{zone="example.com."} 1` + "\n",
+		`rage4_zone_operation_errors_total{zone="missing.example."} 1` + "\n",
+		`rage4_zone_last_success_timestamp_seconds{zone="missing.example."} 0` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, b.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "rage4.prom")
+	if err := p.WriteMetricsFile(path); err != nil {
+		t.Fatalf("WriteMetricsFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != b.String() {
+		t.Errorf("unexpected metrics file:\n%s", data)
+	}
+
+	rec := httptest.NewRecorder()
+	p.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") || rec.Body.String() != b.String() {
+		t.Errorf("unexpected response: %s\n%s", rec.Header(), rec.Body)
+	}
+}
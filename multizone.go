@@ -0,0 +1,97 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// allRecordsWorkers is the number of zones GetAllRecords fetches at once
+// when MaxConcurrentRequests is not set
+const allRecordsWorkers = 8
+
+// GetAllRecords lists the records of many zones in parallel, returning
+// them keyed by zone as given. The domain list is read once, and the
+// zones are fetched concurrently within MaxConcurrentRequests, or eight
+// at a time if it is not set. Zones that fail are left out of the map
+// and reported together in the error, so one bad zone does not cost the
+// others. It runs with PriorityBackground unless ctx carries a priority.
+func (p *Provider) GetAllRecords(ctx context.Context, zones []string) (_ map[string][]libdns.Record, err error) {
+	ctx, done, err := p.beginOp(background(ctx), "GetAllRecords", "")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	ids := make(map[string]int64, len(domains))
+	for _, domain := range domains {
+		ids[domain.Name] = domain.ID
+	}
+
+	p.mu.RLock()
+	workers := p.MaxConcurrentRequests
+	p.mu.RUnlock()
+	if workers <= 0 {
+		workers = allRecordsWorkers
+	}
+
+	var (
+		mu     sync.Mutex
+		result = make(map[string][]libdns.Record, len(zones))
+		errs   []error
+		wg     sync.WaitGroup
+		queue  = make(chan string)
+	)
+	for range min(workers, len(zones)) {
+		wg.Go(func() {
+			for zone := range queue {
+				records, err := p.zoneRecords(ctx, ids, zone)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", zone, err))
+				} else {
+					result[zone] = records
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	for _, zone := range zones {
+		queue <- zone
+	}
+	close(queue)
+	wg.Wait()
+
+	return result, errors.Join(errs...)
+}
+
+// zoneRecords reads the records of zone, looking the ID of the domain
+// holding it, itself or its deepest parent, up in ids
+func (p *Provider) zoneRecords(ctx context.Context, ids map[string]int64, zone string) ([]libdns.Record, error) {
+	zoneName := strings.TrimSuffix(zone, ".")
+	var domainID int64
+	for _, name := range zoneCandidates(zoneName) {
+		if id, ok := ids[name]; ok {
+			domainID = id
+			break
+		}
+	}
+	if domainID == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, zoneName)
+	}
+
+	raw, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, err
+	}
+	return readRecords(ctx, raw, zoneName)
+}
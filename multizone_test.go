@@ -0,0 +1,34 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGetAllRecords(t *testing.T) {
+	ctx := context.Background()
+	zones := []string{"a.example.", "b.example.", "c.example.", "d.example."}
+	f, p := newFakeRage4(t, zones...)
+	p.MaxConcurrentRequests = 2
+	for i := range zones {
+		f.addRecord(int64(i+1), Rage4Record{Name: "www." + strings.TrimSuffix(zones[i], "."), Type: "A", Content: "192.0.2.1", TTL: 300})
+	}
+
+	all, err := p.GetAllRecords(ctx, append(zones, "missing.example."))
+	if !errors.Is(err, ErrZoneNotFound) || !strings.Contains(err.Error(), "missing.example.:") {
+		t.Errorf("expected the missing zone to be reported, got %v", err)
+	}
+	if len(all) != len(zones) {
+		t.Fatalf("expected %d zones, got %v", len(zones), all)
+	}
+	for _, zone := range zones {
+		if records := all[zone]; len(records) != 1 || records[0].RR().Name != "www" {
+			t.Errorf("unexpected records of %s: %+v", zone, records)
+		}
+	}
+	if f.calls("GetDomains") != 1 || f.calls("GetRecords") != len(zones) {
+		t.Errorf("unexpected requests: %v", f.requests)
+	}
+}
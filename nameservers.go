@@ -0,0 +1,244 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// RegularNameservers are Rage4's shared name servers, which zones use
+// unless they are switched to vanity name servers.
+var RegularNameservers = []string{"ns1.r4ns.com", "ns2.r4ns.net"}
+
+// VanityNameservers names the vanity name servers of a zone, which are
+// Rage4's name servers under the customer's own domain.
+type VanityNameservers struct {
+	// Domain is the domain the name servers are named under, such as
+	// "example.com"
+	Domain string
+
+	// Prefix is the first label of the name servers, numbered from one;
+	// it defaults to "ns", for ns1.example.com, ns2.example.com, ...
+	Prefix string
+}
+
+// hosts returns the names of the vanity name servers, one for each of
+// Rage4's regular name servers
+func (v VanityNameservers) hosts() []string {
+	prefix := v.prefix()
+	domain := strings.TrimSuffix(v.Domain, ".")
+
+	hosts := make([]string, len(RegularNameservers))
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("%s%d.%s", prefix, i+1, domain)
+	}
+	return hosts
+}
+
+// prefix returns Prefix, or "ns" if it is empty
+func (v VanityNameservers) prefix() string {
+	if v.Prefix == "" {
+		return "ns"
+	}
+	return v.Prefix
+}
+
+// params returns the UpdateDomain parameters that switch a domain to
+// the vanity name servers
+func (v VanityNameservers) params() url.Values {
+	return url.Values{
+		"enablevanity": {"true"},
+		"nsname":       {strings.TrimSuffix(v.Domain, ".")},
+		"nsprefix":     {v.prefix()},
+	}
+}
+
+// nameserverParams returns the UpdateDomain parameters of the name
+// servers the zone uses now, as told by the apex NS records Rage4
+// manages, so that other domain settings can be updated without
+// switching them
+func nameserverParams(records []Rage4Record, zoneName string) url.Values {
+	for _, r := range records {
+		if !isApexNS(r, zoneName) || !r.IsSystem || isRegularNameserver(r.Content) {
+			continue
+		}
+		label, domain, _ := strings.Cut(strings.TrimSuffix(r.Content, "."), ".")
+		return VanityNameservers{Domain: domain, Prefix: strings.TrimRight(label, "0123456789")}.params()
+	}
+	return url.Values{"enablevanity": {"false"}}
+}
+
+// Nameservers returns the name servers of the zone's apex NS records,
+// sorted.
+func (p *Provider) Nameservers(ctx context.Context, zone string) (_ []string, err error) {
+	ctx, done, err := p.beginOp(ctx, "Nameservers", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	domainID, err := p.wholeDomainID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	records, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get name servers: %w", err)
+	}
+	return apexNameservers(records, zoneName), nil
+}
+
+// SetVanityNameservers switches the zone to the vanity name servers v.
+// The zone's apex NS records that point at Rage4's name servers are
+// changed to match, and the zone's name servers are returned; the
+// delegation at the registrar, and the glue records for v, remain the
+// caller's to update.
+func (p *Provider) SetVanityNameservers(ctx context.Context, zone string, v VanityNameservers) ([]string, error) {
+	if v.Domain == "" {
+		return nil, fmt.Errorf("vanity name servers need a domain")
+	}
+	return p.switchNameservers(ctx, "SetVanityNameservers", zone, v.params(), v.hosts())
+}
+
+// SetRegularNameservers switches the zone back to Rage4's regular name
+// servers, changing the zone's apex NS records to match, and returns the
+// zone's name servers.
+func (p *Provider) SetRegularNameservers(ctx context.Context, zone string) ([]string, error) {
+	params := url.Values{"enablevanity": {"false"}}
+	return p.switchNameservers(ctx, "SetRegularNameservers", zone, params, RegularNameservers)
+}
+
+// switchNameservers updates the name server settings of the zone with
+// params, then brings the apex NS records Rage4 manages in line with
+// hosts, unless Rage4 has already done so
+func (p *Provider) switchNameservers(ctx context.Context, op, zone string, params url.Values, hosts []string) (_ []string, err error) {
+	ctx, done, err := p.beginOp(ctx, op, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	// the name server mode is a setting of the whole domain
+	domainID, err := p.wholeDomainID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	var domain DomainResponse
+	if err := p.get(ctx, "GetDomain", url.Values{"id": {strconv.FormatInt(domainID, 10)}}, &domain); err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	params.Set("id", strconv.FormatInt(domainID, 10))
+	params.Set("email", domain.Email)
+	if err := p.post(ctx, "UpdateDomain", params, nil); err != nil {
+		return nil, fmt.Errorf("failed to update name servers: %w", err)
+	}
+
+	records, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get name servers: %w", err)
+	}
+	if err := p.rewriteNameservers(ctx, domainID, zoneName, records, hosts); err != nil {
+		return nil, err
+	}
+
+	records, err = p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get name servers: %w", err)
+	}
+	return apexNameservers(records, zoneName), nil
+}
+
+// rewriteNameservers points the apex NS records Rage4 manages at hosts:
+// records are updated in place where possible, keeping their TTL, and
+// created or deleted as the number of hosts requires. NS records of
+// other name servers, such as external secondaries, are left alone.
+func (p *Provider) rewriteNameservers(ctx context.Context, domainID int64, zoneName string, records []Rage4Record, hosts []string) error {
+	var managed []Rage4Record
+	for _, r := range records {
+		if isApexNS(r, zoneName) && (r.IsSystem || isRegularNameserver(r.Content)) {
+			managed = append(managed, r)
+		}
+	}
+	slices.SortFunc(managed, func(a, b Rage4Record) int { return strings.Compare(a.Content, b.Content) })
+	hosts = slices.Sorted(slices.Values(hosts))
+	if slices.Equal(nameserverHosts(managed), hosts) {
+		return nil
+	}
+
+	ttl := 86400
+	if len(managed) > 0 {
+		ttl = managed[0].TTL
+	}
+	for i, host := range hosts {
+		record := libdns.NS{Name: "@", TTL: time.Duration(ttl) * time.Second, Target: host}
+		if i >= len(managed) {
+			if _, err := p.createRecord(ctx, domainID, zoneName, ZoneDefaults{}, record); err != nil {
+				return fmt.Errorf("failed to add name server %s: %w", host, err)
+			}
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(managed[i].Content, "."), host) {
+			continue
+		}
+		existing, err := fromRage4(managed[i], zoneName)
+		if err != nil {
+			return err
+		}
+		if _, err := p.updateRecord(ctx, zoneName, existing, record); err != nil {
+			return fmt.Errorf("failed to change name server %s to %s: %w", managed[i].Content, host, err)
+		}
+	}
+	for _, r := range managed[min(len(hosts), len(managed)):] {
+		if err := p.post(ctx, "DeleteRecord", url.Values{"id": {strconv.FormatInt(r.ID, 10)}}, nil); err != nil {
+			return fmt.Errorf("failed to remove name server %s: %w", r.Content, err)
+		}
+	}
+	return nil
+}
+
+// apexNameservers returns the sorted name servers of the apex NS records
+// among records
+func apexNameservers(records []Rage4Record, zoneName string) []string {
+	var apex []Rage4Record
+	for _, r := range records {
+		if isApexNS(r, zoneName) {
+			apex = append(apex, r)
+		}
+	}
+	return nameserverHosts(apex)
+}
+
+// nameserverHosts returns the sorted targets of NS records, without the
+// trailing dot
+func nameserverHosts(records []Rage4Record) []string {
+	var hosts []string
+	for _, r := range records {
+		hosts = append(hosts, strings.TrimSuffix(r.Content, "."))
+	}
+	slices.Sort(hosts)
+	return hosts
+}
+
+// isApexNS reports whether r is an NS record at the apex of the zone
+func isApexNS(r Rage4Record, zoneName string) bool {
+	return r.Type == "NS" && strings.EqualFold(strings.TrimSuffix(r.Name, "."), zoneName)
+}
+
+// isRegularNameserver reports whether host is one of Rage4's regular
+// name servers
+func isRegularNameserver(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	return slices.ContainsFunc(RegularNameservers, func(ns string) bool {
+		return strings.EqualFold(ns, host)
+	})
+}
@@ -0,0 +1,64 @@
+package libdnsrage4
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSetNameservers(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t)
+
+	onboarded, err := p.OnboardZone(ctx, "example.org.", OnboardOptions{})
+	if err != nil {
+		t.Fatalf("OnboardZone failed: %v", err)
+	}
+	// a secondary at another provider, which must be kept
+	f.addRecord(onboarded.DomainID, Rage4Record{Name: "example.org", Type: "NS", Content: "ns.secondary.example", TTL: 3600})
+
+	ns, err := p.SetVanityNameservers(ctx, "example.org.", VanityNameservers{Domain: "example.net.", Prefix: "dns"})
+	if err != nil {
+		t.Fatalf("SetVanityNameservers failed: %v", err)
+	}
+	if want := []string{"dns1.example.net", "dns2.example.net", "ns.secondary.example"}; !slices.Equal(ns, want) {
+		t.Errorf("expected %v, got %v", want, ns)
+	}
+	settings := f.domainSettings[onboarded.DomainID]
+	if settings.Get("enablevanity") != "true" || settings.Get("nsname") != "example.net" ||
+		settings.Get("nsprefix") != "dns" || settings.Get("email") != "user@example.com" {
+		t.Errorf("unexpected domain settings: %v", settings)
+	}
+	for _, r := range f.domainRecords(onboarded.DomainID) {
+		if r.Content == "dns1.example.net" && (r.TTL != 86400 || !r.IsSystem) {
+			t.Errorf("expected the NS record to be updated in place, got %+v", r)
+		}
+	}
+
+	ns, err = p.SetRegularNameservers(ctx, "example.org")
+	if err != nil {
+		t.Fatalf("SetRegularNameservers failed: %v", err)
+	}
+	if want := []string{"ns.secondary.example", "ns1.r4ns.com", "ns2.r4ns.net"}; !slices.Equal(ns, want) {
+		t.Errorf("expected %v, got %v", want, ns)
+	}
+	if f.domainSettings[onboarded.DomainID].Get("enablevanity") != "false" {
+		t.Errorf("expected vanity name servers to be disabled")
+	}
+
+	// switching again changes no records
+	updates := f.calls("UpdateRecord")
+	if _, err := p.SetRegularNameservers(ctx, "example.org"); err != nil {
+		t.Fatalf("SetRegularNameservers failed: %v", err)
+	}
+	if f.calls("UpdateRecord") != updates || f.calls("CreateRecord") != 0 || f.calls("DeleteRecord") != 0 {
+		t.Errorf("expected no record changes, got %v", f.requests)
+	}
+
+	if ns, err := p.Nameservers(ctx, "example.org"); err != nil || len(ns) != 3 {
+		t.Errorf("expected 3 name servers, got %v, %v", ns, err)
+	}
+	if _, err := p.SetVanityNameservers(ctx, "example.org", VanityNameservers{}); err == nil {
+		t.Errorf("expected an error without a vanity domain")
+	}
+}
@@ -0,0 +1,167 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Webhook is a Rage4 webhook, which Rage4 calls when a record it is
+// attached to fails over or recovers. Webhooks belong to the account and
+// can be attached to records of any zone with WithWebhook or
+// ZoneDefaults.WebhookID.
+type Webhook struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Payload is the body Rage4 sends, and ContentType its media type,
+	// such as "application/json"
+	Payload     string `json:"payload"`
+	ContentType string `json:"content_type"`
+}
+
+// ListWebhooks returns the webhooks of the account.
+func (p *Provider) ListWebhooks(ctx context.Context) (_ []Webhook, err error) {
+	ctx, done, err := p.beginOp(ctx, "ListWebhooks", "")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	var webhooks []Webhook
+	if err := p.get(ctx, "ListWebhooks", nil, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// CreateWebhook creates a webhook and returns its ID, for attaching it
+// to records. The ID of w is ignored.
+func (p *Provider) CreateWebhook(ctx context.Context, w Webhook) (_ int64, err error) {
+	if w.URL == "" {
+		return 0, fmt.Errorf("webhook %q has no URL", w.Name)
+	}
+
+	ctx, done, err := p.beginOp(ctx, "CreateWebhook", "")
+	if err != nil {
+		return 0, err
+	}
+	defer done(&err)
+
+	params := url.Values{
+		"name":        {w.Name},
+		"url":         {w.URL},
+		"payload":     {w.Payload},
+		"contenttype": {w.ContentType},
+	}
+	var result CommonResponse
+	if err := p.post(ctx, "CreateWebhook", params, &result); err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return result.ID, nil
+}
+
+// DeleteWebhook deletes the webhook with the given ID. Records it is
+// attached to are no longer notified.
+func (p *Provider) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	ctx, done, err := p.beginOp(ctx, "DeleteWebhook", "")
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	if err := p.post(ctx, "DeleteWebhook", url.Values{"id": {strconv.FormatInt(id, 10)}}, nil); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// WebhookOf returns the ID of the webhook attached to a record read from
+// Rage4 or set with WithWebhook, and whether it has one.
+func WebhookOf(record libdns.Record) (int64, bool) {
+	data, _ := rage4Data(record)
+	if data.WebhookID == nil || *data.WebhookID == 0 {
+		return 0, false
+	}
+	return *data.WebhookID, true
+}
+
+// WithWebhook returns record with the webhook with the given ID
+// attached, or detached if id is zero, keeping its other Rage4
+// settings, for passing to AppendRecords or SetRecords. Records of
+// types libdns has no struct for cannot carry settings and are
+// rejected.
+func WithWebhook(record libdns.Record, id int64) (libdns.Record, error) {
+	attached, err := withSetting(record, settingWebhook, func(data *Rage4Record) {
+		data.WebhookID = nil
+		if id != 0 {
+			data.WebhookID = &id
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot have webhooks: %w", record.RR().Type, err)
+	}
+	return attached, nil
+}
+
+// NotificationEmail returns the address Rage4 sends the zone's
+// notifications to, such as failover alerts: the owner email of its
+// domain.
+func (p *Provider) NotificationEmail(ctx context.Context, zone string) (_ string, err error) {
+	ctx, done, err := p.beginOp(ctx, "NotificationEmail", zone)
+	if err != nil {
+		return "", err
+	}
+	defer done(&err)
+
+	domainID, err := p.wholeDomainID(ctx, zone)
+	if err != nil {
+		return "", fmt.Errorf("failed to get domain ID: %w", err)
+	}
+
+	var domain DomainResponse
+	if err := p.get(ctx, "GetDomain", url.Values{"id": {strconv.FormatInt(domainID, 10)}}, &domain); err != nil {
+		return "", fmt.Errorf("failed to get domain: %w", err)
+	}
+	return domain.Email, nil
+}
+
+// SetNotificationEmail changes the address Rage4 sends the zone's
+// notifications to. It is a setting of the whole domain, which Rage4
+// updates together with the name server mode, so the zone's current
+// name servers, as read from its apex NS records, are sent along
+// unchanged.
+func (p *Provider) SetNotificationEmail(ctx context.Context, zone, email string) (err error) {
+	if email == "" {
+		return fmt.Errorf("notification email is required")
+	}
+
+	ctx, done, err := p.beginOp(ctx, "SetNotificationEmail", zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	domainID, err := p.wholeDomainID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	records, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return fmt.Errorf("failed to get name servers: %w", err)
+	}
+	params := nameserverParams(records, zoneName)
+	params.Set("id", strconv.FormatInt(domainID, 10))
+	params.Set("email", email)
+	if err := p.post(ctx, "UpdateDomain", params, nil); err != nil {
+		return fmt.Errorf("failed to update notification email: %w", err)
+	}
+	return nil
+}
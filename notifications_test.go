@@ -0,0 +1,126 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+
+	if _, err := p.CreateWebhook(ctx, Webhook{Name: "no url"}); err == nil {
+		t.Error("expected an error for a webhook without URL")
+	}
+	id, err := p.CreateWebhook(ctx, Webhook{Name: "pager", URL: "https://alerts.example.net/hook", ContentType: "application/json"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	webhooks, err := p.ListWebhooks(ctx)
+	if err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+	if len(webhooks) != 1 || webhooks[0].ID != id || webhooks[0].URL != "https://alerts.example.net/hook" {
+		t.Errorf("unexpected webhooks: %+v", webhooks)
+	}
+
+	// attached per record, or by the zone defaults
+	p.ZoneDefaults = map[string]ZoneDefaults{"example.org.": {WebhookID: id}}
+	www, err := WithWebhook(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}, id)
+	if err != nil {
+		t.Fatalf("WithWebhook failed: %v", err)
+	}
+	mail := libdns.RR{Name: "mail", Type: "A", Data: "192.0.2.2", TTL: time.Hour}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{www, mail}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.org.", []libdns.Record{mail}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	attached := make(map[string]int64)
+	for _, zone := range []string{"example.com.", "example.org."} {
+		records, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+		for _, r := range records {
+			if webhookID, ok := WebhookOf(r); ok {
+				attached[r.RR().Name+"."+zone] = webhookID
+			}
+		}
+	}
+	if len(attached) != 2 || attached["www.example.com."] != id || attached["mail.example.org."] != id {
+		t.Errorf("unexpected webhooks attached: %v", attached)
+	}
+
+	// records without provider data keep their webhook, detaching takes
+	// WithWebhook with no ID
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.10", TTL: time.Hour}}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].WebhookID == nil || *stored[0].WebhookID != id {
+		t.Errorf("expected the webhook to be kept, got %+v", stored[0])
+	}
+	detached, err := WithWebhook(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.10", TTL: time.Hour}, 0)
+	if err != nil {
+		t.Fatalf("WithWebhook failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{detached}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].WebhookID != nil {
+		t.Errorf("expected the webhook to be detached, got %+v", stored[0])
+	}
+
+	if err := p.DeleteWebhook(ctx, id); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if webhooks, err := p.ListWebhooks(ctx); err != nil || len(webhooks) != 0 {
+		t.Errorf("expected no webhooks, got %+v, %v", webhooks, err)
+	}
+}
+
+func TestNotificationEmail(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t)
+
+	result, err := p.OnboardZone(ctx, "example.org.", OnboardOptions{})
+	if err != nil {
+		t.Fatalf("OnboardZone failed: %v", err)
+	}
+	if email, err := p.NotificationEmail(ctx, "example.org."); err != nil || email != "user@example.com" {
+		t.Errorf("unexpected notification email %q, %v", email, err)
+	}
+
+	if err := p.SetNotificationEmail(ctx, "example.org.", "noc@example.org"); err != nil {
+		t.Fatalf("SetNotificationEmail failed: %v", err)
+	}
+	if email, err := p.NotificationEmail(ctx, "example.org."); err != nil || email != "noc@example.org" {
+		t.Errorf("unexpected notification email %q, %v", email, err)
+	}
+	f.mu.Lock()
+	settings := f.domainSettings[result.DomainID]
+	f.mu.Unlock()
+	if settings.Get("enablevanity") != "false" {
+		t.Errorf("expected regular name servers to be kept, got %v", settings)
+	}
+
+	// the name server mode survives changing the email
+	if _, err := p.SetVanityNameservers(ctx, "example.org.", VanityNameservers{Domain: "example.org", Prefix: "dns"}); err != nil {
+		t.Fatalf("SetVanityNameservers failed: %v", err)
+	}
+	if err := p.SetNotificationEmail(ctx, "example.org.", "ops@example.org"); err != nil {
+		t.Fatalf("SetNotificationEmail failed: %v", err)
+	}
+	f.mu.Lock()
+	settings = f.domainSettings[result.DomainID]
+	f.mu.Unlock()
+	if settings.Get("enablevanity") != "true" || settings.Get("nsname") != "example.org" ||
+		settings.Get("nsprefix") != "dns" || settings.Get("email") != "ops@example.org" {
+		t.Errorf("expected vanity name servers to be kept, got %v", settings)
+	}
+}
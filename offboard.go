@@ -0,0 +1,150 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// OffboardOptions controls OffboardZone.
+type OffboardOptions struct {
+	// Store, if set, keeps the final snapshot of the zone under
+	// "offboard/<zone>/<time>"
+	Store Store
+
+	// LowerTTL, if positive, lowers the TTL of every record above it
+	// before the grace period, so that resolvers forget the zone quickly
+	// once it is deleted
+	LowerTTL time.Duration
+
+	// GracePeriod is the time between the snapshot and the deletion.
+	// Cancelling ctx during it aborts the offboarding and leaves the
+	// zone in place. The wait is not persisted: if the process exits
+	// during it, the zone is not deleted.
+	GracePeriod time.Duration
+}
+
+// OffboardResult describes an offboarded zone.
+type OffboardResult struct {
+	// Snapshot is the state of the zone before any change. Passing its
+	// records to OnboardZone recreates the zone.
+	Snapshot *Snapshot `json:"snapshot"`
+
+	// Deleted is when the zone was deleted, or zero if it was not
+	Deleted time.Time `json:"deleted,omitzero"`
+}
+
+// OffboardZone decommissions a zone: it exports a final snapshot,
+// optionally lowers TTLs, waits for the grace period, and deletes the
+// zone. It blocks for the whole grace period, so it is usually run in
+// its own goroutine, and the deletion only happens if that goroutine
+// lives to the end of it; nothing schedules it elsewhere. The result is
+// returned even if a later step fails, so that the snapshot is never
+// lost.
+func (p *Provider) OffboardZone(ctx context.Context, zone string, opts OffboardOptions) (*OffboardResult, error) {
+	snapshot, err := p.Snapshot(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot zone: %w", err)
+	}
+	result := &OffboardResult{Snapshot: snapshot}
+	if _, err := p.wholeDomainID(ctx, zone); err != nil {
+		return result, fmt.Errorf("cannot offboard zone: %w", err)
+	}
+
+	if opts.Store != nil {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return result, fmt.Errorf("failed to encode snapshot: %w", err)
+		}
+		key := "offboard/" + strings.TrimSuffix(zone, ".") + "/" + snapshot.Taken.Format("20060102T150405.000000000Z")
+		if err := opts.Store.Put(ctx, key, data); err != nil {
+			return result, fmt.Errorf("failed to store snapshot: %w", err)
+		}
+	}
+
+	if opts.LowerTTL > 0 {
+		if err := p.lowerTTLs(ctx, zone, snapshot.Records, opts.LowerTTL); err != nil {
+			return result, fmt.Errorf("failed to lower TTLs: %w", err)
+		}
+	}
+
+	// the grace period is not a provider operation, so that Close does
+	// not have to wait for it
+	select {
+	case <-ctx.Done():
+		return result, fmt.Errorf("offboarding aborted: %w", ctx.Err())
+	case <-time.After(opts.GracePeriod):
+	}
+
+	if err := p.DeleteZone(ctx, zone); err != nil {
+		return result, err
+	}
+	result.Deleted = time.Now().UTC()
+
+	return result, nil
+}
+
+// lowerTTLs updates every record with a TTL above ttl in place to that
+// TTL, keeping its ID and its Rage4-specific settings. The apex NS
+// records are managed by Rage4 and left alone.
+func (p *Provider) lowerTTLs(ctx context.Context, zone string, records []libdns.Record, ttl time.Duration) (err error) {
+	ctx, done, err := p.beginOp(ctx, "LowerTTLs", zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	zoneName := strings.TrimSuffix(zone, ".")
+	for _, record := range records {
+		rr := record.RR()
+		if rr.TTL <= ttl || (rr.Type == "NS" && normalizeName(rr.Name) == "@") {
+			continue
+		}
+		if _, err := p.updateRecord(ctx, zoneName, record, withTTL(record, ttl)); err != nil {
+			return fmt.Errorf("failed to lower the TTL of %s %s: %w", rr.Name, rr.Type, err)
+		}
+	}
+	return nil
+}
+
+// wholeDomainID returns the ID of the domain of zone, failing for
+// subzones, which are only part of their parent's domain
+func (p *Provider) wholeDomainID(ctx context.Context, zone string) (int64, error) {
+	id, domain, err := p.resolveDomain(ctx, zone)
+	if err != nil {
+		return 0, err
+	}
+	if zoneName := strings.TrimSuffix(zone, "."); !strings.EqualFold(domain, zoneName) {
+		return 0, fmt.Errorf("%w: %s is part of %s", ErrZoneNotFound, zoneName, domain)
+	}
+	return id, nil
+}
+
+// DeleteZone deletes a zone with all its records, at once and for good;
+// OffboardZone keeps a snapshot and allows a grace period first.
+// Subzones managed within a parent's domain cannot be deleted on their
+// own and fail with ErrZoneNotFound.
+func (p *Provider) DeleteZone(ctx context.Context, zone string) (err error) {
+	ctx, done, err := p.beginOp(ctx, "DeleteZone", zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	domainID, err := p.wholeDomainID(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to get domain ID: %w", err)
+	}
+
+	if err := p.post(ctx, "DeleteDomain", url.Values{"id": {strconv.FormatInt(domainID, 10)}}, nil); err != nil {
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+	p.InvalidateDomainCache()
+	return nil
+}
@@ -0,0 +1,105 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOffboardZone(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "NS", Content: "ns1.r4ns.com", TTL: 86400})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: 60})
+
+	store := &MemoryStore{}
+	result, err := p.OffboardZone(ctx, "example.com.", OffboardOptions{
+		Store:       store,
+		LowerTTL:    5 * time.Minute,
+		GracePeriod: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("OffboardZone failed: %v", err)
+	}
+
+	if len(result.Snapshot.Records) != 3 || result.Deleted.IsZero() {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if keys, _ := store.List(ctx, "offboard/example.com/"); len(keys) != 1 {
+		t.Errorf("expected the snapshot to be stored, got %v", keys)
+	}
+	if len(f.domains) != 0 || len(f.records) != 0 {
+		t.Errorf("expected the zone to be deleted, got %+v %+v", f.domains, f.records)
+	}
+	if f.calls("UpdateRecord") != 1 || f.calls("CreateRecord") != 0 {
+		t.Errorf("expected only www to be updated with a lower TTL, got %d updates and %d creations",
+			f.calls("UpdateRecord"), f.calls("CreateRecord"))
+	}
+}
+
+func TestLowerTTLsKeepsRecords(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	description := "web"
+	id := f.addRecord(1, Rage4Record{
+		Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600,
+		GeoRegionID: 7, Description: &description, Weight: 5,
+	})
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if err := p.lowerTTLs(ctx, "example.com.", records, 5*time.Minute); err != nil {
+		t.Fatalf("lowerTTLs failed: %v", err)
+	}
+
+	stored := f.domainRecords(1)
+	if len(stored) != 1 {
+		t.Fatalf("expected 1 record, got %+v", stored)
+	}
+	r := stored[0]
+	if r.ID != id || r.TTL != 300 || r.GeoRegionID != 7 || r.Description == nil || *r.Description != "web" || r.Weight != 5 {
+		t.Errorf("expected the record to keep its ID and settings with a lower TTL, got %+v", r)
+	}
+}
+
+func TestOffboardZoneAbortedDuringGracePeriod(t *testing.T) {
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	result, err := p.OffboardZone(ctx, "example.com.", OffboardOptions{GracePeriod: time.Hour})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the offboarding to be aborted, got %v", err)
+	}
+	if result.Snapshot == nil || !result.Deleted.IsZero() {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(f.domains) != 1 || len(f.domainRecords(1)) != 1 {
+		t.Error("expected the zone to be left in place")
+	}
+}
+
+func TestDeleteZone(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	if err := p.DeleteZone(ctx, "sub.example.com."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected a subzone to be refused, got %v", err)
+	}
+	if err := p.DeleteZone(ctx, "example.com."); err != nil {
+		t.Fatalf("DeleteZone failed: %v", err)
+	}
+	if len(f.domains) != 1 || len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the zone and its records to be deleted, got %+v", f.domains)
+	}
+	if _, err := p.GetRecords(ctx, "example.com."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected the deleted zone to be gone, got %v", err)
+	}
+}
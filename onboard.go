@@ -0,0 +1,154 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// OnboardOptions controls OnboardZone.
+type OnboardOptions struct {
+	// OwnerEmail is the owner of the new zone; it defaults to the
+	// account email
+	OwnerEmail string
+
+	// Records is the baseline record set created in the new zone
+	Records []libdns.Record
+
+	// Zone sets how the zone is created, such as with vanity name
+	// servers or as a reverse zone. OwnerEmail takes precedence over
+	// Zone.Email, and Records are created after Zone.Records.
+	Zone ZoneOptions
+
+	// WaitForDelegation makes OnboardZone poll until the zone is
+	// delegated to Rage4's name servers, or ctx is done
+	WaitForDelegation bool
+
+	// PollInterval is the time between delegation checks; it defaults
+	// to one minute
+	PollInterval time.Duration
+
+	// LookupNS returns the name servers the zone is delegated to; it
+	// defaults to a lookup with the provider's Resolver
+	LookupNS func(ctx context.Context, zone string) ([]string, error)
+}
+
+// OnboardResult describes a zone created by OnboardZone.
+type OnboardResult struct {
+	Zone     string `json:"zone"`
+	DomainID int64  `json:"domain_id"`
+
+	// Nameservers are the name servers to configure at the registrar
+	Nameservers []string `json:"nameservers"`
+
+	// Delegated reports whether the zone was seen delegated to
+	// Nameservers; it is only checked with WaitForDelegation
+	Delegated bool `json:"delegated"`
+}
+
+// OnboardZone creates a zone, applies a baseline record set, and returns
+// the name servers to configure at the registrar. With
+// WaitForDelegation, it then polls until the delegation is live; if ctx
+// ends first, the result is returned along with the context's error, so
+// that the caller still learns the name servers.
+func (p *Provider) OnboardZone(ctx context.Context, zone string, opts OnboardOptions) (_ *OnboardResult, err error) {
+	ctx, done, err := p.beginOp(ctx, "OnboardZone", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	zoneOpts := opts.Zone
+	if opts.OwnerEmail != "" {
+		zoneOpts.Email = opts.OwnerEmail
+	}
+	domainID, err := p.createZone(ctx, zoneName, zoneOpts)
+	if err != nil && domainID == 0 {
+		return nil, err
+	}
+	result := &OnboardResult{Zone: zoneName + ".", DomainID: domainID}
+	if err != nil {
+		return result, err
+	}
+
+	if len(opts.Records) > 0 {
+		if _, err := p.appendRecords(ctx, domainID, zoneName, opts.Records); err != nil {
+			return result, fmt.Errorf("failed to apply baseline records: %w", err)
+		}
+	}
+
+	// Rage4 creates the apex NS records itself
+	records, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return result, fmt.Errorf("failed to get name servers: %w", err)
+	}
+	result.Nameservers = apexNameservers(records, zoneName)
+
+	if !opts.WaitForDelegation {
+		return result, nil
+	}
+
+	lookup := opts.LookupNS
+	if lookup == nil {
+		lookup = func(ctx context.Context, zone string) ([]string, error) {
+			return lookupNS(ctx, p.resolver(), zone)
+		}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if ns, err := lookup(ctx, result.Zone); err == nil && delegatedTo(ns, result.Nameservers) {
+			result.Delegated = true
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("zone is not delegated yet: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// lookupNS returns the name servers of zone using resolver
+func lookupNS(ctx context.Context, resolver Resolver, zone string) ([]string, error) {
+	records, err := resolver.LookupNS(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var ns []string
+	for _, r := range records {
+		ns = append(ns, r.Host)
+	}
+	return ns, nil
+}
+
+// delegatedTo reports whether every expected name server is among the
+// delegated ones
+func delegatedTo(delegated, expected []string) bool {
+	if len(expected) == 0 {
+		return false
+	}
+
+	seen := make(map[string]bool)
+	for _, ns := range delegated {
+		seen[strings.ToLower(strings.TrimSuffix(ns, "."))] = true
+	}
+	for _, ns := range expected {
+		if !seen[strings.ToLower(strings.TrimSuffix(ns, "."))] {
+			return false
+		}
+	}
+	return true
+}
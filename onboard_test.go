@@ -0,0 +1,67 @@
+package libdnsrage4
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestOnboardZone(t *testing.T) {
+	f, p := newFakeRage4(t)
+
+	lookups := 0
+	result, err := p.OnboardZone(context.Background(), "example.org.", OnboardOptions{
+		Records:           []libdns.Record{libdns.RR{Name: "@", Type: "A", Data: "192.0.2.1"}},
+		WaitForDelegation: true,
+		PollInterval:      time.Millisecond,
+		LookupNS: func(ctx context.Context, zone string) ([]string, error) {
+			lookups++
+			if lookups < 3 {
+				return []string{"ns.old-provider.example."}, nil
+			}
+			return []string{"NS1.R4NS.COM.", "ns2.r4ns.net."}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("OnboardZone failed: %v", err)
+	}
+
+	if !slices.Equal(result.Nameservers, []string{"ns1.r4ns.com", "ns2.r4ns.net"}) {
+		t.Errorf("unexpected name servers: %v", result.Nameservers)
+	}
+	if !result.Delegated || lookups != 3 {
+		t.Errorf("expected delegation after 3 lookups, got %v after %d", result.Delegated, lookups)
+	}
+
+	if f.domains[0].Name != "example.org" || f.domains[0].Email != "user@example.com" {
+		t.Errorf("unexpected domain: %+v", f.domains[0])
+	}
+	stored := f.domainRecords(result.DomainID)
+	if len(stored) != 3 || stored[2].Type != "A" {
+		t.Errorf("expected baseline record next to the NS records, got %+v", stored)
+	}
+}
+
+func TestOnboardZoneDelegationTimeout(t *testing.T) {
+	_, p := newFakeRage4(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := p.OnboardZone(ctx, "example.org.", OnboardOptions{
+		WaitForDelegation: true,
+		PollInterval:      time.Millisecond,
+		LookupNS: func(ctx context.Context, zone string) ([]string, error) {
+			return nil, nil
+		},
+	})
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if result == nil || len(result.Nameservers) != 2 || result.Delegated {
+		t.Errorf("expected the name servers despite the timeout, got %+v", result)
+	}
+}
@@ -0,0 +1,39 @@
+package libdnsrage4
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError reports a panic recovered while processing a single record.
+// The record fails with this error and the rest of the operation, and
+// the embedding process, carry on.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// safely calls fn, converting a panic into a *PanicError. Every
+// per-record conversion goes through it, since a malformed record from
+// the API or an import must not take down the whole process.
+func safely[T any](fn func() (T, error)) (result T, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,34 @@
+package libdnsrage4
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSafely(t *testing.T) {
+	_, err := safely(func() (int, error) {
+		var m map[string]int
+		m["boom"] = 1
+		return 0, nil
+	})
+
+	var perr *PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PanicError, got %v", err)
+	}
+	if len(perr.Stack) == 0 {
+		t.Error("expected a stack trace")
+	}
+
+	// panics with an error value unwrap to it
+	_, err = safely(func() (int, error) { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected wrapped panic value, got %v", err)
+	}
+
+	n, err := safely(func() (int, error) { return 42, nil })
+	if n != 42 || err != nil {
+		t.Errorf("unexpected result: %d, %v", n, err)
+	}
+}
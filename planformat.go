@@ -0,0 +1,141 @@
+package libdnsrage4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// PlanVersion is the version of the JSON format of change sets. It is
+// increased on incompatible changes; change sets of newer versions are
+// rejected when decoded.
+const PlanVersion = 1
+
+// ErrStalePlan is returned by Apply when the record sets a change set
+// touches changed after it was planned, so that an approved plan is
+// never applied to a zone it was not computed for.
+var ErrStalePlan = errors.New("zone changed since the change set was planned")
+
+// MarshalJSON implements json.Marshaler. Change sets are encoded with
+// the version of the format, so that external change management systems
+// can store them, diff them, and read them back with UnmarshalJSON.
+func (cs *ChangeSet) MarshalJSON() ([]byte, error) {
+	type plain ChangeSet
+	return json.Marshal(struct {
+		Version int `json:"version"`
+		*plain
+	}{PlanVersion, (*plain)(cs)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Change sets encoded before
+// the format was versioned are accepted, without state hashes.
+func (cs *ChangeSet) UnmarshalJSON(data []byte) error {
+	type plain ChangeSet
+	aux := struct {
+		Version int `json:"version"`
+		*plain
+	}{plain: (*plain)(cs)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Version > PlanVersion {
+		return fmt.Errorf("unsupported change set version %d, expected at most %d", aux.Version, PlanVersion)
+	}
+	return nil
+}
+
+// Hash returns a content hash of the change set, including its state
+// hashes, such as "sha256:9f86d0...". An Approver can compare it with
+// the hash of the plan a person or system approved, to make sure the
+// change set applied is that one.
+func (cs *ChangeSet) Hash() (string, error) {
+	data, err := json.Marshal(cs)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode change set: %w", err)
+	}
+	return hashString(data), nil
+}
+
+// touched returns the record sets the change set deletes from or
+// creates in
+func (cs *ChangeSet) touched() map[recordSetKey]bool {
+	sets := make(map[recordSetKey]bool)
+	for _, r := range cs.Delete {
+		sets[recordSetOf(r)] = true
+	}
+	for _, r := range cs.Create {
+		sets[recordSetOf(r)] = true
+	}
+	return sets
+}
+
+// seal sets the state hashes of a change set computed from existing:
+// Before covers the records of the touched record sets as they are, and
+// After as they are once the change set is applied
+func (cs *ChangeSet) seal(existing []libdns.Record) {
+	sets := cs.touched()
+
+	deleted := make(map[int64]bool)
+	for _, r := range cs.Delete {
+		deleted[recordID(r)] = true
+	}
+	var after []libdns.Record
+	for _, r := range existing {
+		if !deleted[recordID(r)] {
+			after = append(after, r)
+		}
+	}
+	after = append(after, cs.Create...)
+
+	cs.Before, cs.After = stateHash(existing, sets), stateHash(after, sets)
+}
+
+// verifyState returns ErrStalePlan if the record sets cs touches no
+// longer match its Before hash. Change sets without one are not checked.
+func (p *Provider) verifyState(ctx context.Context, cs *ChangeSet) error {
+	if cs.Before == "" {
+		return nil
+	}
+
+	existing, err := p.GetRecords(ctx, cs.Zone)
+	if err != nil {
+		return fmt.Errorf("failed to get existing records: %w", err)
+	}
+	if stateHash(existing, cs.touched()) != cs.Before {
+		return ErrStalePlan
+	}
+	return nil
+}
+
+// stateHash returns a content hash of the records in the given record
+// sets, independent of their order and of record IDs. It covers each
+// record's name, TTL, type, data and Rage4 settings.
+func stateHash(records []libdns.Record, sets map[recordSetKey]bool) string {
+	var lines []string
+	for _, r := range records {
+		if !sets[recordSetOf(r)] {
+			continue
+		}
+		rr := r.RR()
+		data, _ := rage4Data(r)
+		settings, _ := json.Marshal(settingsOf(data))
+		lines = append(lines, fmt.Sprintf("%s\t%d\t%s\t%s\t%s",
+			normalizeName(rr.Name), int(rr.TTL.Seconds()), rr.Type, rr.Data, settings))
+	}
+	slices.Sort(lines)
+	return hashString([]byte(strings.Join(lines, "\n")))
+}
+
+// hashString returns the SHA-256 hash of data in the form "sha256:<hex>"
+func hashString(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestPlanFormat(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "mail.example.com", Type: "A", Content: "192.0.2.9", TTL: 3600})
+
+	desired := []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}}
+	cs, err := p.Plan(ctx, "example.com.", desired)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !strings.HasPrefix(cs.Before, "sha256:") || cs.After == "" || cs.Before == cs.After {
+		t.Errorf("unexpected state hashes %q, %q", cs.Before, cs.After)
+	}
+
+	data, err := json.Marshal(cs)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"version":1,"zone":"example.com."`) {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+
+	var approved ChangeSet
+	if err := json.Unmarshal(data, &approved); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want, _ := cs.Hash()
+	if got, err := approved.Hash(); err != nil || got != want {
+		t.Errorf("expected the hash to survive encoding, got %q, want %q (%v)", got, want, err)
+	}
+
+	// changes to other record sets do not make the plan stale
+	f.addRecord(1, Rage4Record{Name: "ftp.example.com", Type: "A", Content: "192.0.2.7", TTL: 3600})
+	if err := p.Apply(ctx, &approved); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if got := stateHash(records, approved.touched()); got != approved.After {
+		t.Errorf("expected the zone to match the After hash, got %q, want %q", got, approved.After)
+	}
+
+	if err := json.Unmarshal([]byte(`{"version":2,"zone":"example.com."}`), &approved); err == nil {
+		t.Error("expected an error for a newer version")
+	}
+	var legacy ChangeSet
+	if err := json.Unmarshal([]byte(`{"zone":"example.com.","create":[{"name":"www","type":"A","data":"192.0.2.3"}]}`), &legacy); err != nil || len(legacy.Create) != 1 {
+		t.Errorf("expected an unversioned change set to decode, got %+v, %v", legacy, err)
+	}
+}
+
+func TestApplyStalePlan(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	cs, err := p.Plan(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	// someone adds a record to the set while the plan awaits approval
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.5", TTL: 3600})
+
+	if err := p.Apply(ctx, cs); !errors.Is(err, ErrStalePlan) {
+		t.Fatalf("expected ErrStalePlan, got %v", err)
+	}
+	if f.calls("DeleteRecord") != 0 || f.calls("CreateRecord") != 0 {
+		t.Error("expected a stale plan to change nothing")
+	}
+}
@@ -0,0 +1,163 @@
+package libdnsrage4
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Priority is the scheduling class of an operation. When the number of
+// concurrent API requests is limited with MaxConcurrentRequests, waiting
+// urgent requests are always sent before waiting background ones.
+type Priority int
+
+const (
+	// PriorityUrgent is for latency-sensitive work such as ACME
+	// challenges. It is the default.
+	PriorityUrgent Priority = iota
+
+	// PriorityBackground is for bulk work such as imports, content
+	// replacements, snapshots, and inventory exports, which use it
+	// unless told otherwise.
+	PriorityBackground
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose provider operations are
+// scheduled with the given priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// priorityFrom returns the priority of ctx
+func priorityFrom(ctx context.Context) Priority {
+	priority, _ := ctx.Value(priorityKey{}).(Priority)
+	return priority
+}
+
+// background marks ctx as background work unless it already carries a
+// priority
+func background(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return WithPriority(ctx, PriorityBackground)
+}
+
+// pipeline limits the number of concurrent API requests, handing free
+// slots to urgent waiters first. The zero value is ready to use.
+type pipeline struct {
+	mu      sync.Mutex
+	active  int
+	waiting [2][]chan struct{} // FIFO per priority
+}
+
+// acquire waits for a slot. The returned function releases it. With a
+// limit of zero or less, requests are not limited.
+func (pl *pipeline) acquire(ctx context.Context, priority Priority, limit int) (func(), error) {
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	if priority != PriorityBackground {
+		priority = PriorityUrgent
+	}
+
+	pl.mu.Lock()
+	if pl.active < limit && len(pl.waiting[PriorityUrgent]) == 0 && len(pl.waiting[PriorityBackground]) == 0 {
+		pl.active++
+		pl.mu.Unlock()
+		return pl.release, nil
+	}
+	ready := make(chan struct{})
+	pl.waiting[priority] = append(pl.waiting[priority], ready)
+	pl.mu.Unlock()
+
+	select {
+	case <-ready:
+		return pl.release, nil
+	case <-ctx.Done():
+		pl.mu.Lock()
+		i := slices.Index(pl.waiting[priority], ready)
+		if i >= 0 {
+			pl.waiting[priority] = slices.Delete(pl.waiting[priority], i, i+1)
+		}
+		pl.mu.Unlock()
+		if i < 0 {
+			// the slot was handed over concurrently
+			pl.release()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a slot, handing it to the next waiter if there is one
+func (pl *pipeline) release() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	for priority := range pl.waiting {
+		if len(pl.waiting[priority]) > 0 {
+			next := pl.waiting[priority][0]
+			pl.waiting[priority] = pl.waiting[priority][1:]
+			close(next)
+			return
+		}
+	}
+	pl.active--
+}
+
+// send sends an API request once the rate limit allows it and the
+// pipeline has a slot for it. delay is the time already spent backing
+// off before a retry of the request, which is counted as waiting. The
+// slot is held until the response body is closed, so that reading
+// large responses counts against MaxConcurrentRequests too.
+func (p *Provider) send(req *http.Request, delay time.Duration) (*http.Response, error) {
+	p.mu.RLock()
+	limit, rate, burst := p.MaxConcurrentRequests, p.RequestsPerSecond, p.RequestBurst
+	client := p.HTTPClient
+	p.mu.RUnlock()
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	start := time.Now().Add(-delay)
+	if rate > 0 {
+		if err := p.limiter.wait(req.Context(), rate, burst); err != nil {
+			return nil, err
+		}
+	}
+	release, err := p.pipeline.acquire(req.Context(), priorityFrom(req.Context()), limit)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+
+	resp, err := client.Do(req)
+	if op := p.operationFrom(req.Context()); op != nil {
+		op.time(req, start, wait, resp, err)
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody is a response body that frees its pipeline slot when it
+// is closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,145 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPipelinePrefersUrgent(t *testing.T) {
+	ctx := context.Background()
+	var pl pipeline
+
+	release, err := pl.acquire(ctx, PriorityUrgent, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []Priority
+		wg    sync.WaitGroup
+	)
+	enqueue := func(priority Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := pl.acquire(ctx, priority, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			release()
+		}()
+		// wait until the request is queued
+		for {
+			pl.mu.Lock()
+			n := len(pl.waiting[priority])
+			pl.mu.Unlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// background work queued first still goes after urgent work
+	enqueue(PriorityBackground)
+	enqueue(PriorityBackground)
+	enqueue(PriorityUrgent)
+
+	release()
+	wg.Wait()
+
+	want := []Priority{PriorityUrgent, PriorityBackground, PriorityBackground}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("unexpected order: %v", order)
+		}
+	}
+	if pl.active != 0 {
+		t.Errorf("expected every slot to be released, got %d active", pl.active)
+	}
+}
+
+func TestPipelineCancelledWait(t *testing.T) {
+	var pl pipeline
+	release, _ := pl.acquire(context.Background(), PriorityUrgent, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pl.acquire(ctx, PriorityBackground, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	release()
+	if pl.active != 0 || len(pl.waiting[PriorityBackground]) != 0 {
+		t.Errorf("expected the pipeline to be idle, got %d active", pl.active)
+	}
+}
+
+func TestBackgroundKeepsExplicitPriority(t *testing.T) {
+	if priorityFrom(background(context.Background())) != PriorityBackground {
+		t.Error("expected background priority by default")
+	}
+	if priorityFrom(background(WithPriority(context.Background(), PriorityUrgent))) != PriorityUrgent {
+		t.Error("expected explicit priority to be kept")
+	}
+}
+
+func TestSendHoldsSlotUntilBodyClosed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	p := &Provider{MaxConcurrentRequests: 1}
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	first, err := p.send(newRequest(), 0)
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		resp, err := p.send(newRequest(), 0)
+		if err == nil {
+			resp.Body.Close()
+		}
+		sent <- err
+	}()
+
+	select {
+	case err := <-sent:
+		t.Fatalf("second request sent while the first body was open: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first.Body.Close()
+	first.Body.Close() // closing twice frees the slot once
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Errorf("second request failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second request not sent after the first body was closed")
+	}
+	if p.pipeline.active != 0 {
+		t.Errorf("expected every slot to be free, %d active", p.pipeline.active)
+	}
+}
@@ -4,11 +4,15 @@ package libdnsrage4
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/libdns/libdns"
@@ -16,6 +20,12 @@ import (
 
 const baseURL = "https://rage4.com/rapi"
 
+// defaultHTTPClient sends requests when Provider.HTTPClient is not set
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// defaultTTL is the TTL of records created without one
+const defaultTTL = time.Hour
+
 // Provider facilitates DNS record manipulation with Rage4.
 type Provider struct {
 	// Email is the account email for Rage4 API authentication
@@ -23,10 +33,180 @@ type Provider struct {
 
 	// APIKey is the API key for Rage4 API authentication
 	APIKey string `json:"api_key,omitempty"`
+
+	// Endpoint is the base URL of the Rage4 API; it defaults to
+	// https://rage4.com/rapi
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// MaxRequestsPerOperation, if positive, caps the number of API
+	// requests a single operation such as SetRecords may issue. An
+	// operation exceeding it fails with ErrBudgetExceeded, which catches
+	// pathological request patterns before they hit rate limits. It can
+	// be overridden per call with WithRequestBudget.
+	MaxRequestsPerOperation int `json:"max_requests_per_operation,omitempty"`
+
+	// MaxConcurrentRequests, if positive, limits the number of API
+	// requests in flight at once. Requests beyond it wait, urgent ones
+	// first; see Priority.
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty"`
+
+	// BatchConcurrency, if greater than one, is the number of records
+	// AppendRecords and DeleteRecords create or delete at once, instead
+	// of one after the other. A failed record then does not stop the
+	// others; see BatchError.
+	BatchConcurrency int `json:"batch_concurrency,omitempty"`
+
+	// TransactionalSetRecords makes SetRecords roll back the changes it
+	// made when a later one fails: records it created are deleted,
+	// records it deleted are created again (with new IDs) and records
+	// it updated are changed back, so the zone is not left with part of
+	// the record set. If the rollback fails too, a *RollbackError is
+	// returned.
+	TransactionalSetRecords bool `json:"transactional_set_records,omitempty"`
+
+	// RequestsPerSecond, if positive, limits the rate of API requests,
+	// shared by every goroutine using the provider, so that batch work
+	// stays under Rage4's rate limits instead of tripping them
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+
+	// RequestBurst is the number of requests that may be sent at once
+	// before RequestsPerSecond applies; it defaults to RequestsPerSecond
+	// rounded up
+	RequestBurst int `json:"request_burst,omitempty"`
+
+	// AllowedTTLs, if set, are the TTLs in seconds the account may use.
+	// Other TTLs are snapped to the nearest allowed one; see SnapTTL.
+	AllowedTTLs []int `json:"allowed_ttls,omitempty"`
+
+	// StrictTTL makes TTLs that are not allowed an error instead of
+	// snapping them
+	StrictTTL bool `json:"strict_ttl,omitempty"`
+
+	// SkipExistingRecords makes AppendRecords return the existing record
+	// instead of creating another with the same name, type and data, so
+	// that repeated runs, such as retried ACME challenges, do not pile
+	// up duplicates
+	SkipExistingRecords bool `json:"skip_existing_records,omitempty"`
+
+	// MaxAttempts is the number of times an API request is tried
+	// before its error is returned, as a *RetryError; it defaults to 3.
+	// Failed attempts are retried with exponential backoff when they
+	// may succeed on another try.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// RetryBackoff is the backoff before the first retry, doubling with
+	// every attempt; it defaults to 500 milliseconds
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// HTTPClient, if set, sends every API request, for custom timeouts,
+	// proxies, TLS settings, or connection limits. It defaults to a
+	// client with a 30 second timeout.
+	HTTPClient *http.Client `json:"-"`
+
+	// Approver, if set, must approve every change set before Apply
+	// makes any changes to the zone
+	Approver Approver `json:"-"`
+
+	// Validators check the desired records passed to Plan before any
+	// changes are computed; see Validate
+	Validators []Validator `json:"-"`
+
+	// OnTiming, if set, is called with the timing breakdown of every
+	// operation when it ends, to tell slowness at Rage4 apart from time
+	// spent waiting on the provider's own limits
+	OnTiming func(OperationTiming) `json:"-"`
+
+	// OnSchemaDrift, if set, is called with the fields of API responses
+	// that the package does not model, each reported once, as an early
+	// warning of API changes
+	OnSchemaDrift func(SchemaDrift) `json:"-"`
+
+	// StrictFeatures makes records whose geo or failover settings the
+	// account's plan does not offer fail with ErrFeatureUnavailable.
+	// Without it, they are written without those settings, and
+	// OnDegraded, if set, is called for each.
+	StrictFeatures bool `json:"strict_features,omitempty"`
+
+	// OnDegraded, if set, is called for every record written without
+	// the settings of a feature the account does not offer
+	OnDegraded func(Degradation) `json:"-"`
+
+	// UndoStore, if set, enables soft delete: DeleteRecords, including
+	// the deletions of Apply, first saves the records it deletes there,
+	// so that they can be restored with Undo within UndoWindow
+	UndoStore Store `json:"-"`
+
+	// UndoWindow is how long soft deletions can be undone; it defaults
+	// to 15 minutes
+	UndoWindow time.Duration `json:"undo_window,omitempty"`
+
+	// JournalStore, if set, journals every change before it is sent to
+	// Rage4 and removes it once Rage4 has answered, so that after a crash
+	// PendingMutations and ReconcileJournal can tell which changes of an
+	// interrupted batch took effect
+	JournalStore Store `json:"-"`
+
+	// Resolver, if set, answers the DNS lookups of OnboardZone and
+	// ACMEDelegation, in place of net.DefaultResolver
+	Resolver Resolver `json:"-"`
+
+	// ZoneDefaults are the default settings of records created in each
+	// zone, keyed by zone name
+	ZoneDefaults map[string]ZoneDefaults `json:"zone_defaults,omitempty"`
+
+	// RecordDescription tags the records created in zones whose
+	// ZoneDefaults set no description, such as "managed-by:libdns", so
+	// that they can be told apart from records created by hand
+	RecordDescription string `json:"record_description,omitempty"`
+
+	// Quotas limit the changes each tenant may make, keyed by the
+	// tenant set with WithTenant; operations of other tenants, and
+	// those made without one, are not limited
+	Quotas map[string]Quota `json:"quotas,omitempty"`
+
+	// DomainCacheTTL is how long the IDs of zones are cached, so that
+	// operations do not each read the domain list; it defaults to five
+	// minutes, and a negative value disables the cache. See
+	// InvalidateDomainCache.
+	DomainCacheTTL time.Duration `json:"domain_cache_ttl,omitempty"`
+
+	// DomainCacheStale is how long past DomainCacheTTL a zone's cached
+	// ID is still used, while it is looked up again in the background,
+	// so that operations such as ACME challenges do not wait for a slow
+	// API. Zero, the default, makes operations wait for the lookup.
+	DomainCacheStale time.Duration `json:"domain_cache_stale,omitempty"`
+
+	mu sync.RWMutex // guards the settings swapped by Reload
+
+	pipeline    pipeline
+	stats       statsRecorder
+	recordTypes recordTypeCache
+	geoRegions  geoRegionCache
+	domains     domainCache
+	limiter     rateLimiter
+	drift       driftTracker
+	quotas      quotaTracker
+	features    featureTracker
+
+	opsMu      sync.Mutex // guards the fields below
+	inflight   int
+	closed     bool
+	drained    chan struct{} // closed when inflight drops to zero after Close
+	bgCtx      context.Context
+	stopBgWork context.CancelFunc
 }
 
-// GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+// GetRecords lists all the records in the zone. Records of the types
+// libdns has structs for are returned as those, with the Rage4Record
+// they were read from as ProviderData; others are returned as
+// libdns.RR.
+func (p *Provider) GetRecords(ctx context.Context, zone string) (_ []libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "GetRecords", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
 	domainID, err := p.getDomainID(ctx, zone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get domain ID: %w", err)
@@ -34,204 +214,436 @@ func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record
 
 	// Remove trailing dot from zone for name conversion
 	zoneName := strings.TrimSuffix(zone, ".")
-
-	url := fmt.Sprintf("%s/GetRecords?id=%d", baseURL, domainID)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	result, err := p.getRage4Records(ctx, domainID, zoneName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return readRecords(ctx, result, zoneName)
+}
 
-	req.SetBasicAuth(p.Email, p.APIKey)
-	resp, err := http.DefaultClient.Do(req)
+// AppendRecords adds records to the zone. It returns the records that were added,
+// carrying the ID Rage4 assigned in ProviderData and the TTL they were created
+// with, so that DeleteRecords can remove them by ID without reading the zone.
+// With SkipExistingRecords set, records already in the zone are returned
+// as they are instead of being added again. If a record fails, the records
+// added before are returned along with a *BatchError naming it.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "AppendRecords", zone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("received non-200 response: %d %s", resp.StatusCode, string(body))
+		return nil, err
 	}
+	defer done(&err)
 
-	body, err := io.ReadAll(resp.Body)
+	domainID, err := p.getDomainID(ctx, zone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
 	}
 
-	var result []Rage4Record
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
-	}
+	// Remove trailing dot from zone for name construction
+	zoneName := strings.TrimSuffix(zone, ".")
 
-	var records []libdns.Record
-	for _, record := range result {
-		records = append(records, toLibdnsRecord(record, zoneName))
+	p.mu.RLock()
+	skipExisting := p.SkipExistingRecords
+	p.mu.RUnlock()
+	if !skipExisting {
+		return p.appendRecords(ctx, domainID, zoneName, records)
 	}
-	return records, nil
+	return p.appendMissingRecords(ctx, domainID, zoneName, records)
 }
 
-// AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	domainID, err := p.getDomainID(ctx, zone)
+// appendMissingRecords creates the records that are not in the zone yet,
+// comparing name, type and data, and returns the existing record in
+// place of each of the others
+func (p *Provider) appendMissingRecords(ctx context.Context, domainID int64, zoneName string, records []libdns.Record) ([]libdns.Record, error) {
+	existing, err := p.getRage4Records(ctx, domainID, zoneName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
 	}
 
-	// Remove trailing dot from zone for name construction
-	zoneName := strings.TrimSuffix(zone, ".")
+	defaults := p.zoneDefaults(zoneName)
 
-	var appendedRecords []libdns.Record
-	for _, record := range records {
-		ttl := int(record.TTL.Seconds())
-		if ttl == 0 {
-			ttl = 3600
+	var result []libdns.Record
+	for i, record := range records {
+		rr := record.RR()
+		r, _ := findRecord(existing, zoneName, libdns.RR{Name: rr.Name, Type: rr.Type, Data: rr.Data})
+		if r.ID == 0 {
+			if r, err = p.createRecord(ctx, domainID, zoneName, defaults, record); err != nil {
+				return result, batchError([]*RecordError{{Record: record, Err: err}}, records[i+1:])
+			}
+			// later duplicates in records find the one just created
+			existing = append(existing, r)
 		}
 
-		// Construct the full record name (FQDN)
-		var fullName string
-		if record.Name == "" || record.Name == "@" {
-			fullName = zoneName
-		} else {
-			fullName = record.Name + "." + zoneName
+		converted, err := fromRage4(r, zoneName)
+		if err != nil {
+			return result, batchError([]*RecordError{{Record: record, Err: err}}, records[i+1:])
 		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
 
-		url := fmt.Sprintf("%s/CreateRecord?id=%d&name=%s&content=%s&type=%s&ttl=%d",
-			baseURL, domainID, fullName, record.Value, record.Type, ttl)
+// appendRecords creates records in the domain with the given ID
+func (p *Provider) appendRecords(ctx context.Context, domainID int64, zoneName string, records []libdns.Record) ([]libdns.Record, error) {
+	defaults := p.zoneDefaults(zoneName)
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+	if workers := p.batchWorkers(); workers > 1 {
+		created, errs := batch(workers, len(records), func(i int) (libdns.Record, error) {
+			r, err := p.createRecord(ctx, domainID, zoneName, defaults, records[i])
+			if err != nil {
+				return nil, err
+			}
+			return fromRage4(r, zoneName)
+		})
+
+		var appendedRecords []libdns.Record
+		var failed []*RecordError
+		for i, err := range errs {
+			if err != nil {
+				failed = append(failed, &RecordError{Record: records[i], Err: err})
+			} else {
+				appendedRecords = append(appendedRecords, created[i])
+			}
 		}
+		return appendedRecords, batchError(failed, nil)
+	}
 
-		req.SetBasicAuth(p.Email, p.APIKey)
-		resp, err := http.DefaultClient.Do(req)
+	var appendedRecords []libdns.Record
+	for i, record := range records {
+		r, err := p.createRecord(ctx, domainID, zoneName, defaults, record)
 		if err != nil {
-			return nil, fmt.Errorf("failed to make request: %w", err)
+			return appendedRecords, batchError([]*RecordError{{Record: record, Err: err}}, records[i+1:])
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("failed to create record: %d %s", resp.StatusCode, string(body))
+		created, err := fromRage4(r, zoneName)
+		if err != nil {
+			return appendedRecords, batchError([]*RecordError{{Record: record, Err: err}}, records[i+1:])
 		}
+		appendedRecords = append(appendedRecords, created)
+	}
 
-		body, _ := io.ReadAll(resp.Body)
-		var result CommonResponse
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
+	return appendedRecords, nil
+}
 
-		if !result.Status {
-			return nil, fmt.Errorf("API returned error: %s", result.Error)
-		}
+// createRecord creates a record in the domain with the given ID, filling
+// in the zone defaults, and returns it as stored by Rage4
+func (p *Provider) createRecord(ctx context.Context, domainID int64, zoneName string, defaults ZoneDefaults, record libdns.Record) (Rage4Record, error) {
+	rr := record.RR()
+	r, err := toRage4(record, zoneName)
+	if err != nil {
+		return Rage4Record{}, err
+	}
+	defaults.apply(&r)
+	if r.Type, err = p.recordType(ctx, r.Type); err != nil {
+		return Rage4Record{}, err
+	}
 
-		appendedRecords = append(appendedRecords, record)
+	ttl, err := p.SnapTTL(time.Duration(r.TTL) * time.Second)
+	if err != nil {
+		return Rage4Record{}, fmt.Errorf("invalid record %s %s: %w", rr.Name, rr.Type, err)
 	}
+	r.TTL = int(ttl.Seconds())
 
-	return appendedRecords, nil
+	params := url.Values{
+		"id":       {strconv.FormatInt(domainID, 10)},
+		"name":     {r.Name},
+		"content":  {r.Content},
+		"type":     {r.Type},
+		"ttl":      {strconv.Itoa(r.TTL)},
+		"priority": {strconv.Itoa(r.Priority)},
+	}
+	var result CommonResponse
+	if err := p.postRecord(ctx, "CreateRecord", zoneName, params, &r, &result); err != nil {
+		return Rage4Record{}, fmt.Errorf("failed to create record: %w", err)
+	}
+
+	r.ID, r.DomainID = result.ID, domainID
+	return r, nil
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
-// It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	existingRecords, err := p.GetRecords(ctx, zone)
+// Existing records of the same name and type are updated in place with
+// UpdateRecord where possible, so they never disappear and keep
+// Rage4-specific settings such as geo and failover; records already
+// matching are left alone, surplus ones are deleted, and missing ones
+// are created. It returns the records that were set. With
+// TransactionalSetRecords, a failure rolls back the changes already
+// made.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "SetRecords", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	domainID, err := p.getDomainID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	if records, err = p.snapTTLs(records); err != nil {
+		return nil, err
+	}
+
+	// The zone is read once; the records to update and delete carry
+	// their IDs, and the raw records are handed down for any lookups
+	// DeleteRecords would otherwise repeat.
+	raw, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+	existingRecords, err := convertRecords(raw, zoneName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing records: %w", err)
 	}
 
-	// Find records to delete (existing records with same name and type as new records)
-	var toDelete []libdns.Record
+	// Existing records with the same name and type as new records are
+	// kept if they already match and reused for the others
+	set := make([]libdns.Record, len(records))
+	matched := make([]bool, len(records))
+	var reusable []libdns.Record
 	for _, existing := range existingRecords {
-		for _, newRecord := range records {
-			if existing.Name == newRecord.Name && existing.Type == newRecord.Type {
-				toDelete = append(toDelete, existing)
+		managed, kept := false, false
+		for i, newRecord := range records {
+			if !sameRecordSet(existing, newRecord) {
+				continue
+			}
+			managed = true
+			if !matched[i] && sameRecord(existing, newRecord) {
+				set[i], matched[i], kept = existing, true, true
 				break
 			}
 		}
+		if managed && !kept {
+			reusable = append(reusable, existing)
+		}
 	}
 
-	// Delete old records
-	if len(toDelete) > 0 {
-		_, err := p.DeleteRecords(ctx, zone, toDelete)
+	// In transactional mode, every change is recorded so that a failure
+	// leaves the zone as it was
+	var rb *setRollback
+	if p.transactionalSetRecords() {
+		rb = &setRollback{domainID: domainID, zoneName: zoneName}
+		defer func() {
+			if err == nil {
+				return
+			}
+			if rollbackErr := rb.undo(ctx, p); rollbackErr != nil {
+				err = &RollbackError{Err: err, RollbackErr: rollbackErr}
+			}
+		}()
+	}
+
+	var toCreate []int
+	for i, newRecord := range records {
+		if matched[i] {
+			continue
+		}
+		j := slices.IndexFunc(reusable, func(r libdns.Record) bool {
+			return sameRecordSet(r, newRecord)
+		})
+		if j < 0 {
+			toCreate = append(toCreate, i)
+			continue
+		}
+
+		updated, err := p.updateRecord(ctx, zoneName, reusable[j], newRecord)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update record: %w", err)
+		}
+		set[i] = updated
+		if rb != nil {
+			rb.updated = append(rb.updated, updatedRecord{before: reusable[j], after: updated})
+		}
+		reusable = slices.Delete(reusable, j, j+1)
+	}
+
+	// Delete surplus records
+	if len(reusable) > 0 {
+		deleted, err := p.deleteRecords(ctx, domainID, zoneName, reusable, raw)
+		if rb != nil {
+			rb.deleted = deleted
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to delete old records: %w", err)
 		}
 	}
 
-	// Append new records
-	appendedRecords, err := p.AppendRecords(ctx, zone, records)
+	// Create missing records
+	for _, i := range toCreate {
+		created, err := p.appendRecords(ctx, domainID, zoneName, records[i:i+1])
+		if rb != nil {
+			rb.created = append(rb.created, created...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to append new records: %w", err)
+		}
+		set[i] = created[0]
+	}
+
+	return set, nil
+}
+
+// updateRecord changes the existing record in place to record, and
+// returns the updated record. The Rage4-specific settings of existing,
+// such as geo and failover, are kept unless record sets them; see
+// desiredSettings.
+func (p *Provider) updateRecord(ctx context.Context, zoneName string, existing, record libdns.Record) (libdns.Record, error) {
+	rr := record.RR()
+	r, err := toRage4(record, zoneName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to append new records: %w", err)
+		return nil, err
 	}
+	current, _ := rage4Data(existing)
+	desiredSettings(existing, record).applyTo(&r)
+	id := current.ID
 
-	return appendedRecords, nil
+	ttl, err := p.SnapTTL(time.Duration(r.TTL) * time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid record %s %s: %w", rr.Name, rr.Type, err)
+	}
+	r.ID, r.TTL = id, int(ttl.Seconds())
+
+	params := url.Values{
+		"id":       {strconv.FormatInt(id, 10)},
+		"name":     {r.Name},
+		"content":  {r.Content},
+		"ttl":      {strconv.Itoa(r.TTL)},
+		"priority": {strconv.Itoa(r.Priority)},
+	}
+	if err := p.postRecord(ctx, "UpdateRecord", zoneName, params, &r, nil); err != nil {
+		return nil, fmt.Errorf("failed to update record: %w", err)
+	}
+
+	return fromRage4(r, zoneName)
 }
 
 // DeleteRecords deletes the specified records from the zone. It returns the records that were deleted.
-func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// Records read from Rage4 are deleted by ID; others are matched by name
+// and, unless left empty or zero, type, data and TTL. Records that do not
+// exist in the zone are ignored. If a record fails, the records deleted
+// before are returned along with a *BatchError naming it.
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "DeleteRecords", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
 	domainID, err := p.getDomainID(ctx, zone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get domain ID: %w", err)
 	}
 
+	var existing []Rage4Record
+	if p.UndoStore != nil {
+		if existing, err = p.softDelete(ctx, domainID, zone, records); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.deleteRecords(ctx, domainID, strings.TrimSuffix(zone, "."), records, existing)
+}
+
+// deleteRecords deletes records from the domain with the given ID.
+// Records without an ID are looked up in existing, which is fetched at
+// most once if nil, and skipped if they are not found.
+func (p *Provider) deleteRecords(ctx context.Context, domainID int64, zoneName string, records []libdns.Record, existing []Rage4Record) ([]libdns.Record, error) {
+	if workers := p.batchWorkers(); workers > 1 {
+		return p.deleteRecordsConcurrently(ctx, domainID, zoneName, records, existing, workers)
+	}
+
 	var deletedRecords []libdns.Record
-	for _, record := range records {
-		// If record has an ID, use it directly; otherwise, find it by name/type/value
-		recordID := 0
-		if record.ID != "" {
-			// Try to parse the ID if it's provided
-			if id, err := strconv.Atoi(record.ID); err == nil {
-				recordID = id
+	for i, record := range records {
+		// If record has an ID, use it directly; otherwise, find it by name/type/data
+		id := recordID(record)
+		deleted := record
+		if id == 0 {
+			if existing == nil {
+				var err error
+				existing, err = p.getRage4Records(ctx, domainID, zoneName)
+				if err != nil {
+					return deletedRecords, fmt.Errorf("failed to get existing records: %w", err)
+				}
 			}
-		}
 
-		// If no ID, find it by matching name, type, and value
-		if recordID == 0 {
-			var err error
-			recordID, err = p.getRecordID(ctx, domainID, record)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get record ID: %w", err)
+			var found Rage4Record
+			found, existing = findRecord(existing, zoneName, record)
+			if found.ID == 0 {
+				continue
+			}
+			id = found.ID
+			deleted = nil
+			if converted, err := fromRage4(found, zoneName); err == nil {
+				deleted = converted
 			}
 		}
 
-		url := fmt.Sprintf("%s/DeleteRecord?id=%d", baseURL, recordID)
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+		if err := p.post(ctx, "DeleteRecord", url.Values{"id": {strconv.FormatInt(id, 10)}}, nil); err != nil {
+			err = fmt.Errorf("failed to delete record: %w", err)
+			return deletedRecords, batchError([]*RecordError{{Record: record, Err: err}}, records[i+1:])
 		}
-
-		req.SetBasicAuth(p.Email, p.APIKey)
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to make request: %w", err)
+		if deleted != nil {
+			deletedRecords = append(deletedRecords, deleted)
 		}
-		defer resp.Body.Close()
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("failed to delete record: %d %s", resp.StatusCode, string(body))
-		}
+	return deletedRecords, nil
+}
 
-		body, _ := io.ReadAll(resp.Body)
-		var result CommonResponse
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
+// deleteRecordsConcurrently is deleteRecords with up to workers records
+// deleted at once. The records are all looked up first, so that
+// duplicates are matched to different records as in deleteRecords.
+func (p *Provider) deleteRecordsConcurrently(ctx context.Context, domainID int64, zoneName string, records []libdns.Record, existing []Rage4Record, workers int) ([]libdns.Record, error) {
+	var (
+		ids    []int64
+		toDrop []libdns.Record
+	)
+	for _, record := range records {
+		id := recordID(record)
+		if id == 0 {
+			if existing == nil {
+				var err error
+				existing, err = p.getRage4Records(ctx, domainID, zoneName)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get existing records: %w", err)
+				}
+			}
 
-		if !result.Status {
-			return nil, fmt.Errorf("API returned error: %s", result.Error)
+			var found Rage4Record
+			found, existing = findRecord(existing, zoneName, record)
+			if found.ID == 0 {
+				continue
+			}
+			id = found.ID
+			if converted, err := fromRage4(found, zoneName); err == nil {
+				record = converted
+			}
 		}
-
-		deletedRecords = append(deletedRecords, record)
+		ids = append(ids, id)
+		toDrop = append(toDrop, record)
 	}
 
-	return deletedRecords, nil
+	_, errs := batch(workers, len(ids), func(i int) (struct{}, error) {
+		return struct{}{}, p.post(ctx, "DeleteRecord", url.Values{"id": {strconv.FormatInt(ids[i], 10)}}, nil)
+	})
+
+	var deletedRecords []libdns.Record
+	var failed []*RecordError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &RecordError{Record: toDrop[i], Err: fmt.Errorf("failed to delete record: %w", err)})
+		} else {
+			deletedRecords = append(deletedRecords, toDrop[i])
+		}
+	}
+	return deletedRecords, batchError(failed, nil)
 }
 
 // Rage4Record represents a DNS record from Rage4 API
 type Rage4Record struct {
-	ID               int      `json:"id"`
-	DomainID         int      `json:"domain_id"`
+	ID               int64    `json:"id"`
+	DomainID         int64    `json:"domain_id"`
 	Name             string   `json:"name"`
 	Content          string   `json:"content"`
 	Type             string   `json:"type"`
@@ -248,152 +660,300 @@ type Rage4Record struct {
 	GeoAsNum         *int64   `json:"geo_asnum"`
 	UDPLimit         bool     `json:"udp_limit"`
 	Description      *string  `json:"description"`
-	WebhookID        *int     `json:"webhook_id"`
+	WebhookID        *int64   `json:"webhook_id"`
 	IsSystem         bool     `json:"is_system"`
 	Weight           int      `json:"weight"`
+
+	// overrides are the settings WithGeo and the like set on a record
+	// without provider data; when it replaces an existing record, the
+	// other settings of that record are kept. Zero means every setting.
+	overrides settingMask
 }
 
 // CommonResponse represents a common API response from Rage4
 type CommonResponse struct {
 	Status bool   `json:"status"`
-	ID     int    `json:"id"`
+	ID     int64  `json:"id"`
 	Error  string `json:"error"`
 }
 
 // DomainResponse represents a domain from Rage4 API
 type DomainResponse struct {
-	ID    int    `json:"id"`
+	ID    int64  `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"owner_email"`
 }
 
-// getDomainID retrieves the domain ID from Rage4 API
-func (p *Provider) getDomainID(ctx context.Context, zone string) (int, error) {
-	// Remove trailing dot if present
-	zone = strings.TrimSuffix(zone, ".")
+// UnmarshalJSON decodes a domain, reading its ID as an exact 64-bit
+// integer, also when quoted.
+func (d *DomainResponse) UnmarshalJSON(data []byte) error {
+	type plain DomainResponse
+	aux := struct {
+		*plain
+		ID json.Number `json:"id"`
+	}{plain: (*plain)(d)}
 
-	url := fmt.Sprintf("%s/GetDomains", baseURL)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
 	}
-
-	req.SetBasicAuth(p.Email, p.APIKey)
-	resp, err := http.DefaultClient.Do(req)
+	id, err := parseID(aux.ID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("invalid domain ID: %w", err)
 	}
-	defer resp.Body.Close()
+	d.ID = id
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("received non-200 response: %d %s", resp.StatusCode, string(body))
+// newRequest creates an authenticated request for an API method. The
+// parameters are sent in the query string of GET requests and as a form
+// body otherwise, encoded either way. The settings are read once per
+// request, so a concurrent Reload applies from the next request on.
+// Requests count against the budget of the operation they belong to,
+// except those rolling it back.
+func (p *Provider) newRequest(ctx context.Context, httpMethod, method string, params url.Values) (*http.Request, error) {
+	if op := p.operationFrom(ctx); op != nil && !rollingBack(ctx) {
+		if err := op.charge(method); err != nil {
+			return nil, err
+		}
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %w", err)
-	}
+	p.mu.RLock()
+	endpoint, email, apiKey := p.Endpoint, p.Email, p.APIKey
+	p.mu.RUnlock()
 
-	var domains []DomainResponse
-	if err := json.Unmarshal(body, &domains); err != nil {
-		return 0, fmt.Errorf("failed to parse JSON: %w", err)
+	if endpoint == "" {
+		endpoint = baseURL
 	}
 
-	for _, domain := range domains {
-		if domain.Name == zone {
-			return domain.ID, nil
+	target := strings.TrimSuffix(endpoint, "/") + "/" + method
+	var body io.Reader
+	if httpMethod == http.MethodGet {
+		if len(params) > 0 {
+			target += "?" + params.Encode()
 		}
+	} else {
+		body = strings.NewReader(params.Encode())
 	}
 
-	return 0, fmt.Errorf("domain not found: %s", zone)
+	req, err := http.NewRequestWithContext(ctx, httpMethod, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.SetBasicAuth(email, apiKey)
+	return req, nil
 }
 
-// getRecordID retrieves the record ID by matching name, type, and value
-func (p *Provider) getRecordID(ctx context.Context, domainID int, record libdns.Record) (int, error) {
-	url := fmt.Sprintf("%s/GetRecords?id=%d", baseURL, domainID)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// ListZones lists the zones in the account, with trailing dots.
+func (p *Provider) ListZones(ctx context.Context) (_ []libdns.Zone, err error) {
+	ctx, done, err := p.beginOp(ctx, "ListZones", "")
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	defer done(&err)
 
-	req.SetBasicAuth(p.Email, p.APIKey)
-	resp, err := http.DefaultClient.Do(req)
+	domains, err := p.listDomains(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to list domains: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("received non-200 response: %d %s", resp.StatusCode, string(body))
+	zones := make([]libdns.Zone, len(domains))
+	for i, domain := range domains {
+		zones[i] = libdns.Zone{Name: domain.Name + "."}
 	}
+	return zones, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %w", err)
+// getDomainID retrieves the ID of the domain holding zone from Rage4
+// API, or from the cache of domain IDs. See resolveDomain.
+func (p *Provider) getDomainID(ctx context.Context, zone string) (int64, error) {
+	id, _, err := p.resolveDomain(ctx, zone)
+	return id, err
+}
+
+// resolveDomain returns the ID and name of the domain holding zone: the
+// domain of that name or, if there is none, of its deepest parent, so
+// that "sub.example.com." can be managed within "example.com". Zones
+// missing from the cache are looked up by name, or in the domain list
+// if Rage4 does not offer GetDomainByName.
+func (p *Provider) resolveDomain(ctx context.Context, zone string) (int64, string, error) {
+	// Remove trailing dot if present
+	zone = strings.TrimSuffix(zone, ".")
+
+	if cached, ok, stale := p.cachedDomain(zone); ok {
+		if stale {
+			p.refreshDomain(zone)
+		}
+		return cached.id, cached.name, nil
 	}
+	return p.lookupDomain(ctx, zone)
+}
 
-	var records []Rage4Record
-	if err := json.Unmarshal(body, &records); err != nil {
-		return 0, fmt.Errorf("failed to parse JSON: %w", err)
+// lookupDomain looks up the domain holding zone, a name without the
+// trailing dot, and caches it
+func (p *Provider) lookupDomain(ctx context.Context, zone string) (int64, string, error) {
+	if p.lookupByName() {
+		domain, err := p.findDomainByName(ctx, zone)
+		if err == nil {
+			p.cacheDomain(zone, domain)
+			return domain.ID, domain.Name, nil
+		}
+		if !errors.Is(err, errLookupByNameUnavailable) {
+			return 0, "", err
+		}
+		p.disableLookupByName()
 	}
 
-	// We need to get the zone name to convert Rage4's full names to relative names
-	// Get domain info to retrieve the zone name
-	domainURL := fmt.Sprintf("%s/GetDomain?id=%d", baseURL, domainID)
-	domainReq, err := http.NewRequestWithContext(ctx, "GET", domainURL, nil)
+	domains, err := p.listDomains(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create domain request: %w", err)
+		return 0, "", err
 	}
-	domainReq.SetBasicAuth(p.Email, p.APIKey)
-	domainResp, err := http.DefaultClient.Do(domainReq)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get domain info: %w", err)
+
+	for _, name := range zoneCandidates(zone) {
+		for _, domain := range domains {
+			if domain.Name == name {
+				p.cacheDomain(zone, domain)
+				return domain.ID, domain.Name, nil
+			}
+		}
 	}
-	defer domainResp.Body.Close()
 
-	var domain DomainResponse
-	if err := json.NewDecoder(domainResp.Body).Decode(&domain); err != nil {
-		return 0, fmt.Errorf("failed to parse domain info: %w", err)
-	}
-	zoneName := domain.Name
-
-	for _, r := range records {
-		// Convert Rage4's full name to relative name for comparison
-		relativeName := r.Name
-		if strings.HasSuffix(r.Name, "."+zoneName) {
-			relativeName = strings.TrimSuffix(r.Name, "."+zoneName)
-		} else if r.Name == zoneName {
-			relativeName = "@"
+	return 0, "", fmt.Errorf("%w: %s", ErrZoneNotFound, zone)
+}
+
+// zoneCandidates returns zone and its parents with at least two labels,
+// deepest first, such as "a.b.example.com", "b.example.com" and
+// "example.com"
+func zoneCandidates(zone string) []string {
+	candidates := []string{zone}
+	for name := zone; strings.Count(name, ".") > 1; {
+		_, name, _ = strings.Cut(name, ".")
+		candidates = append(candidates, name)
+	}
+	return candidates
+}
+
+// inZone reports whether name, a full record name, is zoneName or below
+// it
+func inZone(name, zoneName string) bool {
+	name, zoneName = strings.ToLower(name), strings.ToLower(zoneName)
+	return name == zoneName || strings.HasSuffix(name, "."+zoneName)
+}
+
+// errLookupByNameUnavailable is returned by getDomainByName when Rage4
+// does not offer GetDomainByName
+var errLookupByNameUnavailable = errors.New("GetDomainByName not available")
+
+// findDomainByName looks up the domain holding zone, trying the zone and
+// then its parents by name
+func (p *Provider) findDomainByName(ctx context.Context, zone string) (DomainResponse, error) {
+	for _, name := range zoneCandidates(zone) {
+		domain, err := p.getDomainByName(ctx, name)
+		if !errors.Is(err, ErrZoneNotFound) {
+			return domain, err
 		}
+	}
+	return DomainResponse{}, fmt.Errorf("%w: %s", ErrZoneNotFound, zone)
+}
 
-		// For TXT records, compare values with and without quotes
-		// since Rage4 API adds quotes to TXT record values
-		valueMatches := false
-		if r.Type == "TXT" {
-			// Remove quotes from API response if present
-			apiValue := r.Content
-			if len(apiValue) >= 2 && apiValue[0] == '"' && apiValue[len(apiValue)-1] == '"' {
-				apiValue = apiValue[1 : len(apiValue)-1]
-			}
-			valueMatches = (apiValue == record.Value)
-		} else {
-			valueMatches = (r.Content == record.Value)
+// getDomainByName looks a single domain up, instead of reading the
+// whole domain list
+func (p *Provider) getDomainByName(ctx context.Context, name string) (DomainResponse, error) {
+	var domain DomainResponse
+	err := p.get(ctx, "GetDomainByName", url.Values{"name": {name}}, &domain)
+
+	// Rage4 may answer a missing domain with a 404 too, so only a 404
+	// that is not its own error means the method is unknown
+	var apiErr *APIError
+	missingMethod := errors.As(err, &apiErr) && apiErr.missingMethod()
+	switch {
+	case errors.Is(err, ErrZoneNotFound) && !missingMethod, err == nil && domain.ID == 0:
+		return DomainResponse{}, fmt.Errorf("%w: %s", ErrZoneNotFound, name)
+	case missingMethod:
+		return DomainResponse{}, fmt.Errorf("%w: %v", errLookupByNameUnavailable, err)
+	case err != nil:
+		return DomainResponse{}, err
+	}
+
+	domain.Name = name
+	return domain, nil
+}
+
+// listDomains retrieves all domains in the account from Rage4 API and
+// refreshes the cache of domain IDs with them
+func (p *Provider) listDomains(ctx context.Context) ([]DomainResponse, error) {
+	var domains []DomainResponse
+	if err := p.get(ctx, "GetDomains", nil, &domains); err != nil {
+		return nil, err
+	}
+	p.cacheDomains(domains)
+
+	return domains, nil
+}
+
+// getRage4Records retrieves the raw records of a domain from Rage4 API,
+// keeping those of zoneName, the domain itself or a subzone of it
+func (p *Provider) getRage4Records(ctx context.Context, domainID int64, zoneName string) ([]Rage4Record, error) {
+	var records []Rage4Record
+	if err := p.get(ctx, "GetRecords", url.Values{"id": {strconv.FormatInt(domainID, 10)}}, &records); err != nil {
+		return nil, err
+	}
+	records = slices.DeleteFunc(records, func(r Rage4Record) bool {
+		return !inZone(r.Name, zoneName)
+	})
+
+	if err := p.resolveRecordTypes(ctx, records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// findRecord finds record among existing by matching name and, unless
+// left empty or zero in record, type, data, and TTL. zoneName is the
+// domain name without a trailing dot, used to convert Rage4's full names
+// to relative ones. The matched record is removed from the returned
+// slice, so deleting duplicates finds each copy in turn; if none
+// matches, the returned record has a zero ID.
+func findRecord(existing []Rage4Record, zoneName string, record libdns.Record) (Rage4Record, []Rage4Record) {
+	want := record.RR()
+	for i, r := range existing {
+		// Compare in libdns form, so that TXT quoting and registered
+		// converters are taken into account
+		rr, err := fromRage4RR(r, zoneName)
+		if err != nil {
+			continue
 		}
 
-		// Compare using relative names
-		if relativeName == record.Name && r.Type == record.Type && valueMatches {
-			return r.ID, nil
+		if rr.Name == normalizeName(want.Name) &&
+			(want.Type == "" || rr.Type == want.Type) &&
+			(want.Data == "" || rr.Data == want.Data) &&
+			(want.TTL == 0 || rr.TTL == want.TTL) {
+			return r, slices.Delete(slices.Clone(existing), i, i+1)
 		}
 	}
 
-	return 0, fmt.Errorf("record not found: %s %s", record.Name, record.Type)
+	return Rage4Record{}, existing
+}
+
+// convertRecords converts Rage4 records to libdns records
+func convertRecords(result []Rage4Record, zoneName string) ([]libdns.Record, error) {
+	var records []libdns.Record
+	for _, record := range result {
+		converted, err := fromRage4(record, zoneName)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, converted)
+	}
+	return records, nil
 }
 
-// toLibdnsRecord converts a Rage4Record to a libdns.Record
+// toLibdnsRR converts a Rage4Record to a libdns.RR
 // It converts the full FQDN name from Rage4 to a relative name for libdns
-func toLibdnsRecord(r Rage4Record, zoneName string) libdns.Record {
+func toLibdnsRR(r Rage4Record, zoneName string) libdns.RR {
 	// Convert full name to relative name
 	// If name equals zone, it's the root record (@)
 	// Otherwise, strip the zone suffix
@@ -414,14 +974,11 @@ func toLibdnsRecord(r Rage4Record, zoneName string) libdns.Record {
 		value = value[1 : len(value)-1]
 	}
 
-	return libdns.Record{
-		ID:       strconv.Itoa(r.ID),
-		Type:     r.Type,
-		Name:     relativeName,
-		Value:    value,
-		TTL:      time.Duration(r.TTL) * time.Second,
-		Priority: uint(r.Priority),
-		Weight:   uint(r.Weight),
+	return libdns.RR{
+		Name: relativeName,
+		TTL:  time.Duration(r.TTL) * time.Second,
+		Type: r.Type,
+		Data: value,
 	}
 }
 
@@ -431,4 +988,5 @@ var (
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
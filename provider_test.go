@@ -2,6 +2,12 @@ package libdnsrage4
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,7 +44,7 @@ func TestToLibdnsRecord(t *testing.T) {
 		name     string
 		input    Rage4Record
 		zone     string
-		expected libdns.Record
+		expected libdns.RR
 	}{
 		{
 			name: "A record - subdomain",
@@ -50,12 +56,11 @@ func TestToLibdnsRecord(t *testing.T) {
 				TTL:     3600,
 			},
 			zone: "example.com",
-			expected: libdns.Record{
-				ID:    "123",
-				Name:  "www",
-				Type:  "A",
-				Value: "192.0.2.1",
-				TTL:   3600 * time.Second,
+			expected: libdns.RR{
+				Name: "www",
+				Type: "A",
+				Data: "192.0.2.1",
+				TTL:  3600 * time.Second,
 			},
 		},
 		{
@@ -68,12 +73,11 @@ func TestToLibdnsRecord(t *testing.T) {
 				TTL:     7200,
 			},
 			zone: "example.com",
-			expected: libdns.Record{
-				ID:    "456",
-				Name:  "alias",
-				Type:  "CNAME",
-				Value: "www.example.com",
-				TTL:   7200 * time.Second,
+			expected: libdns.RR{
+				Name: "alias",
+				Type: "CNAME",
+				Data: "www.example.com",
+				TTL:  7200 * time.Second,
 			},
 		},
 		{
@@ -87,23 +91,25 @@ func TestToLibdnsRecord(t *testing.T) {
 				Priority: 10,
 			},
 			zone: "example.com",
-			expected: libdns.Record{
-				ID:       "789",
-				Name:     "@",
-				Type:     "MX",
-				Value:    "mail.example.com",
-				TTL:      3600 * time.Second,
-				Priority: 10,
+			expected: libdns.RR{
+				Name: "@",
+				Type: "MX",
+				Data: "10 mail.example.com",
+				TTL:  3600 * time.Second,
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := toLibdnsRecord(tt.input, tt.zone)
+			record, err := fromRage4(tt.input, tt.zone)
+			if err != nil {
+				t.Fatalf("fromRage4 failed: %v", err)
+			}
+			result := record.RR()
 
-			if result.ID != tt.expected.ID {
-				t.Errorf("ID mismatch: got %s, want %s", result.ID, tt.expected.ID)
+			if id := recordID(record); id != tt.input.ID {
+				t.Errorf("ID mismatch: got %d, want %d", id, tt.input.ID)
 			}
 			if result.Name != tt.expected.Name {
 				t.Errorf("Name mismatch: got %s, want %s", result.Name, tt.expected.Name)
@@ -111,15 +117,12 @@ func TestToLibdnsRecord(t *testing.T) {
 			if result.Type != tt.expected.Type {
 				t.Errorf("Type mismatch: got %s, want %s", result.Type, tt.expected.Type)
 			}
-			if result.Value != tt.expected.Value {
-				t.Errorf("Value mismatch: got %s, want %s", result.Value, tt.expected.Value)
+			if result.Data != tt.expected.Data {
+				t.Errorf("Data mismatch: got %s, want %s", result.Data, tt.expected.Data)
 			}
 			if result.TTL != tt.expected.TTL {
 				t.Errorf("TTL mismatch: got %v, want %v", result.TTL, tt.expected.TTL)
 			}
-			if result.Priority != tt.expected.Priority {
-				t.Errorf("Priority mismatch: got %d, want %d", result.Priority, tt.expected.Priority)
-			}
 		})
 	}
 }
@@ -141,54 +144,418 @@ func TestProviderStructure(t *testing.T) {
 }
 
 func TestRecordConversion(t *testing.T) {
-	// Test that libdns.Record fields are correctly mapped
-	record := libdns.Record{
-		ID:       "999",
-		Name:     "test",
-		Type:     "A",
-		Value:    "10.0.0.1",
-		TTL:      1800 * time.Second,
-		Priority: 5,
-		Weight:   10,
+	// Test that libdns record fields are correctly mapped to Rage4
+	record := libdns.SRV{
+		Service:      "sip",
+		Transport:    "tcp",
+		Name:         "test",
+		TTL:          1800 * time.Second,
+		Priority:     5,
+		Weight:       10,
+		Port:         5060,
+		Target:       "sip.example.com",
+		ProviderData: Rage4Record{ID: 999},
 	}
 
-	// Verify that the record has all expected fields
-	if record.ID != "999" {
-		t.Errorf("Record ID mismatch: got %s", record.ID)
+	r, err := toRage4(record, "example.com")
+	if err != nil {
+		t.Fatalf("toRage4 failed: %v", err)
 	}
-	if record.Name != "test" {
-		t.Errorf("Record Name mismatch: got %s", record.Name)
+	if r.ID != 999 {
+		t.Errorf("Record ID mismatch: got %d", r.ID)
 	}
-	if record.Type != "A" {
-		t.Errorf("Record Type mismatch: got %s", record.Type)
+	if r.Name != "_sip._tcp.test.example.com" {
+		t.Errorf("Record Name mismatch: got %s", r.Name)
 	}
-	if record.Value != "10.0.0.1" {
-		t.Errorf("Record Value mismatch: got %s", record.Value)
+	if r.Type != "SRV" {
+		t.Errorf("Record Type mismatch: got %s", r.Type)
 	}
-	if record.TTL != 1800*time.Second {
-		t.Errorf("Record TTL mismatch: got %v", record.TTL)
+	if r.TTL != 1800 {
+		t.Errorf("Record TTL mismatch: got %d", r.TTL)
 	}
-	if record.Priority != 5 {
-		t.Errorf("Record Priority mismatch: got %d", record.Priority)
+	if r.Priority != 5 {
+		t.Errorf("Record Priority mismatch: got %d", r.Priority)
 	}
-	if record.Weight != 10 {
-		t.Errorf("Record Weight mismatch: got %d", record.Weight)
+	if r.Content != "10 5060 sip.example.com" {
+		t.Errorf("Record Content mismatch: got %s", r.Content)
 	}
 }
 
 func TestContextHandling(t *testing.T) {
-	// Test that methods accept context
-	p := &Provider{
-		Email:  "test@example.com",
-		APIKey: "test-api-key",
+	// Test that methods accept context and stop once it is canceled,
+	// against the fake API rather than the network
+	_, p := newFakeRage4(t, "example.com.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p.GetRecords(ctx, "example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestProviderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "TXT", Content: `"v=spf1 -all"`, TTL: 3600})
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Name != "@" || records[0].RR().Data != "v=spf1 -all" || records[0].RR().TTL != time.Hour {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	stored := f.domainRecords(1)
+	if len(stored) != 2 || stored[1].Name != "www.example.com" || stored[1].TTL != 3600 {
+		t.Fatalf("unexpected stored records: %+v", stored)
+	}
+
+	// deleting without an ID looks the record up by name, type and value
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "@", Type: "TXT", Data: "v=spf1 -all"}}); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	stored = f.domainRecords(1)
+	if len(stored) != 1 || stored[0].Type != "A" {
+		t.Errorf("unexpected stored records after delete: %+v", stored)
+	}
+	if f.calls("DeleteRecord") != 1 {
+		t.Errorf("expected one DeleteRecord call, got %d", f.calls("DeleteRecord"))
+	}
+}
+
+func TestDeleteRecordsWithoutIDSkipsGetDomain(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: 10})
+
+	// the apex may be given as "" or "@"
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "", Type: "MX", Data: "10 mail.example.com"}}); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the record to be deleted, got %+v", f.domainRecords(1))
+	}
+	if n := f.calls("GetDomain"); n != 0 {
+		t.Errorf("expected no GetDomain requests, got %d", n)
+	}
+}
+
+func TestSetRecordsReadsZoneOnce(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "mail.example.com", Type: "A", Content: "192.0.2.3", TTL: 3600})
+
+	_, err := p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9"}})
+	if err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+
+	// the first www record is updated in place, keeping its ID
+	stored := f.domainRecords(1)
+	if len(stored) != 2 || stored[0].ID != 1001 || stored[0].Content != "192.0.2.9" || stored[1].Name != "mail.example.com" {
+		t.Errorf("unexpected stored records: %+v", stored)
+	}
+	if f.calls("GetDomainByName") != 1 || f.calls("GetRecords") != 1 {
+		t.Errorf("expected one GetDomainByName and one GetRecords request, got %d and %d", f.calls("GetDomainByName"), f.calls("GetRecords"))
+	}
+}
+
+func TestSetRecordsUpdatesInPlace(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+
+	set, err := p.SetRecords(ctx, "example.com.", []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3", TTL: time.Hour},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.4", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+
+	// the matching record is kept, the other one updated, one created
+	if f.calls("UpdateRecord") != 1 || f.calls("CreateRecord") != 1 || f.calls("DeleteRecord") != 0 {
+		t.Errorf("unexpected requests: %v", f.requests)
+	}
+	stored := f.domainRecords(1)
+	if len(stored) != 3 || stored[0].ID != id || stored[0].Content != "192.0.2.3" {
+		t.Errorf("unexpected stored records: %+v", stored)
+	}
+	if len(set) != 3 || recordID(set[1]) != id {
+		t.Errorf("unexpected result: %+v", set)
+	}
+
+	// setting fewer records deletes the surplus
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3"}}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); len(stored) != 1 || stored[0].ID != id {
+		t.Errorf("unexpected stored records: %+v", stored)
+	}
+}
+
+func TestSetRecordsKeepsRage4Settings(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	standby, description, webhook := "192.0.2.99", "web", int64(7)
+	f.addRecord(1, Rage4Record{
+		Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600,
+		GeoRegionID: 1, FailoverEnabled: true, FailoverContent: &standby, UDPLimit: true,
+		Description: &description, WebhookID: &webhook, Weight: 3,
+	})
+
+	// plain records keep every setting
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	r := f.domainRecords(1)[0]
+	if r.Content != "192.0.2.2" || r.GeoRegionID != 1 || !r.FailoverEnabled || r.FailoverContent == nil || *r.FailoverContent != standby ||
+		!r.UDPLimit || r.Description == nil || *r.Description != description || r.WebhookID == nil || *r.WebhookID != webhook || r.Weight != 3 {
+		t.Errorf("expected every setting to be kept, got %+v", r)
+	}
+
+	// records given some settings keep the others
+	geo, err := WithGeo(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.3", TTL: time.Hour}, Geo{RegionID: 2})
+	if err != nil {
+		t.Fatalf("WithGeo failed: %v", err)
+	}
+	if geo, err = WithWeight(geo, 5); err != nil {
+		t.Fatalf("WithWeight failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{geo}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	r = f.domainRecords(1)[0]
+	if r.Content != "192.0.2.3" || r.GeoRegionID != 2 || r.Weight != 5 || !r.FailoverEnabled || !r.UDPLimit ||
+		r.Description == nil || r.WebhookID == nil {
+		t.Errorf("expected geo and weight to change and the other settings to be kept, got %+v", r)
+	}
+
+	updates := f.calls("UpdateRecord")
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{geo}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if f.calls("UpdateRecord") != updates {
+		t.Error("expected a record matching in the settings it sets to be left alone")
+	}
+}
+
+func TestSpecialCharactersRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	value := "a=b&c=d + e%20f?g#h"
+
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "odd", Type: "TXT", Data: value}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
 	}
+	if stored := f.domainRecords(1); len(stored) != 1 || stored[0].Content != value {
+		t.Fatalf("value was corrupted: %+v", stored)
+	}
+
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "odd", Type: "TXT", Data: value}}); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); len(stored) != 0 {
+		t.Errorf("expected the record to be deleted, got %+v", stored)
+	}
+}
+
+// countingTransport counts the requests it forwards
+type countingTransport struct {
+	n int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPClient(t *testing.T) {
+	_, p := newFakeRage4(t, "example.com.")
+	transport := &countingTransport{}
+	p.HTTPClient = &http.Client{Transport: transport}
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if transport.n != 2 {
+		t.Errorf("expected both requests to use the configured client, got %d", transport.n)
+	}
+}
 
+func TestListZones(t *testing.T) {
+	_, p := newFakeRage4(t, "example.com.", "example.net.")
+
+	zones, err := p.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones failed: %v", err)
+	}
+	if len(zones) != 2 || zones[0].Name != "example.com." || zones[1].Name != "example.net." {
+		t.Errorf("unexpected zones: %+v", zones)
+	}
+}
+
+func TestLargeIDs(t *testing.T) {
+	tests := []struct {
+		json string
+		id   int64
+		ok   bool
+	}{
+		{`{"id":9223372036854775807,"domain_id":1}`, math.MaxInt64, true},
+		{`{"id":9007199254740993,"domain_id":1}`, 1<<53 + 1, true},
+		{`{"id":"9007199254740993","domain_id":"1"}`, 1<<53 + 1, true},
+		{`{"id":9223372036854775808}`, 0, false},
+		{`{"id":1.5}`, 0, false},
+	}
+	for _, tt := range tests {
+		var r Rage4Record
+		err := json.Unmarshal([]byte(tt.json), &r)
+		if (err == nil) != tt.ok || tt.ok && r.ID != tt.id {
+			t.Errorf("decoding %s: got ID %d, %v", tt.json, r.ID, err)
+		}
+	}
+
+	var d DomainResponse
+	if err := json.Unmarshal([]byte(`{"id":9007199254740993,"name":"example.com"}`), &d); err != nil || d.ID != 1<<53+1 {
+		t.Errorf("expected the exact domain ID, got %d, %v", d.ID, err)
+	}
+
+	// IDs survive a round trip through the provider and Records
 	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.mu.Lock()
+	f.nextID = 1<<53 + 1
+	f.mu.Unlock()
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
 
-	// These will fail due to invalid credentials, but we're just testing
-	// that the methods accept context and don't panic
-	_, err := p.GetRecords(ctx, "example.com")
-	if err == nil {
-		t.Log("GetRecords succeeded (unexpected with test credentials)")
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil || len(records) != 1 || recordID(records[0]) != id {
+		t.Fatalf("expected record ID %d, got %+v, %v", id, records, err)
+	}
+	encoded, err := json.Marshal(Records(records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Records
+	if err := json.Unmarshal(encoded, &decoded); err != nil || recordID(decoded[0]) != id {
+		t.Fatalf("expected record ID %d after encoding, got %s, %v", id, encoded, err)
+	}
+	if _, err := p.DeleteRecords(ctx, "example.com.", decoded); err != nil || len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the record to be deleted by ID, got %v", err)
+	}
+}
+
+func TestSubzones(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "deep.sub.example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "sub.example.com", Type: "TXT", Content: `"sub"`, TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "www.sub.example.com", Type: "A", Content: "192.0.2.2", TTL: 300})
+	f.addRecord(2, Rage4Record{Name: "www.deep.sub.example.com", Type: "A", Content: "192.0.2.3", TTL: 300})
+
+	for _, noLookupByName := range []bool{false, true} {
+		f.noLookupByName = noLookupByName
+		p.InvalidateDomainCache()
+
+		records, err := p.GetRecords(ctx, "sub.example.com.")
+		if err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+		var names []string
+		for _, r := range records {
+			names = append(names, r.RR().Name)
+		}
+		if strings.Join(names, ",") != "@,www" {
+			t.Errorf("expected the records of the subzone relative to it, got %v", names)
+		}
+
+		// the deepest registered parent wins
+		records, err = p.GetRecords(ctx, "a.deep.sub.example.com.")
+		if err != nil || len(records) != 0 {
+			t.Errorf("expected the empty subzone of deep.sub.example.com, got %+v, %v", records, err)
+		}
+		all, err := p.GetAllRecords(ctx, []string{"sub.example.com.", "deep.sub.example.com."})
+		if err != nil || len(all["sub.example.com."]) != 2 || len(all["deep.sub.example.com."]) != 1 {
+			t.Errorf("unexpected records of all zones %+v, %v", all, err)
+		}
+	}
+
+	if _, err := p.AppendRecords(ctx, "sub.example.com.", []libdns.Record{libdns.RR{Name: "api", Type: "A", Data: "192.0.2.4"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[len(stored)-1].Name != "api.sub.example.com" {
+		t.Errorf("expected the record to be created in the parent domain, got %+v", stored)
+	}
+
+	if _, err := p.OffboardZone(ctx, "sub.example.com.", OffboardOptions{}); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected offboarding a subzone to fail, got %v", err)
+	}
+	if f.calls("DeleteDomain") != 0 {
+		t.Error("expected the parent domain to be kept")
+	}
+	if _, err := p.GetRecords(ctx, "example.org."); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound for a zone without a parent, got %v", err)
+	}
+}
+
+func TestSkipExistingRecords(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "_acme-challenge.example.com", Type: "TXT", Content: `"token1"`, TTL: 300})
+	p.SkipExistingRecords = true
+
+	records, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{
+		libdns.TXT{Name: "_acme-challenge", Text: "token1", TTL: time.Minute},
+		libdns.TXT{Name: "_acme-challenge", Text: "token2", TTL: time.Minute},
+		libdns.RR{Name: "_acme-challenge", Type: "TXT", Data: "token2", TTL: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if len(records) != 3 || recordID(records[0]) != id || recordID(records[1]) == 0 || recordID(records[2]) != recordID(records[1]) {
+		t.Fatalf("expected the existing and the created records, got %+v", records)
+	}
+	if n := f.calls("CreateRecord"); n != 1 {
+		t.Errorf("expected one record to be created, got %d", n)
+	}
+	if stored := f.domainRecords(1); len(stored) != 2 {
+		t.Errorf("expected no duplicates, got %+v", stored)
+	}
+}
+
+func TestAppendRecordsResult(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.AllowedTTLs = []int{60, 3600}
+
+	records, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{
+		libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.TXT{Name: "txt", Text: "hello", TTL: 90 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	stored := f.domainRecords(1)
+	if len(records) != 2 || recordID(records[0]) != stored[0].ID || recordID(records[1]) != stored[1].ID {
+		t.Fatalf("expected the assigned IDs, got %+v", records)
+	}
+	if records[0].RR().TTL != time.Hour || records[1].RR().TTL != time.Minute {
+		t.Errorf("expected the effective TTLs, got %s and %s", records[0].RR().TTL, records[1].RR().TTL)
+	}
+
+	if _, err := p.DeleteRecords(ctx, "example.com.", records); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if f.calls("GetRecords") != 0 || len(f.domainRecords(1)) != 0 {
+		t.Errorf("expected the records to be deleted by ID without a lookup")
 	}
 }
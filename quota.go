@@ -0,0 +1,202 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a change would take a tenant over
+// its Quota. The change is refused before it is sent to Rage4.
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// Quota limits what one tenant, such as an internal team using a DNS
+// self-service platform, may do with the shared Rage4 account, so that
+// no tenant can exhaust the account's limits for the others. Zero
+// values mean no limit.
+type Quota struct {
+	// Zones is the zone group the quota covers; changes to other zones
+	// do not count against it. It defaults to every zone.
+	Zones []string `json:"zones,omitempty"`
+
+	// MaxRecords caps the number of records in each zone of the group
+	MaxRecords int `json:"max_records,omitempty"`
+
+	// MaxMutationsPerHour caps the changes sent to Rage4 for the zone
+	// group in any hour
+	MaxMutationsPerHour int `json:"max_mutations_per_hour,omitempty"`
+}
+
+// covers reports whether zone belongs to the zone group of q
+func (q Quota) covers(zone string) bool {
+	if len(q.Zones) == 0 {
+		return true
+	}
+	zone = strings.TrimSuffix(zone, ".")
+	return slices.ContainsFunc(q.Zones, func(z string) bool {
+		return strings.EqualFold(strings.TrimSuffix(z, "."), zone)
+	})
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose operations are made on behalf of
+// tenant, and count against its quota in Provider.Quotas. For example:
+//
+//	p.AppendRecords(libdnsrage4.WithTenant(ctx, "team-payments"), zone, records)
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// tenantOf returns the tenant set with WithTenant, or ""
+func tenantOf(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// quotaTracker keeps the recent mutations of each tenant. The zero value
+// is ready to use.
+type quotaTracker struct {
+	mu        sync.Mutex
+	mutations map[string][]time.Time // per tenant, oldest first
+}
+
+// reserve counts a mutation of tenant made now, unless it already made
+// max mutations in the last hour
+func (t *quotaTracker) reserve(tenant string, max int, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recent := t.mutations[tenant]
+	cutoff := now.Add(-time.Hour)
+	for len(recent) > 0 && !recent[0].After(cutoff) {
+		recent = recent[1:]
+	}
+	if len(recent) >= max {
+		return fmt.Errorf("%w: %s made %d changes in the last hour, the most its quota allows",
+			ErrQuotaExceeded, tenant, len(recent))
+	}
+
+	if t.mutations == nil {
+		t.mutations = make(map[string][]time.Time)
+	}
+	t.mutations[tenant] = append(recent, now)
+	return nil
+}
+
+// quotaRecords counts the records of the domains an operation creates
+// records in, as read when it first creates one, so that each operation
+// reads each domain at most once
+type quotaRecords struct {
+	mu     sync.Mutex
+	counts map[string]int // by domain ID
+}
+
+// quota returns the quota of the tenant ctx belongs to, if it has one
+// covering the zone of the operation. Account-wide operations, such as
+// ReplaceContent, are covered by every quota; rollbacks by none.
+func (p *Provider) quota(ctx context.Context) (string, Quota, *operation, bool) {
+	tenant := tenantOf(ctx)
+	op := p.operationFrom(ctx)
+	if tenant == "" || op == nil || rollingBack(ctx) {
+		return "", Quota{}, nil, false
+	}
+
+	p.mu.RLock()
+	q, ok := p.Quotas[tenant]
+	p.mu.RUnlock()
+	if !ok || (op.zone != "" && !q.covers(op.zone)) {
+		return "", Quota{}, nil, false
+	}
+	return tenant, q, op, true
+}
+
+// checkQuota refuses a mutation that would take the tenant of ctx over
+// its quota, and otherwise counts it. The returned function must be
+// called with the outcome of the mutation, to keep the record counts
+// of the operation right.
+func (p *Provider) checkQuota(ctx context.Context, method string, params url.Values) (func(error), error) {
+	tenant, q, op, ok := p.quota(ctx)
+	if !ok {
+		return func(error) {}, nil
+	}
+
+	// mutations are counted last, so that creations refused for the
+	// record count do not use them up
+	domainID := params.Get("id")
+	if method == "CreateRecord" && q.MaxRecords > 0 {
+		if err := p.reserveRecord(ctx, op, tenant, q.MaxRecords, domainID); err != nil {
+			return nil, err
+		}
+	}
+	if q.MaxMutationsPerHour > 0 {
+		if err := p.quotas.reserve(tenant, q.MaxMutationsPerHour, time.Now()); err != nil {
+			if method == "CreateRecord" && q.MaxRecords > 0 {
+				op.quotaRecords.release(domainID)
+			}
+			return nil, err
+		}
+	}
+
+	switch {
+	case method == "CreateRecord" && q.MaxRecords > 0:
+		return func(err error) {
+			if err != nil {
+				op.quotaRecords.release(domainID)
+			}
+		}, nil
+	case method == "DeleteRecord" && q.MaxRecords > 0:
+		// the domain of the record is not known, so every count is read
+		// again before the next creation
+		return func(err error) {
+			if err == nil {
+				op.quotaRecords.mu.Lock()
+				op.quotaRecords.counts = nil
+				op.quotaRecords.mu.Unlock()
+			}
+		}, nil
+	}
+	return func(error) {}, nil
+}
+
+// reserveRecord counts a record about to be created in the domain,
+// unless the domain already has max records. Concurrent creations each
+// reserve their record, so that together they cannot exceed max.
+func (p *Provider) reserveRecord(ctx context.Context, op *operation, tenant string, max int, domainID string) error {
+	op.quotaRecords.mu.Lock()
+	defer op.quotaRecords.mu.Unlock()
+
+	count, counted := op.quotaRecords.counts[domainID]
+	if !counted {
+		var records []Rage4Record
+		if err := p.get(ctx, "GetRecords", url.Values{"id": {domainID}}, &records); err != nil {
+			return fmt.Errorf("failed to count records: %w", err)
+		}
+		count = len(records)
+	}
+	if count >= max {
+		return fmt.Errorf("%w: %s has %d records in domain %s, the most its quota allows",
+			ErrQuotaExceeded, tenant, count, domainID)
+	}
+
+	if op.quotaRecords.counts == nil {
+		op.quotaRecords.counts = make(map[string]int)
+	}
+	op.quotaRecords.counts[domainID] = count + 1
+	return nil
+}
+
+// release gives back a record reserved in the domain that was not
+// created
+func (r *quotaRecords) release(domainID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.counts[domainID]; ok {
+		r.counts[domainID]--
+	}
+}
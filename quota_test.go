@@ -0,0 +1,88 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestQuotaMaxRecords(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	p.Quotas = map[string]Quota{"team-a": {Zones: []string{"example.com"}, MaxRecords: 2}}
+	team := WithTenant(ctx, "team-a")
+
+	records := []libdns.Record{
+		libdns.RR{Name: "a", Type: "A", Data: "192.0.2.2", TTL: time.Hour},
+		libdns.RR{Name: "b", Type: "A", Data: "192.0.2.3", TTL: time.Hour},
+	}
+	created, err := p.AppendRecords(team, "example.com.", records)
+	if !errors.Is(err, ErrQuotaExceeded) || len(created) != 1 {
+		t.Fatalf("expected one record to be created before the quota was hit, got %d, %v", len(created), err)
+	}
+	if f.calls("CreateRecord") != 1 {
+		t.Errorf("expected the refused record not to be sent, got %d creations", f.calls("CreateRecord"))
+	}
+
+	// deleting makes room within the same operation
+	cs := &ChangeSet{
+		Zone:   "example.com.",
+		Delete: []libdns.Record{recordWithID(id, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour})},
+		Create: records[1:],
+	}
+	if err := p.Apply(team, cs); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// other zones, other tenants and untagged operations are not limited
+	if _, err := p.AppendRecords(team, "example.org.", records); err != nil {
+		t.Errorf("expected zones outside the group not to be limited, got %v", err)
+	}
+	more := []libdns.Record{libdns.RR{Name: "c", Type: "A", Data: "192.0.2.4", TTL: time.Hour}}
+	if _, err := p.AppendRecords(WithTenant(ctx, "team-b"), "example.com.", more); err != nil {
+		t.Errorf("expected other tenants not to be limited, got %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.com.", more); err != nil {
+		t.Errorf("expected operations without a tenant not to be limited, got %v", err)
+	}
+}
+
+func TestQuotaMaxMutationsPerHour(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.Quotas = map[string]Quota{"team-a": {MaxMutationsPerHour: 2}}
+	team := WithTenant(ctx, "team-a")
+
+	records := []libdns.Record{
+		libdns.RR{Name: "a", Type: "A", Data: "192.0.2.1", TTL: time.Hour},
+		libdns.RR{Name: "b", Type: "A", Data: "192.0.2.2", TTL: time.Hour},
+	}
+	if _, err := p.AppendRecords(team, "example.com.", records); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if _, err := p.DeleteRecords(team, "example.com.", records[:1]); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if f.calls("DeleteRecord") != 0 {
+		t.Error("expected the refused deletion not to be sent")
+	}
+
+	// the window slides
+	var tracker quotaTracker
+	start := time.Now()
+	for i := range 2 {
+		if err := tracker.reserve("team-a", 2, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("reserve failed: %v", err)
+		}
+	}
+	if err := tracker.reserve("team-a", 2, start.Add(59*time.Minute)); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded within the hour, got %v", err)
+	}
+	if err := tracker.reserve("team-a", 2, start.Add(61*time.Minute)); err != nil {
+		t.Errorf("expected mutations older than an hour not to count, got %v", err)
+	}
+}
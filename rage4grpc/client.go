@@ -0,0 +1,64 @@
+package rage4grpc
+
+import (
+	"context"
+
+	"github.com/libdns/libdns"
+	"google.golang.org/grpc"
+)
+
+// Client implements the libdns interfaces by calling a remote DNS
+// service.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewClient returns a client using cc, typically a *grpc.ClientConn.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+// GetRecords lists all the records in the zone.
+func (c *Client) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	resp := new(RecordsResponse)
+	if err := c.invoke(ctx, "GetRecords", &GetRecordsRequest{Zone: zone}, resp); err != nil {
+		return nil, err
+	}
+	return toLibdns(resp.Records)
+}
+
+// AppendRecords adds records to the zone. It returns the records that were added.
+func (c *Client) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return c.records(ctx, "AppendRecords", zone, records)
+}
+
+// SetRecords sets the records in the zone. It returns the updated records.
+func (c *Client) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return c.records(ctx, "SetRecords", zone, records)
+}
+
+// DeleteRecords deletes the specified records from the zone. It returns the records that were deleted.
+func (c *Client) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return c.records(ctx, "DeleteRecords", zone, records)
+}
+
+func (c *Client) records(ctx context.Context, method, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	resp := new(RecordsResponse)
+	req := &RecordsRequest{Zone: zone, Records: fromLibdns(records)}
+	if err := c.invoke(ctx, method, req, resp); err != nil {
+		return nil, err
+	}
+	return toLibdns(resp.Records)
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp message) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, req, resp, grpc.ForceCodec(codec{}))
+}
+
+// Interface guards
+var (
+	_ libdns.RecordGetter   = (*Client)(nil)
+	_ libdns.RecordAppender = (*Client)(nil)
+	_ libdns.RecordSetter   = (*Client)(nil)
+	_ libdns.RecordDeleter  = (*Client)(nil)
+)
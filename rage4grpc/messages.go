@@ -0,0 +1,218 @@
+package rage4grpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/libdns/libdns"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The message types below mirror rage4.proto. They are encoded by hand
+// with protowire rather than generated by protoc, which keeps the build
+// free of a code generation step; the wire format is identical, so
+// clients generated from rage4.proto in any language interoperate. Keep
+// field numbers in sync with the .proto file; TestMessagesMatchProto
+// checks them against it.
+
+// message is implemented by every type exchanged with the service
+type message interface {
+	marshal() []byte
+	unmarshal(b []byte) error
+}
+
+var errMalformed = errors.New("malformed protobuf message")
+
+// Record mirrors rage4.v1.Record.
+type Record struct {
+	Type       string
+	Name       string
+	TTLSeconds int64
+	Data       string
+}
+
+// GetRecordsRequest mirrors rage4.v1.GetRecordsRequest.
+type GetRecordsRequest struct {
+	Zone string
+}
+
+// RecordsRequest mirrors rage4.v1.RecordsRequest.
+type RecordsRequest struct {
+	Zone    string
+	Records []Record
+}
+
+// RecordsResponse mirrors rage4.v1.RecordsResponse.
+type RecordsResponse struct {
+	Records []Record
+}
+
+func (r *Record) marshal() []byte {
+	var b []byte
+	b = appendString(b, 2, r.Type)
+	b = appendString(b, 3, r.Name)
+	b = appendVarint(b, 5, uint64(r.TTLSeconds))
+	b = appendString(b, 8, r.Data)
+	return b
+}
+
+func (r *Record) unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(b, &r.Type)
+		case num == 3 && typ == protowire.BytesType:
+			return consumeString(b, &r.Name)
+		case num == 5 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			r.TTLSeconds = int64(v)
+			return n, protowire.ParseError(n)
+		case num == 8 && typ == protowire.BytesType:
+			return consumeString(b, &r.Data)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+func (m *GetRecordsRequest) marshal() []byte {
+	return appendString(nil, 1, m.Zone)
+}
+
+func (m *GetRecordsRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 && typ == protowire.BytesType {
+			return consumeString(b, &m.Zone)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+func (m *RecordsRequest) marshal() []byte {
+	b := appendString(nil, 1, m.Zone)
+	return appendRecords(b, 2, m.Records)
+}
+
+func (m *RecordsRequest) unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &m.Zone)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeRecord(b, &m.Records)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+func (m *RecordsResponse) marshal() []byte {
+	return appendRecords(nil, 1, m.Records)
+}
+
+func (m *RecordsResponse) unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 && typ == protowire.BytesType {
+			return consumeRecord(b, &m.Records)
+		}
+		return skipField(num, typ, b)
+	})
+}
+
+// appendString appends a string field, omitting the proto3 default
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendVarint appends a varint field, omitting the proto3 default
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendRecords(b []byte, num protowire.Number, records []Record) []byte {
+	for i := range records {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, records[i].marshal())
+	}
+	return b
+}
+
+// consumeFields calls fn for every field in b; fn returns the number of
+// bytes of the field value it consumed
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errMalformed
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil || n < 0 {
+			return errMalformed
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeString(b []byte, v *string) (int, error) {
+	s, n := protowire.ConsumeString(b)
+	*v = s
+	return n, protowire.ParseError(n)
+}
+
+func consumeRecord(b []byte, records *[]Record) (int, error) {
+	raw, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, protowire.ParseError(n)
+	}
+	var r Record
+	if err := r.unmarshal(raw); err != nil {
+		return 0, err
+	}
+	*records = append(*records, r)
+	return n, nil
+}
+
+func skipField(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+	n := protowire.ConsumeFieldValue(num, typ, b)
+	return n, protowire.ParseError(n)
+}
+
+func toLibdns(records []Record) ([]libdns.Record, error) {
+	out := make([]libdns.Record, 0, len(records))
+	for _, r := range records {
+		record, err := libdns.RR{
+			Name: r.Name,
+			TTL:  time.Duration(r.TTLSeconds) * time.Second,
+			Type: r.Type,
+			Data: r.Data,
+		}.Parse()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+func fromLibdns(records []libdns.Record) []Record {
+	out := make([]Record, 0, len(records))
+	for _, record := range records {
+		r := record.RR()
+		out = append(out, Record{
+			Type:       r.Type,
+			Name:       r.Name,
+			TTLSeconds: int64(r.TTL.Seconds()),
+			Data:       r.Data,
+		})
+	}
+	return out
+}
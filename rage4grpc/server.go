@@ -0,0 +1,230 @@
+// Package rage4grpc exposes the libdns operations of a provider as a
+// gRPC service, defined in rage4.proto, along with a client that
+// implements the libdns interfaces on top of it.
+//
+// A typical deployment runs one Server holding the Rage4 credentials and
+// hands out Client connections to internal platforms:
+//
+//	s := grpc.NewServer(rage4grpc.ServerCodec())
+//	rage4grpc.Register(s, &rage4grpc.Server{Provider: provider, Authorize: authz})
+package rage4grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/libdns/libdns"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	libdnsrage4 "github.com/r6c/rage4"
+)
+
+// ServiceName is the fully-qualified name of the DNS service.
+const ServiceName = "rage4.v1.DNS"
+
+// Provider is the set of libdns operations exposed by the service.
+// *libdnsrage4.Provider satisfies this interface.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+	libdns.RecordDeleter
+}
+
+// Server implements the DNS service on top of a libdns provider.
+// Provider errors are returned with the code matching their cause, such
+// as NotFound for unknown zones and ResourceExhausted for rate limits.
+type Server struct {
+	// Provider performs the record operations
+	Provider Provider
+
+	// Authorize, if set, is called before every operation with the full
+	// method name and the zone. The context carries the caller's
+	// metadata and peer information. Returning an error rejects the
+	// call with PermissionDenied.
+	Authorize func(ctx context.Context, method, zone string) error
+}
+
+// Register registers srv with s. The gRPC server must be created with
+// the ServerCodec option.
+func Register(s grpc.ServiceRegistrar, srv *Server) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// ServerCodec returns the server option required to serve the DNS
+// service. Other services on the same server keep using the standard
+// protobuf codec.
+func ServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(codec{})
+}
+
+// codec encodes the hand-written messages of this package and falls
+// back to the standard protobuf codec for generated messages
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case message:
+		return m.marshal(), nil
+	case proto.Message:
+		return proto.Marshal(m)
+	}
+	return nil, fmt.Errorf("rage4grpc: cannot marshal %T", v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case message:
+		return m.unmarshal(data)
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	}
+	return fmt.Errorf("rage4grpc: cannot unmarshal into %T", v)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+var _ encoding.Codec = codec{}
+
+// recordsFunc is one of the libdns operations taking records
+type recordsFunc func(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)
+
+func (s *Server) authorize(ctx context.Context, method, zone string) error {
+	if zone == "" {
+		return status.Error(codes.InvalidArgument, "zone is required")
+	}
+	if s.Authorize != nil {
+		if err := s.Authorize(ctx, method, zone); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return nil
+}
+
+func (s *Server) getRecords(ctx context.Context, req *GetRecordsRequest) (*RecordsResponse, error) {
+	if err := s.authorize(ctx, "/"+ServiceName+"/GetRecords", req.Zone); err != nil {
+		return nil, err
+	}
+
+	records, err := s.Provider.GetRecords(ctx, req.Zone)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &RecordsResponse{Records: fromLibdns(records)}, nil
+}
+
+func (s *Server) records(ctx context.Context, method string, fn recordsFunc, req *RecordsRequest) (*RecordsResponse, error) {
+	if err := s.authorize(ctx, method, req.Zone); err != nil {
+		return nil, err
+	}
+	if len(req.Records) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "records are required")
+	}
+
+	in, err := toLibdns(req.Records)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	records, err := fn(ctx, req.Zone, in)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &RecordsResponse{Records: fromLibdns(records)}, nil
+}
+
+// statusError returns the gRPC status for a provider error. Errors the
+// caller can act on get the code that tells them how; only transport
+// failures and server errors from Rage4, which may pass on a retry, are
+// Unavailable.
+func statusError(err error) error {
+	var (
+		apiErr   *libdnsrage4.APIError
+		panicErr *libdnsrage4.PanicError
+		convErr  *libdnsrage4.ConversionError
+		validErr *libdnsrage4.ValidationError
+	)
+	code := codes.Unavailable
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, libdnsrage4.ErrZoneNotFound), errors.Is(err, libdnsrage4.ErrRecordNotFound):
+		code = codes.NotFound
+	case errors.Is(err, libdnsrage4.ErrAuthenticationFailed):
+		code = codes.Unauthenticated
+	case errors.Is(err, libdnsrage4.ErrRateLimited), errors.Is(err, libdnsrage4.ErrBudgetExceeded),
+		errors.Is(err, libdnsrage4.ErrQuotaExceeded):
+		code = codes.ResourceExhausted
+	case errors.Is(err, libdnsrage4.ErrUnsupportedRecordType), errors.Is(err, libdnsrage4.ErrTTLNotAllowed),
+		errors.As(err, &convErr), errors.As(err, &validErr):
+		code = codes.InvalidArgument
+	case errors.As(err, &panicErr):
+		code = codes.Internal
+	case errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError:
+		// rejected by Rage4 rather than failed on its side
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+// recordsHandler adapts one of the record-taking methods to a gRPC
+// method handler
+func recordsHandler(name string, fn func(s *Server) recordsFunc) grpc.MethodDesc {
+	method := "/" + ServiceName + "/" + name
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			s := srv.(*Server)
+			req := new(RecordsRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return s.records(ctx, method, fn(s), req.(*RecordsRequest))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: s, FullMethod: method}, handler)
+		},
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRecords",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				s := srv.(*Server)
+				req := new(GetRecordsRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req any) (any, error) {
+					return s.getRecords(ctx, req.(*GetRecordsRequest))
+				}
+				if interceptor == nil {
+					return handler(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{Server: s, FullMethod: "/" + ServiceName + "/GetRecords"}
+				return interceptor(ctx, req, info, handler)
+			},
+		},
+		recordsHandler("AppendRecords", func(s *Server) recordsFunc { return s.Provider.AppendRecords }),
+		recordsHandler("SetRecords", func(s *Server) recordsFunc { return s.Provider.SetRecords }),
+		recordsHandler("DeleteRecords", func(s *Server) recordsFunc { return s.Provider.DeleteRecords }),
+	},
+	Metadata: "rage4.proto",
+}
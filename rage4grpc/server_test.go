@@ -0,0 +1,265 @@
+package rage4grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	libdnsrage4 "github.com/r6c/rage4"
+	"github.com/r6c/rage4/internal/memprovider"
+)
+
+func startServer(t *testing.T, srv *Server) *Client {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(ServerCodec())
+	Register(s, srv)
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return NewClient(cc)
+}
+
+func TestClientServerRoundTrip(t *testing.T) {
+	p := &memprovider.Provider{}
+	client := startServer(t, &Server{Provider: p})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	added, err := client.AppendRecords(ctx, "example.com.", []libdns.Record{
+		libdns.MX{Name: "@", TTL: 5 * time.Minute, Preference: 10, Target: "mail.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if len(added) != 1 || added[0].RR().Data != "10 mail.example.com" {
+		t.Errorf("unexpected appended records: %+v", added)
+	}
+
+	records, err := client.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if mx, ok := records[0].(libdns.MX); len(records) != 1 || !ok || mx.Preference != 10 || mx.TTL != 5*time.Minute || mx.Target != "mail.example.com" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	broken := startServer(t, &Server{Provider: &errorProvider{err: errors.New("upstream unavailable")}})
+	if _, err := broken.GetRecords(ctx, "example.com."); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable for provider failure, got %v", err)
+	}
+	if _, err := client.DeleteRecords(ctx, "example.com.", nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for empty records, got %v", err)
+	}
+}
+
+func TestServerAuthorize(t *testing.T) {
+	p := &memprovider.Provider{}
+	client := startServer(t, &Server{
+		Provider: p,
+		Authorize: func(ctx context.Context, method, zone string) error {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if team := md.Get("team"); len(team) == 1 && strings.HasSuffix(zone, team[0]+".example.com.") {
+				return nil
+			}
+			return errors.New("zone not owned by caller")
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "team", "payments")
+
+	if _, err := client.GetRecords(ctx, "payments.example.com."); err != nil {
+		t.Errorf("expected authorized call to succeed, got %v", err)
+	}
+	if _, err := client.GetRecords(ctx, "search.example.com."); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+}
+
+// errorProvider fails every lookup with err
+type errorProvider struct {
+	memprovider.Provider
+	err error
+}
+
+func (p *errorProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return nil, p.err
+}
+
+func TestServerErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("failed to get domain ID: %w", libdnsrage4.ErrZoneNotFound), codes.NotFound},
+		{&libdnsrage4.APIError{Method: "GetDomainByName", StatusCode: http.StatusUnauthorized}, codes.Unauthenticated},
+		{fmt.Errorf("%w: 100 requests", libdnsrage4.ErrBudgetExceeded), codes.ResourceExhausted},
+		{&libdnsrage4.RateLimitError{APIError: &libdnsrage4.APIError{Method: "GetRecords", StatusCode: http.StatusTooManyRequests}}, codes.ResourceExhausted},
+		{fmt.Errorf("%w: Rage4 does not support HINFO records on this plan", libdnsrage4.ErrUnsupportedRecordType), codes.InvalidArgument},
+		{&libdnsrage4.APIError{Method: "GetRecords", StatusCode: http.StatusOK, Message: "invalid content"}, codes.InvalidArgument},
+		{&libdnsrage4.APIError{Method: "GetRecords", StatusCode: http.StatusBadGateway}, codes.Unavailable},
+		{errors.New("connection refused"), codes.Unavailable},
+	}
+	for _, tt := range tests {
+		client := startServer(t, &Server{Provider: &errorProvider{err: tt.err}})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := client.GetRecords(ctx, "example.com.")
+		cancel()
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%v: expected %s, got %s", tt.err, tt.want, got)
+		}
+	}
+}
+
+// protoFile returns the descriptor of rage4.proto, read with a parser
+// just good enough for its messages, so that the hand-written messages
+// are checked against the .proto file itself
+func protoFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	src, err := os.ReadFile("rage4.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("rage4.proto"),
+		Syntax: proto.String("proto3"),
+	}
+	if m := regexp.MustCompile(`(?m)^package ([\w.]+);`).FindSubmatch(src); m != nil {
+		fdp.Package = proto.String(string(m[1]))
+	}
+
+	scalars := map[string]descriptorpb.FieldDescriptorProto_Type{
+		"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	}
+	fieldRE := regexp.MustCompile(`(?m)^\s*(repeated\s+)?(\w+)\s+(\w+)\s*=\s*(\d+);`)
+	for _, m := range regexp.MustCompile(`(?s)message (\w+) \{(.*?)\n\}`).FindAllSubmatch(src, -1) {
+		msg := &descriptorpb.DescriptorProto{Name: proto.String(string(m[1]))}
+		for _, f := range fieldRE.FindAllSubmatch(m[2], -1) {
+			num, _ := strconv.Atoi(string(f[4]))
+			fd := field(string(f[3]), int32(num), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+			if typ, ok := scalars[string(f[2])]; ok {
+				fd.Type = typ.Enum()
+			} else {
+				fd.TypeName = proto.String("." + fdp.GetPackage() + "." + string(f[2]))
+			}
+			if len(f[1]) > 0 {
+				fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			}
+			msg.Field = append(msg.Field, fd)
+		}
+		fdp.MessageType = append(fdp.MessageType, msg)
+	}
+
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	return fd
+}
+
+func TestMessagesMatchProto(t *testing.T) {
+	fd := protoFile(t)
+	record := Record{Type: "SRV", Name: "_sip._tcp", Data: "10 20 5060 sip.example.com", TTLSeconds: 300}
+
+	tests := []struct {
+		name string
+		in   message
+		out  message
+	}{
+		{"Record", &record, new(Record)},
+		{"GetRecordsRequest", &GetRecordsRequest{Zone: "example.com."}, new(GetRecordsRequest)},
+		{"RecordsRequest", &RecordsRequest{Zone: "example.com.", Records: []Record{record, record}}, new(RecordsRequest)},
+		{"RecordsResponse", &RecordsResponse{Records: []Record{record}}, new(RecordsResponse)},
+	}
+	if n := fd.Messages().Len(); n != len(tests) {
+		t.Errorf("rage4.proto defines %d messages, %d are checked", n, len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := fd.Messages().ByName(protoreflect.Name(tt.name))
+			if md == nil {
+				t.Fatalf("%s is not defined in rage4.proto", tt.name)
+			}
+
+			// decode with the reference protobuf implementation: every
+			// field must be known to the .proto, and every field of the
+			// .proto must be written
+			dyn := dynamicpb.NewMessage(md)
+			if err := proto.Unmarshal(tt.in.marshal(), dyn); err != nil {
+				t.Fatalf("reference implementation rejected message: %v", err)
+			}
+			if unknown := dyn.GetUnknown(); len(unknown) > 0 {
+				t.Errorf("fields not in rage4.proto: %x", unknown)
+			}
+			for i := range md.Fields().Len() {
+				if f := md.Fields().Get(i); !dyn.Has(f) {
+					t.Errorf("field %s of rage4.proto is not written", f.Name())
+				}
+			}
+			if md.Name() == "Record" {
+				if got := dyn.Get(md.Fields().ByName("data")).String(); got != record.Data {
+					t.Errorf("data mismatch: got %q", got)
+				}
+				if got := dyn.Get(md.Fields().ByName("ttl_seconds")).Int(); got != 300 {
+					t.Errorf("ttl mismatch: got %d", got)
+				}
+			}
+
+			// and back again
+			b, err := proto.Marshal(dyn)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			if err := tt.out.unmarshal(b); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(tt.out, tt.in) {
+				t.Errorf("round trip mismatch: got %+v, want %+v", tt.out, tt.in)
+			}
+		})
+	}
+}
+
+func field(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(num),
+		Type:     typ.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
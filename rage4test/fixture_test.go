@@ -0,0 +1,89 @@
+package rage4test_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+
+	libdnsrage4 "github.com/r6c/rage4"
+	"github.com/r6c/rage4/rage4test"
+)
+
+func TestDefaultBundleEndToEnd(t *testing.T) {
+	ctx := context.Background()
+	s, err := rage4test.NewServer(rage4test.Default(), rage4test.PublicKey)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer s.Close()
+
+	p := &libdnsrage4.Provider{Email: "ci@example.com", APIKey: "unused", Endpoint: s.Endpoint}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	want := map[string]libdns.RR{
+		"MX":  {Name: "@", Type: "MX", Data: "10 mail.example.com", TTL: time.Hour},
+		"SRV": {Name: "_sip._tcp", Type: "SRV", Data: "10 5 5060 sip.example.com", TTL: time.Hour},
+	}
+	for _, r := range records {
+		rr := r.RR()
+		if w, ok := want[rr.Type]; ok {
+			if rr != w {
+				t.Errorf("unexpected %s record: %+v", rr.Type, rr)
+			}
+			delete(want, rr.Type)
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("missing records: %v", want)
+	}
+
+	created, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "new", Type: "A", Data: "192.0.2.9"}})
+	if err != nil || len(created) != 1 {
+		t.Errorf("unexpected AppendRecords result: %+v, %v", created, err)
+	}
+
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.Address{Name: "gone", ProviderData: libdnsrage4.Rage4Record{ID: 999}}}); err == nil {
+		t.Error("expected the recorded API error to be reported")
+	}
+
+	if !slices.Contains(s.Requests(), "CreateRecord") {
+		t.Errorf("unexpected requests: %v", s.Requests())
+	}
+}
+
+func TestBundleSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	b, err := rage4test.Sign([]rage4test.Fixture{
+		{Method: "GetDomains", Body: json.RawMessage(`[]`)},
+	}, priv)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// reformatting keeps the signature valid
+	var indented bytes.Buffer
+	json.Indent(&indented, b.Fixtures, "", "  ")
+	b.Fixtures = indented.Bytes()
+	if _, err := b.Verify(pub); err != nil {
+		t.Errorf("expected reformatted bundle to verify, got %v", err)
+	}
+
+	b.Fixtures = json.RawMessage(`[{"method":"GetDomains","body":[{"id":1,"name":"evil.com"}]}]`)
+	if _, err := rage4test.NewServer(b, pub); !errors.Is(err, rage4test.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for edited fixtures, got %v", err)
+	}
+	if _, err := rage4test.NewServer(rage4test.Default(), pub); !errors.Is(err, rage4test.ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for the wrong key, got %v", err)
+	}
+}
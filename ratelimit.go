@@ -0,0 +1,54 @@
+package libdnsrage4
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by every request of a provider.
+// The zero value is an empty bucket, filled on first use.
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// wait blocks until a request may be sent under a limit of rate requests
+// per second with bursts of up to burst requests, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context, rate float64, burst int) error {
+	if burst < 1 {
+		burst = max(1, int(math.Ceil(rate)))
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = float64(burst)
+	} else {
+		l.tokens = min(float64(burst), l.tokens+now.Sub(l.last).Seconds()*rate)
+	}
+	l.last = now
+
+	// the token is taken now, leaving the bucket in debt if it is
+	// empty, so that waiting requests are served in order
+	l.tokens--
+	delay := time.Duration(-l.tokens / rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRequestsPerSecond(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+	p.RequestsPerSecond = 100
+	p.RequestBurst = 5
+	p.DomainCacheTTL = -1
+
+	// 20 requests from several goroutines share the bucket: 5 go at
+	// once, the other 15 at 10ms intervals
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 5 {
+				if _, err := p.ListZones(ctx); err != nil {
+					t.Errorf("ListZones failed: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("expected the requests to be spread out, took %s", elapsed)
+	}
+}
+
+// loadRecords returns n distinct records to create
+func loadRecords(n int) []libdns.Record {
+	records := make([]libdns.Record, n)
+	for i := range records {
+		records[i] = libdns.RR{Name: fmt.Sprintf("host%d", i), Type: "A", Data: "192.0.2.1", TTL: time.Hour}
+	}
+	return records
+}
+
+func TestLoadStaysUnderRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	// Rage4 allows a little more than the provider sends, as network
+	// jitter can bunch requests up on arrival
+	f.throttle = &fakeThrottle{rate: 220, burst: 20}
+	p.RequestsPerSecond = 200
+	p.RequestBurst = 10
+	p.BatchConcurrency = 16
+
+	// several goroutines run large parallel batches at once
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			if _, err := p.AppendRecords(ctx, "example.com.", loadRecords(50)); err != nil {
+				t.Errorf("AppendRecords failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	rejected, peak := f.throttle.rejected, f.throttle.peak(250*time.Millisecond)
+	f.mu.Unlock()
+	if rejected != 0 {
+		t.Errorf("expected the limiter to keep every request under the ceiling, %d were throttled", rejected)
+	}
+	// 200 per second, plus the burst
+	if peak > 50+10 {
+		t.Errorf("expected at most 60 requests in any 250ms, got %d", peak)
+	}
+	if n := len(f.domainRecords(1)); n != 150 {
+		t.Errorf("expected 150 records, got %d", n)
+	}
+}
+
+func TestLoadBacksOffWhenThrottled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.throttle = &fakeThrottle{rate: 200, burst: 10}
+	p.BatchConcurrency = 16
+	p.MaxAttempts = 20
+	p.RetryBackoff = 10 * time.Millisecond
+
+	// without a limiter of its own, the provider relies on backing off
+	// from Rage4's 429 answers
+	if _, err := p.AppendRecords(ctx, "example.com.", loadRecords(150)); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	if n := len(f.domainRecords(1)); n != 150 {
+		t.Errorf("expected every record to be created, got %d", n)
+	}
+	f.mu.Lock()
+	rejected := f.throttle.rejected
+	f.mu.Unlock()
+	if rejected == 0 {
+		t.Errorf("expected the batch to be throttled")
+	}
+	// backing off keeps the retries from hammering the API: well under
+	// one rejection per attempt allowed
+	if rejected > 150*5 {
+		t.Errorf("expected backoff to limit the retries, %d requests were throttled", rejected)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	var l rateLimiter
+	if err := l.wait(context.Background(), 0.1, 1); err != nil {
+		t.Fatalf("expected the first request to pass, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, 0.1, 1); err != context.DeadlineExceeded {
+		t.Errorf("expected waiting to end with the context, got %v", err)
+	}
+	if l.tokens < -0.01 {
+		t.Errorf("expected the cancelled request to return its token, have %f", l.tokens)
+	}
+}
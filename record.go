@@ -0,0 +1,373 @@
+package libdnsrage4
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Records returned by the provider are the typed structs of libdns
+// (libdns.Address, libdns.MX, libdns.SRV, ...) for the types libdns
+// knows, and libdns.RR for the others. The typed structs carry the
+// Rage4Record they were read from in ProviderData, so that they can be
+// updated and deleted by ID and keep their Rage4-specific settings, such
+// as geo and failover, when written back. Callers can set those settings
+// on new records the same way.
+
+// parseRecord returns the typed form of rr, or rr itself if its data
+// does not parse, so that one odd record never hides the rest of a zone
+func parseRecord(rr libdns.RR) libdns.Record {
+	record, err := rr.Parse()
+	if err != nil {
+		return rr
+	}
+	return record
+}
+
+// rage4Data returns the Rage4 record a record was read from, if any
+func rage4Data(record libdns.Record) (Rage4Record, bool) {
+	var data any
+	switch r := record.(type) {
+	case libdns.Address:
+		data = r.ProviderData
+	case libdns.CAA:
+		data = r.ProviderData
+	case libdns.CNAME:
+		data = r.ProviderData
+	case libdns.MX:
+		data = r.ProviderData
+	case libdns.NS:
+		data = r.ProviderData
+	case libdns.SRV:
+		data = r.ProviderData
+	case libdns.ServiceBinding:
+		data = r.ProviderData
+	case libdns.TXT:
+		data = r.ProviderData
+	}
+	r, ok := data.(Rage4Record)
+	return r, ok
+}
+
+// recordID returns the Rage4 ID of a record, or zero if it was not read
+// from Rage4
+func recordID(record libdns.Record) int64 {
+	r, _ := rage4Data(record)
+	return r.ID
+}
+
+// withRage4Data returns record with data attached as its provider data.
+// Records of types libdns has no struct for are returned unchanged.
+func withRage4Data(record libdns.Record, data Rage4Record) libdns.Record {
+	switch r := record.(type) {
+	case libdns.Address:
+		r.ProviderData = data
+		return r
+	case libdns.CAA:
+		r.ProviderData = data
+		return r
+	case libdns.CNAME:
+		r.ProviderData = data
+		return r
+	case libdns.MX:
+		r.ProviderData = data
+		return r
+	case libdns.NS:
+		r.ProviderData = data
+		return r
+	case libdns.SRV:
+		r.ProviderData = data
+		return r
+	case libdns.ServiceBinding:
+		r.ProviderData = data
+		return r
+	case libdns.TXT:
+		r.ProviderData = data
+		return r
+	}
+	return record
+}
+
+// withoutID returns record detached from the Rage4 record it was read
+// from, so that it is created anew rather than matched by ID. Its
+// Rage4-specific settings are kept.
+func withoutID(record libdns.Record) libdns.Record {
+	data, ok := rage4Data(record)
+	if !ok {
+		return record
+	}
+	data.ID, data.DomainID = 0, 0
+	return withRage4Data(parseRecord(record.RR()), data)
+}
+
+// withTTL returns record with its TTL changed, keeping its provider data
+func withTTL(record libdns.Record, ttl time.Duration) libdns.Record {
+	rr := record.RR()
+	rr.TTL = ttl
+	changed := parseRecord(rr)
+	if data, ok := rage4Data(record); ok {
+		changed = withRage4Data(changed, data)
+	}
+	return changed
+}
+
+// recordSettings are the Rage4-specific settings of a record, which
+// libdns has no fields for. They travel in the Rage4Record provider
+// data: settings read from Rage4 are kept when the record is written
+// back, and callers can set them on new records.
+type recordSettings struct {
+	GeoRegionID      int      `json:"geo_region_id,omitempty"`
+	GeoLat           *float64 `json:"geo_lat,omitempty"`
+	GeoLong          *float64 `json:"geo_long,omitempty"`
+	GeoAsNum         *int64   `json:"geo_asnum,omitempty"`
+	FailoverEnabled  bool     `json:"failover_enabled,omitempty"`
+	FailoverContent  *string  `json:"failover_content,omitempty"`
+	FailoverWithdraw bool     `json:"failover_withdraw,omitempty"`
+	Description      *string  `json:"description,omitempty"`
+	WebhookID        *int64   `json:"webhook_id,omitempty"`
+	Weight           int      `json:"weight,omitempty"`
+	UDPLimit         bool     `json:"udp_limit,omitempty"`
+}
+
+// settingsOf returns the settings of r. The weight of SRV records is
+// part of their data rather than a setting.
+func settingsOf(r Rage4Record) recordSettings {
+	s := recordSettings{
+		GeoRegionID:      r.GeoRegionID,
+		GeoLat:           r.GeoLat,
+		GeoLong:          r.GeoLong,
+		GeoAsNum:         r.GeoAsNum,
+		FailoverEnabled:  r.FailoverEnabled,
+		FailoverContent:  r.FailoverContent,
+		FailoverWithdraw: r.FailoverWithdraw,
+		Description:      r.Description,
+		WebhookID:        r.WebhookID,
+		UDPLimit:         r.UDPLimit,
+	}
+	if r.Type != "SRV" {
+		s.Weight = r.Weight
+	}
+	return s
+}
+
+// applyTo sets the settings on r
+func (s recordSettings) applyTo(r *Rage4Record) {
+	r.GeoRegionID = s.GeoRegionID
+	r.GeoLat, r.GeoLong, r.GeoAsNum = s.GeoLat, s.GeoLong, s.GeoAsNum
+	r.FailoverEnabled, r.FailoverContent, r.FailoverWithdraw = s.FailoverEnabled, s.FailoverContent, s.FailoverWithdraw
+	r.Description, r.WebhookID, r.UDPLimit = s.Description, s.WebhookID, s.UDPLimit
+	if r.Type != "SRV" {
+		r.Weight = s.Weight
+	}
+}
+
+// isZero reports whether no setting is set
+func (s recordSettings) isZero() bool {
+	return s.equal(recordSettings{})
+}
+
+// equal reports whether s and o hold the same settings
+func (s recordSettings) equal(o recordSettings) bool {
+	return s.GeoRegionID == o.GeoRegionID && s.Weight == o.Weight && s.UDPLimit == o.UDPLimit && s.FailoverEnabled == o.FailoverEnabled && s.FailoverWithdraw == o.FailoverWithdraw &&
+		equalPtr(s.GeoLat, o.GeoLat) && equalPtr(s.GeoLong, o.GeoLong) && equalPtr(s.GeoAsNum, o.GeoAsNum) &&
+		equalPtr(s.FailoverContent, o.FailoverContent) && equalPtr(s.Description, o.Description) &&
+		equalPtr(s.WebhookID, o.WebhookID)
+}
+
+// settingMask is a set of record settings, by the helper that sets them
+type settingMask uint8
+
+const (
+	settingGeo settingMask = 1 << iota
+	settingFailover
+	settingDescription
+	settingWebhook
+	settingWeight
+	settingUDPLimit
+)
+
+// overriding returns data, of a record that had provider data if
+// hadData, marked as setting s. Provider data read from Rage4 or passed
+// in full sets every setting already.
+func (r Rage4Record) overriding(hadData bool, s settingMask) Rage4Record {
+	if !hadData || r.overrides != 0 {
+		r.overrides |= s
+	}
+	return r
+}
+
+// errNoProviderData is returned by withSetting for records of types
+// libdns has no struct for, which cannot carry settings
+var errNoProviderData = errors.New("no provider data")
+
+// withSetting returns record with the settings in mask changed by set,
+// keeping its other settings
+func withSetting(record libdns.Record, mask settingMask, set func(*Rage4Record)) (libdns.Record, error) {
+	data, ok := rage4Data(record)
+	if !ok {
+		// an RR of a known type has a struct that can carry settings
+		record = parseRecord(record.RR())
+	}
+	data = data.overriding(ok, mask)
+	set(&data)
+
+	updated := withRage4Data(record, data)
+	if _, ok := rage4Data(updated); !ok {
+		return nil, errNoProviderData
+	}
+	return updated, nil
+}
+
+// desiredSettings returns the settings record asks for when it replaces
+// existing. Records without provider data keep the settings of existing,
+// and records given settings with WithGeo and the like keep the others;
+// provider data passed in full replaces them, except for the
+// description, which is kept unless set.
+func desiredSettings(existing, record libdns.Record) recordSettings {
+	current, _ := rage4Data(existing)
+	have := settingsOf(current)
+	data, ok := rage4Data(record)
+	if !ok {
+		return have
+	}
+	want := settingsOf(data)
+	if data.overrides == 0 {
+		return want.keepDescription(have)
+	}
+
+	if data.overrides&settingGeo != 0 {
+		have.GeoRegionID, have.GeoLat, have.GeoLong, have.GeoAsNum = want.GeoRegionID, want.GeoLat, want.GeoLong, want.GeoAsNum
+	}
+	if data.overrides&settingFailover != 0 {
+		have.FailoverEnabled, have.FailoverContent, have.FailoverWithdraw = want.FailoverEnabled, want.FailoverContent, want.FailoverWithdraw
+	}
+	if data.overrides&settingDescription != 0 {
+		have.Description = want.Description
+	}
+	if data.overrides&settingWebhook != 0 {
+		have.WebhookID = want.WebhookID
+	}
+	if data.overrides&settingWeight != 0 {
+		have.Weight = want.Weight
+	}
+	if data.overrides&settingUDPLimit != 0 {
+		have.UDPLimit = want.UDPLimit
+	}
+	return have
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Records is a list of records that can be encoded to and decoded from
+// JSON, which the libdns.Record interface alone cannot. Each record is
+// encoded as its name, TTL, type and data, with the Rage4 ID and
+// settings, such as geo and failover, of records that carry them.
+type Records []libdns.Record
+
+// MarshalJSON implements json.Marshaler.
+func (rs Records) MarshalJSON() ([]byte, error) {
+	encoded := make([]jsonRecord, len(rs))
+	for i, r := range rs {
+		encoded[i] = newJSONRecord(r)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It also accepts records
+// encoded before the move to libdns v1, with a separate value, priority
+// and weight.
+func (rs *Records) UnmarshalJSON(data []byte) error {
+	var encoded []jsonRecord
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	records := make(Records, len(encoded))
+	for i, e := range encoded {
+		r, err := e.record()
+		if err != nil {
+			return err
+		}
+		records[i] = r
+	}
+	*rs = records
+	return nil
+}
+
+// jsonRecord is the JSON form of a record
+type jsonRecord struct {
+	ID string `json:"id,omitempty"`
+	libdns.RR
+	Rage4 *recordSettings `json:"rage4,omitempty"`
+
+	// Value, Priority and Weight are the fields of records encoded with
+	// libdns v0, read for compatibility only
+	Value    string `json:"value,omitempty"`
+	Priority uint   `json:"priority,omitempty"`
+	Weight   uint   `json:"weight,omitempty"`
+}
+
+func newJSONRecord(record libdns.Record) jsonRecord {
+	e := jsonRecord{RR: record.RR()}
+	data, _ := rage4Data(record)
+	if data.ID != 0 {
+		e.ID = strconv.FormatInt(data.ID, 10)
+	}
+	if settings := settingsOf(data); !settings.isZero() {
+		e.Rage4 = &settings
+	}
+	return e
+}
+
+// record decodes e, attaching its Rage4 ID and settings if it has them
+func (e jsonRecord) record() (libdns.Record, error) {
+	rr := e.RR
+	if rr.Data == "" && e.Value != "" {
+		rr.Data = legacyData(rr.Type, e.Value, e.Priority, e.Weight)
+	}
+
+	record := parseRecord(rr)
+	if e.ID == "" && e.Rage4 == nil {
+		return record, nil
+	}
+
+	var data Rage4Record
+	if e.ID != "" {
+		id, err := strconv.ParseInt(e.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record ID %q: %w", e.ID, err)
+		}
+		data.ID = id
+	}
+	if e.Rage4 != nil {
+		e.Rage4.applyTo(&data)
+	}
+	return withRage4Data(record, data), nil
+}
+
+// legacyData returns the RDATA of a libdns v0 record, which kept the MX
+// and SRV priority and weight out of its value
+func legacyData(recordType, value string, priority, weight uint) string {
+	switch recordType {
+	case "MX":
+		if len(strings.Fields(value)) == 1 {
+			return fmt.Sprintf("%d %s", priority, value)
+		}
+	case "SRV":
+		if len(strings.Fields(value)) == 2 {
+			return fmt.Sprintf("%d %d %s", priority, weight, value)
+		}
+	}
+	return value
+}
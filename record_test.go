@@ -0,0 +1,69 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRage4SettingsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	backup, owner := "192.0.2.99", "team-web"
+
+	_, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.Address{
+		Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.1"),
+		ProviderData: Rage4Record{GeoRegionID: 4, FailoverEnabled: true, FailoverContent: &backup, Description: &owner},
+	}})
+	if err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	data, ok := rage4Data(records[0])
+	if !ok || data.GeoRegionID != 4 || !data.FailoverEnabled || *data.FailoverContent != backup || *data.Description != owner {
+		t.Fatalf("expected the settings to be read back, got %+v", records[0])
+	}
+
+	// the settings survive generic tooling that stores records as JSON
+	encoded, err := json.Marshal(Records(records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Records
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to decode %s: %v", encoded, err)
+	}
+	if back, _ := rage4Data(decoded[0]); back.ID != data.ID || !settingsOf(back).equal(settingsOf(data)) {
+		t.Errorf("expected the settings to survive JSON, got %s", encoded)
+	}
+
+	// changing the address without provider data keeps the settings
+	_, err = p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.2", TTL: time.Hour}})
+	if err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	stored := f.domainRecords(1)
+	if len(stored) != 1 || stored[0].Content != "192.0.2.2" || stored[0].GeoRegionID != 4 || !stored[0].FailoverEnabled || stored[0].Description == nil {
+		t.Errorf("expected the settings to be kept, got %+v", stored)
+	}
+
+	// a record that asks for other settings is updated to them
+	_, err = p.SetRecords(ctx, "example.com.", []libdns.Record{libdns.Address{
+		Name: "www", TTL: time.Hour, IP: netip.MustParseAddr("192.0.2.2"),
+		ProviderData: Rage4Record{GeoRegionID: 5},
+	}})
+	if err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].GeoRegionID != 5 || stored[0].FailoverEnabled || f.calls("UpdateRecord") != 2 {
+		t.Errorf("expected the new settings to be applied, got %+v", stored)
+	}
+}
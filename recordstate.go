@@ -0,0 +1,71 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// IsActive reports whether Rage4 answers with a record read from it.
+// Records disabled with SetRecordActive are still returned by
+// GetRecords, but left out of answers. Records not read from Rage4 are
+// active.
+func IsActive(record libdns.Record) bool {
+	data, ok := rage4Data(record)
+	return !ok || data.ID == 0 || data.IsActive
+}
+
+// SetRecordActive enables or disables a record without deleting it, for
+// example to take a server out of rotation during maintenance: Rage4
+// keeps a disabled record, with its ID and settings, but leaves it out
+// of answers until it is enabled again. Records read from Rage4 are
+// found by ID; others are matched by name and, unless left empty or
+// zero, type, data and TTL. The record is returned in its new state, or
+// ErrRecordNotFound if it does not exist.
+func (p *Provider) SetRecordActive(ctx context.Context, zone string, record libdns.Record, active bool) (_ libdns.Record, err error) {
+	ctx, done, err := p.beginOp(ctx, "SetRecordActive", zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	domainID, err := p.getDomainID(ctx, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+
+	existing, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+	var found Rage4Record
+	if id := recordID(record); id != 0 {
+		for _, r := range existing {
+			if r.ID == id {
+				found = r
+			}
+		}
+	} else {
+		found, _ = findRecord(existing, zoneName, record)
+	}
+	if found.ID == 0 {
+		rr := record.RR()
+		return nil, fmt.Errorf("%w: %s %s %q", ErrRecordNotFound, rr.Name, rr.Type, rr.Data)
+	}
+
+	params := url.Values{
+		"id":     {strconv.FormatInt(found.ID, 10)},
+		"active": {strconv.FormatBool(active)},
+	}
+	if err := p.post(ctx, "SetRecordState", params, nil); err != nil {
+		return nil, fmt.Errorf("failed to set record state: %w", err)
+	}
+
+	found.IsActive = active
+	return fromRage4(found, zoneName)
+}
@@ -0,0 +1,54 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSetRecordActive(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+
+	// matched by content
+	disabled, err := p.SetRecordActive(ctx, "example.com.", libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}, false)
+	if err != nil {
+		t.Fatalf("SetRecordActive failed: %v", err)
+	}
+	if IsActive(disabled) || recordID(disabled) != id {
+		t.Errorf("expected record %d to be disabled, got %+v", id, disabled)
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 2 || IsActive(records[0]) || !IsActive(records[1]) {
+		t.Errorf("expected the disabled record to be kept, got %+v", records)
+	}
+
+	// matched by ID
+	enabled, err := p.SetRecordActive(ctx, "example.com.", records[0], true)
+	if err != nil {
+		t.Fatalf("SetRecordActive failed: %v", err)
+	}
+	if !IsActive(enabled) || !f.domainRecords(1)[0].IsActive {
+		t.Errorf("expected the record to be enabled again, got %+v", enabled)
+	}
+	if f.calls("DeleteRecord") != 0 || f.calls("CreateRecord") != 0 {
+		t.Error("expected the record to be neither deleted nor recreated")
+	}
+
+	_, err = p.SetRecordActive(ctx, "example.com.", libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9", TTL: time.Hour}, false)
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+	if !IsActive(libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9"}) {
+		t.Error("expected records not read from Rage4 to be active")
+	}
+}
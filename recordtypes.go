@@ -0,0 +1,178 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedRecordType is returned when creating a record of a type
+// that Rage4 does not offer to the account.
+var ErrUnsupportedRecordType = errors.New("unsupported record type")
+
+// RecordType is a record type supported by Rage4, with the numeric ID
+// some endpoints use instead of the mnemonic.
+type RecordType struct {
+	Name string `json:"name"`
+	ID   int    `json:"value"`
+}
+
+// recordTypeCache holds the record types of the account. The zero value
+// is empty; it is filled on first use and cleared by Reload.
+type recordTypeCache struct {
+	mu    sync.Mutex
+	types []RecordType
+}
+
+// ListRecordTypes returns the record types supported by Rage4. The list
+// is fetched once and cached; callers get a copy of it.
+func (p *Provider) ListRecordTypes(ctx context.Context) ([]RecordType, error) {
+	p.recordTypes.mu.Lock()
+	defer p.recordTypes.mu.Unlock()
+
+	if p.recordTypes.types != nil {
+		return slices.Clone(p.recordTypes.types), nil
+	}
+
+	var types []RecordType
+	if err := p.get(ctx, "ListRecordTypes", nil, &types); err != nil {
+		return nil, fmt.Errorf("failed to list record types: %w", err)
+	}
+
+	p.recordTypes.types = types
+	return slices.Clone(types), nil
+}
+
+// RecordTypeID returns the numeric ID of a record type mnemonic.
+func (p *Provider) RecordTypeID(ctx context.Context, name string) (int, error) {
+	types, err := p.ListRecordTypes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range types {
+		if t.Name == name {
+			return t.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown record type: %s", name)
+}
+
+// RecordTypeName returns the mnemonic of a numeric record type ID.
+func (p *Provider) RecordTypeName(ctx context.Context, id int) (string, error) {
+	types, err := p.ListRecordTypes(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range types {
+		if t.ID == id {
+			return t.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown record type ID: %d", id)
+}
+
+// recordType returns the mnemonic Rage4 uses for a record type about to
+// be submitted, matching the types of the account ignoring case, or an
+// error wrapping ErrUnsupportedRecordType if the account does not offer
+// it. The types the package converts itself are offered by every plan,
+// and those with a registered Converter are taken as known to be, so
+// both are passed through without fetching the type list.
+func (p *Provider) recordType(ctx context.Context, name string) (string, error) {
+	if _, ok := converterFor(name); ok || slices.Contains(supportedRecordTypes, name) {
+		return name, nil
+	}
+
+	types, err := p.ListRecordTypes(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range types {
+		if strings.EqualFold(t.Name, name) {
+			return t.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: Rage4 does not support %s records on this plan", ErrUnsupportedRecordType, name)
+}
+
+// resolveRecordTypes replaces numeric record types, as decoded by
+// Rage4Record.UnmarshalJSON, with their mnemonics. The type list is only
+// needed if a numeric type is present; unknown IDs are left as they are.
+func (p *Provider) resolveRecordTypes(ctx context.Context, records []Rage4Record) error {
+	var names map[int]string
+	for i := range records {
+		id, err := strconv.Atoi(records[i].Type)
+		if err != nil {
+			continue
+		}
+
+		if names == nil {
+			types, err := p.ListRecordTypes(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to resolve record type IDs: %w", err)
+			}
+			names = make(map[int]string, len(types))
+			for _, t := range types {
+				names[t.ID] = t.Name
+			}
+		}
+
+		if name, ok := names[id]; ok {
+			records[i].Type = name
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a record, accepting the type either as a
+// mnemonic or as a numeric ID. Numeric IDs are kept in decimal form in
+// Type until they are resolved. Record and domain IDs are read as exact
+// 64-bit integers, also when quoted.
+func (r *Rage4Record) UnmarshalJSON(data []byte) error {
+	type plain Rage4Record
+	aux := struct {
+		*plain
+		ID       json.Number     `json:"id"`
+		DomainID json.Number     `json:"domain_id"`
+		Type     json.RawMessage `json:"type"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if r.ID, err = parseID(aux.ID); err != nil {
+		return fmt.Errorf("invalid record ID: %w", err)
+	}
+	if r.DomainID, err = parseID(aux.DomainID); err != nil {
+		return fmt.Errorf("invalid domain ID: %w", err)
+	}
+
+	switch {
+	case len(aux.Type) == 0 || string(aux.Type) == "null":
+		r.Type = ""
+	case aux.Type[0] == '"':
+		return json.Unmarshal(aux.Type, &r.Type)
+	default:
+		var id int
+		if err := json.Unmarshal(aux.Type, &id); err != nil {
+			return fmt.Errorf("invalid record type %s", aux.Type)
+		}
+		r.Type = strconv.Itoa(id)
+	}
+	return nil
+}
+
+// parseID returns the integer value of an ID, or zero if it is empty.
+// IDs are never decoded through float64, which cannot hold every ID
+// above 2^53 exactly.
+func parseID(n json.Number) (int64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(n), 10, 64)
+}
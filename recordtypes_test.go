@@ -0,0 +1,98 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRage4RecordUnmarshalType(t *testing.T) {
+	var records []Rage4Record
+	data := `[{"id": 1, "type": "A"}, {"id": 2, "type": 5}, {"id": 3, "type": null}]`
+	if err := json.Unmarshal([]byte(data), &records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if records[0].Type != "A" || records[1].Type != "5" || records[2].Type != "" || records[1].ID != 2 {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type": 1.5}`), &records[0]); err == nil {
+		t.Error("expected a fractional type to be rejected")
+	}
+}
+
+func TestNumericRecordTypes(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.numericTypes = true
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "MX", Content: "mail.example.com", Priority: 10, TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	for range 2 {
+		records, err := p.GetRecords(ctx, "example.com.")
+		if err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+		if len(records) != 2 || records[0].RR().Data != "10 mail.example.com" || records[1].RR().Type != "A" {
+			t.Errorf("expected resolved types, got %+v", records)
+		}
+	}
+	if n := f.calls("ListRecordTypes"); n != 1 {
+		t.Errorf("expected the type table to be fetched once, got %d", n)
+	}
+
+	if id, err := p.RecordTypeID(ctx, "AAAA"); err != nil || id != 3 {
+		t.Errorf("unexpected ID for AAAA: %d, %v", id, err)
+	}
+	if _, err := p.RecordTypeName(ctx, 99); err == nil {
+		t.Error("expected an unknown ID to be rejected")
+	}
+}
+
+func TestCreateRecordType(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if n := f.calls("ListRecordTypes"); n != 0 {
+		t.Errorf("expected built-in types not to be looked up, got %d lookups", n)
+	}
+
+	// types are sent as Rage4 lists them
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "txt", Type: "txt", Data: "hello"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if records := f.domainRecords(1); len(records) != 2 || records[1].Type != "TXT" {
+		t.Errorf("expected the type to be translated, got %+v", records)
+	}
+
+	https := libdns.RR{Name: "www", Type: "HTTPS", Data: `1 . alpn="h2"`}
+	_, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{https})
+	if !errors.Is(err, ErrUnsupportedRecordType) || !strings.Contains(err.Error(), "Rage4 does not support HTTPS records") {
+		t.Fatalf("expected ErrUnsupportedRecordType, got %v", err)
+	}
+	if n := f.calls("CreateRecord"); n != 2 {
+		t.Errorf("expected the unsupported record not to be sent, got %d creations", n)
+	}
+}
+
+func TestListRecordTypesReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t)
+
+	types, err := p.ListRecordTypes(ctx)
+	if err != nil || len(types) == 0 {
+		t.Fatalf("ListRecordTypes failed: %v, %v", types, err)
+	}
+	name := types[0].Name
+	types[0].Name = "Changed"
+	if again, _ := p.ListRecordTypes(ctx); again[0].Name != name {
+		t.Errorf("expected the cached types to be unaffected, got %q", again[0].Name)
+	}
+}
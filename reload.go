@@ -0,0 +1,94 @@
+package libdnsrage4
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Reload atomically replaces the settings of a running provider with
+// those of cfg, for example after an API key rotation. Only the settings
+// that can be loaded from configuration (the fields with JSON names) are
+// replaced; hooks such as Approver are left alone. Operations already in
+// flight are not interrupted: every API request reads the settings once,
+// so requests started after Reload returns use the new settings.
+func (p *Provider) Reload(cfg *Provider) error {
+	cfg.mu.RLock()
+	email, apiKey, endpoint := cfg.Email, cfg.APIKey, cfg.Endpoint
+	budget, concurrency := cfg.MaxRequestsPerOperation, cfg.MaxConcurrentRequests
+	rate, burst := cfg.RequestsPerSecond, cfg.RequestBurst
+	allowedTTLs, strictTTL, undoWindow := cfg.AllowedTTLs, cfg.StrictTTL, cfg.UndoWindow
+	zoneDefaults, domainCacheTTL := cfg.ZoneDefaults, cfg.DomainCacheTTL
+	maxAttempts, retryBackoff := cfg.MaxAttempts, cfg.RetryBackoff
+	skipExisting, batchConcurrency := cfg.SkipExistingRecords, cfg.BatchConcurrency
+	transactional, recordDescription := cfg.TransactionalSetRecords, cfg.RecordDescription
+	quotas, domainCacheStale := cfg.Quotas, cfg.DomainCacheStale
+	strictFeatures := cfg.StrictFeatures
+	cfg.mu.RUnlock()
+
+	if email == "" || apiKey == "" {
+		return fmt.Errorf("email and API key are required")
+	}
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoint: %q", endpoint)
+		}
+	}
+
+	// the record type table, geo regions, domains and features may
+	// differ behind another endpoint or account
+	p.recordTypes.mu.Lock()
+	p.recordTypes.types = nil
+	p.recordTypes.mu.Unlock()
+	p.geoRegions.mu.Lock()
+	p.geoRegions.regions = nil
+	p.geoRegions.mu.Unlock()
+	p.InvalidateDomainCache()
+	p.features.reset()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Email = email
+	p.APIKey = apiKey
+	p.Endpoint = endpoint
+	p.MaxRequestsPerOperation = budget
+	p.MaxConcurrentRequests = concurrency
+	p.RequestsPerSecond = rate
+	p.RequestBurst = burst
+	p.AllowedTTLs = allowedTTLs
+	p.StrictTTL = strictTTL
+	p.UndoWindow = undoWindow
+	p.ZoneDefaults = zoneDefaults
+	p.DomainCacheTTL = domainCacheTTL
+	p.DomainCacheStale = domainCacheStale
+	p.StrictFeatures = strictFeatures
+	p.MaxAttempts = maxAttempts
+	p.RetryBackoff = retryBackoff
+	p.SkipExistingRecords = skipExisting
+	p.BatchConcurrency = batchConcurrency
+	p.TransactionalSetRecords = transactional
+	p.RecordDescription = recordDescription
+	p.Quotas = quotas
+	return nil
+}
+
+// ReloadFile reloads the settings from a JSON file with the same
+// structure as the provider's configuration, such as
+// {"email": "...", "api_key": "..."}. It is meant to be called from a
+// SIGHUP handler or a file watcher.
+func (p *Provider) ReloadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Provider
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return p.Reload(&cfg)
+}
@@ -0,0 +1,49 @@
+package libdnsrage4
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	// rotate the key on the server; the provider still has the old one
+	f.mu.Lock()
+	f.apiKey = "rotated"
+	f.mu.Unlock()
+
+	if _, err := p.GetRecords(ctx, "example.com."); err == nil {
+		t.Fatal("expected the old key to be rejected")
+	}
+
+	cfg := filepath.Join(t.TempDir(), "rage4.json")
+	os.WriteFile(cfg, []byte(`{"email": "user@example.com", "api_key": "rotated", "endpoint": "`+p.Endpoint+`"}`), 0o600)
+	if err := p.ReloadFile(cfg); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+
+	if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+		t.Errorf("expected the rotated key to be accepted: %v", err)
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	p := &Provider{Email: "user@example.com", APIKey: "secret"}
+
+	for _, cfg := range []*Provider{
+		{Email: "user@example.com"},
+		{Email: "user@example.com", APIKey: "new", Endpoint: "rage4.com/rapi"},
+	} {
+		if err := p.Reload(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+
+	if p.APIKey != "secret" {
+		t.Errorf("rejected config must not be applied, got key %q", p.APIKey)
+	}
+}
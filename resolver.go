@@ -0,0 +1,84 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// Resolver looks up the public DNS data that the verification features
+// check: zone delegations, ownership tokens and ACME challenge CNAMEs.
+// *net.Resolver implements it; set Provider.Resolver to a resolver for
+// a specific server, for example behind split-horizon DNS, or to a
+// StaticResolver in tests and air-gapped environments.
+type Resolver interface {
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// resolver returns the resolver of p, net.DefaultResolver by default
+func (p *Provider) resolver() Resolver {
+	if p == nil || p.Resolver == nil {
+		return net.DefaultResolver
+	}
+	return p.Resolver
+}
+
+// StaticResolver is a Resolver that answers from fixed data, keyed by
+// name without the trailing dot. Names it has no data for do not exist,
+// as for a real resolver. It is safe for concurrent use as long as its
+// maps are not modified.
+type StaticResolver struct {
+	NS    map[string][]string
+	TXT   map[string][]string
+	CNAME map[string]string
+}
+
+// LookupNS implements Resolver.
+func (r *StaticResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	hosts, ok := r.NS[staticKey(name)]
+	if !ok {
+		return nil, notFound(name)
+	}
+	ns := make([]*net.NS, len(hosts))
+	for i, host := range hosts {
+		ns[i] = &net.NS{Host: host}
+	}
+	return ns, nil
+}
+
+// LookupTXT implements Resolver.
+func (r *StaticResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	values, ok := r.TXT[staticKey(name)]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return values, nil
+}
+
+// LookupCNAME implements Resolver. Like net.Resolver, it returns the
+// name itself if it exists but is no alias.
+func (r *StaticResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if target, ok := r.CNAME[staticKey(host)]; ok {
+		return target, nil
+	}
+	if _, ok := r.TXT[staticKey(host)]; ok {
+		return host, nil
+	}
+	if _, ok := r.NS[staticKey(host)]; ok {
+		return host, nil
+	}
+	return "", notFound(host)
+}
+
+// staticKey returns the key of name in the maps of a StaticResolver
+func staticKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// notFound returns the error net.Resolver returns for a name that does
+// not exist
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
@@ -0,0 +1,87 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStaticResolver(t *testing.T) {
+	ctx := context.Background()
+	r := &StaticResolver{
+		NS:    map[string][]string{"example.org": {"ns1.r4ns.com.", "ns2.r4ns.net."}},
+		TXT:   map[string][]string{"_verify.example.org": {"token"}},
+		CNAME: map[string]string{"www.example.org": "example.org."},
+	}
+
+	ns, err := r.LookupNS(ctx, "Example.org.")
+	if err != nil || len(ns) != 2 || ns[0].Host != "ns1.r4ns.com." {
+		t.Errorf("unexpected NS lookup: %v, %v", ns, err)
+	}
+	if txt, err := r.LookupTXT(ctx, "_verify.example.org."); err != nil || len(txt) != 1 || txt[0] != "token" {
+		t.Errorf("unexpected TXT lookup: %v, %v", txt, err)
+	}
+	if cname, err := r.LookupCNAME(ctx, "www.example.org."); err != nil || cname != "example.org." {
+		t.Errorf("unexpected CNAME lookup: %q, %v", cname, err)
+	}
+	if cname, err := r.LookupCNAME(ctx, "example.org."); err != nil || cname != "example.org." {
+		t.Errorf("expected a name that is no alias to be its own canonical name, got %q, %v", cname, err)
+	}
+
+	var dnsErr *net.DNSError
+	if _, err := r.LookupTXT(ctx, "missing.example.org."); !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestResolverOnboardZone(t *testing.T) {
+	_, p := newFakeRage4(t)
+	p.Resolver = &StaticResolver{
+		NS: map[string][]string{"example.org": {"ns1.r4ns.com.", "ns2.r4ns.net."}},
+	}
+
+	result, err := p.OnboardZone(context.Background(), "example.org.", OnboardOptions{
+		WaitForDelegation: true,
+		PollInterval:      time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("OnboardZone failed: %v", err)
+	}
+	if !result.Delegated {
+		t.Error("expected delegation to be detected through the provider's resolver")
+	}
+}
+
+func TestResolverCheckDelegation(t *testing.T) {
+	d := &ACMEDelegation{Provider: &Provider{}, Zone: "acme.example.net."}
+	d.Provider.Resolver = &StaticResolver{
+		CNAME: map[string]string{"_acme-challenge.customer.com": d.Target("customer.com") + "."},
+	}
+
+	if ok, err := d.CheckDelegation(context.Background(), "customer.com", nil); !ok || err != nil {
+		t.Errorf("expected delegation to be detected, got %v, %v", ok, err)
+	}
+	if ok, err := d.CheckDelegation(context.Background(), "other.com", nil); ok || err != nil {
+		t.Errorf("expected missing delegation, got %v, %v", ok, err)
+	}
+}
+
+func TestResolverVerification(t *testing.T) {
+	v, err := NewVerification("customer.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := &StaticResolver{TXT: map[string][]string{}}
+	v.Resolver = resolver
+
+	if ok, err := v.Check(context.Background(), nil); ok || err != nil {
+		t.Errorf("expected unverified domain, got %v, %v", ok, err)
+	}
+
+	resolver.TXT[v.FQDN()] = []string{"other", v.Token}
+	if ok, err := v.Check(context.Background(), nil); !ok || err != nil {
+		t.Errorf("expected verified domain, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,108 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults of the retry settings.
+const (
+	defaultMaxAttempts  = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+
+	// maxRetryBackoff caps the backoff between attempts
+	maxRetryBackoff = 30 * time.Second
+)
+
+// Attempt records one try of an API request.
+type Attempt struct {
+	// Time is when the request was sent
+	Time time.Time `json:"time"`
+
+	// StatusCode is the HTTP status of the response, or zero if none
+	// was received
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Err is why the attempt failed
+	Err error `json:"-"`
+
+	// Wait is how long the provider waited before the next attempt
+	Wait time.Duration `json:"wait,omitempty"`
+}
+
+// RetryError is returned when an API request still fails after every
+// allowed attempt. It carries the history of the attempts, so that
+// postmortems can see how the failure unfolded without debug logs.
+type RetryError struct {
+	// Method is the Rage4 API method, such as "GetRecords"
+	Method string `json:"method"`
+
+	// Attempts are the attempts made, oldest first
+	Attempts []Attempt `json:"attempts"`
+}
+
+func (e *RetryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s failed after %d attempts", e.Method, len(e.Attempts))
+	for i, a := range e.Attempts {
+		fmt.Fprintf(&b, "; #%d at %s", i+1, a.Time.Format(time.RFC3339Nano))
+		if a.StatusCode != 0 {
+			fmt.Fprintf(&b, " status %d", a.StatusCode)
+		}
+		if a.Err != nil {
+			fmt.Fprintf(&b, ": %v", a.Err)
+		}
+		if a.Wait > 0 {
+			fmt.Fprintf(&b, " (waited %s)", a.Wait)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// retryable reports whether an attempt that failed with the given status
+// code, or zero if no response was received, and error may succeed if
+// repeated. Rate limited requests are always retried, after the wait
+// Rage4 asks for. Reads are also retried on server errors and failed
+// connections. Mutations are only retried when Rage4 reports that the
+// request did not reach it, with 502 or 503, since any other failure may
+// come after the change was made and repeating it could apply it twice.
+func retryable(httpMethod string, statusCode int, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrBudgetExceeded) {
+		return false
+	}
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if httpMethod != http.MethodGet {
+		return statusCode == http.StatusBadGateway || statusCode == http.StatusServiceUnavailable
+	}
+	return statusCode == 0 || statusCode >= 500
+}
+
+// retryDelay returns the time to wait after the given number of failed
+// attempts: the backoff doubles with every attempt, up to
+// maxRetryBackoff, and a random half of it is jitter, so that clients
+// failing together do not retry in lockstep
+func retryDelay(backoff time.Duration, attempts int) time.Duration {
+	d := backoff
+	for range attempts - 1 {
+		if d *= 2; d >= maxRetryBackoff {
+			d = maxRetryBackoff
+			break
+		}
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,166 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRetryError(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	err := &RetryError{
+		Method: "GetRecords",
+		Attempts: []Attempt{
+			{Time: start, StatusCode: 503, Err: errors.New("service unavailable"), Wait: time.Second},
+			{Time: start.Add(time.Second), Err: context.DeadlineExceeded},
+		},
+	}
+
+	want := "GetRecords failed after 2 attempts" +
+		"; #1 at 2024-01-01T12:00:00Z status 503: service unavailable (waited 1s)" +
+		"; #2 at 2024-01-01T12:00:01Z: context deadline exceeded"
+	if err.Error() != want {
+		t.Errorf("unexpected message:\n%s\nwant:\n%s", err.Error(), want)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the last attempt's error to be wrapped")
+	}
+}
+
+// flaky wraps a handler, failing the next failures requests with status
+type flaky struct {
+	http.Handler
+	status   atomic.Int32
+	failures atomic.Int32
+}
+
+// fail makes the next n requests fail with status
+func (f *flaky) fail(n, status int) {
+	f.status.Store(int32(status))
+	f.failures.Store(int32(n))
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.failures.Add(-1) >= 0 {
+		http.Error(w, "try again", int(f.status.Load()))
+		return
+	}
+	f.Handler.ServeHTTP(w, r)
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newFakeRage4(t, "example.com.")
+	handler := &flaky{Handler: f}
+	handler.fail(2, http.StatusServiceUnavailable)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	var timing OperationTiming
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi", RetryBackoff: time.Millisecond}
+	p.OnTiming = func(t OperationTiming) { timing = t }
+
+	if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatalf("expected GetRecords to succeed on the third attempt, got %v", err)
+	}
+	if len(timing.Requests) != 4 || timing.Requests[0].StatusCode != 503 || timing.Requests[1].Wait == 0 {
+		t.Errorf("expected the backoff to be timed as waiting, got %+v", timing.Requests)
+	}
+
+	handler.fail(5, http.StatusServiceUnavailable)
+	_, err := p.GetRecords(ctx, "example.com.")
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 || retryErr.Attempts[0].Wait == 0 {
+		t.Fatalf("expected a RetryError after three attempts, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Errorf("expected the last APIError to be wrapped, got %v", err)
+	}
+
+	// a failed mutation may have been applied, so it is not repeated
+	handler.fail(1, http.StatusInternalServerError)
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); !errors.As(err, &apiErr) || errors.As(err, &retryErr) {
+		t.Errorf("expected the mutation to fail without retries, got %v", err)
+	}
+
+	handler.fail(5, http.StatusServiceUnavailable)
+	p.RetryBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.GetRecords(ctx, "example.com."); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the backoff to end with the context, got %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, limit := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 10: maxRetryBackoff} {
+		if d := retryDelay(time.Second, attempts); d < limit/2 || d > limit {
+			t.Errorf("retryDelay after %d attempts = %s, want between %s and %s", attempts, d, limit/2, limit)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	f, _ := newFakeRage4(t, "example.com.")
+	var retryAfter atomic.Value
+	retryAfter.Store("0")
+	var limited atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited.Add(-1) >= 0 {
+			w.Header().Set("Retry-After", retryAfter.Load().(string))
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		f.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi", RetryBackoff: time.Millisecond}
+
+	// rate limited mutations are retried, since they were not applied
+	p.GetRecords(context.Background(), "example.com.")
+	limited.Store(2)
+	if _, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Fatalf("expected the rate limited request to be retried, got %v", err)
+	}
+	if n := f.calls("CreateRecord"); n != 1 {
+		t.Errorf("expected one record to be created, got %d", n)
+	}
+
+	// waits beyond the deadline are not attempted
+	retryAfter.Store("120")
+	limited.Store(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	_, err := p.GetRecords(ctx, "example.com.")
+	var rateLimit *RateLimitError
+	if !errors.As(err, &rateLimit) || rateLimit.RetryAfter != 2*time.Minute || rateLimit.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a RateLimitError, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("expected the provider not to wait past the deadline")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"30":                            30 * time.Second,
+		"-5":                            0,
+		"Mon, 01 Jan 2024 12:01:00 GMT": time.Minute,
+		"Mon, 01 Jan 2024 11:00:00 GMT": 0,
+		"soon":                          0,
+	} {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
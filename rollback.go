@@ -0,0 +1,93 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// transactionalSetRecords reports whether SetRecords rolls back on
+// failure
+func (p *Provider) transactionalSetRecords() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.TransactionalSetRecords
+}
+
+// setRollback records the changes SetRecords made to a zone, so that
+// they can be reversed if a later change fails
+type setRollback struct {
+	domainID int64
+	zoneName string
+
+	updated []updatedRecord // in the order made
+	deleted []libdns.Record
+	created []libdns.Record
+}
+
+// updatedRecord is a record updated in place, before and after
+type updatedRecord struct {
+	before, after libdns.Record
+}
+
+// rollbackKey marks the context of the requests undoing a failed
+// operation
+type rollbackKey struct{}
+
+// rollingBack reports whether ctx belongs to a rollback. Its requests
+// are exempt from the request budget and tenant quota of the operation,
+// which may be what failed.
+func rollingBack(ctx context.Context) bool {
+	return ctx.Value(rollbackKey{}) != nil
+}
+
+// undo reverses the recorded changes: created records are deleted,
+// deleted ones created again, with new IDs, and updated ones changed
+// back. It carries on past failures, which it returns joined. Rolling
+// back is not cut short when ctx is canceled, nor by the request budget
+// or tenant quota of the operation, as those are among the failures it
+// undoes.
+func (rb *setRollback) undo(ctx context.Context, p *Provider) error {
+	ctx = context.WithValue(context.WithoutCancel(ctx), rollbackKey{}, true)
+	var errs []error
+
+	if len(rb.created) > 0 {
+		if _, err := p.deleteRecords(ctx, rb.domainID, rb.zoneName, rb.created, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete created records: %w", err))
+		}
+	}
+	if len(rb.deleted) > 0 {
+		if _, err := p.appendRecords(ctx, rb.domainID, rb.zoneName, rb.deleted); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore deleted records: %w", err))
+		}
+	}
+	for i := len(rb.updated) - 1; i >= 0; i-- {
+		u := rb.updated[i]
+		if _, err := p.updateRecord(ctx, rb.zoneName, u.after, u.before); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revert updated record: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RollbackError is returned by SetRecords in transactional mode when
+// rolling back after a failure failed as well, so the zone may be left
+// with only part of the change.
+type RollbackError struct {
+	// Err is the failure that caused the rollback
+	Err error
+
+	// RollbackErr is the failure of the rollback
+	RollbackErr error
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%v; rollback failed: %v", e.Err, e.RollbackErr)
+}
+
+// Unwrap returns the failure that caused the rollback.
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,89 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestTransactionalSetRecords(t *testing.T) {
+	ctx := context.Background()
+	f, _ := newFakeRage4(t, "example.com.")
+	srv := httptest.NewServer(droppingRage4{f})
+	t.Cleanup(srv.Close)
+	p := &Provider{Email: f.email, APIKey: f.apiKey, Endpoint: srv.URL + "/rapi", TransactionalSetRecords: true}
+
+	description := "web"
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600, Description: &description})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+
+	_, err := p.SetRecords(ctx, "example.com.", []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9"},
+		libdns.RR{Name: "new", Type: "A", Data: "192.0.2.3"},
+		libdns.RR{Name: "rejected", Type: "A", Data: "192.0.2.4"},
+	})
+	if err == nil {
+		t.Fatalf("expected SetRecords to fail")
+	}
+	var rollbackErr *RollbackError
+	if errors.As(err, &rollbackErr) {
+		t.Errorf("expected the rollback to succeed, got %v", err)
+	}
+
+	// the update, the deletion and the creation are all reversed
+	var contents []string
+	for _, r := range f.domainRecords(1) {
+		contents = append(contents, r.Name+" "+r.Content)
+		if r.ID == id && (r.Content != "192.0.2.1" || r.Description == nil || *r.Description != "web") {
+			t.Errorf("expected the updated record to be restored, got %+v", r)
+		}
+	}
+	slices.Sort(contents)
+	if want := []string{"www.example.com 192.0.2.1", "www.example.com 192.0.2.2"}; !slices.Equal(contents, want) {
+		t.Errorf("expected %v, got %v", want, contents)
+	}
+	if f.calls("UpdateRecord") != 2 || f.calls("DeleteRecord") != 2 || f.calls("CreateRecord") != 2 {
+		t.Errorf("unexpected requests: %v", f.requests)
+	}
+}
+
+func TestTransactionalSetRecordsOverLimits(t *testing.T) {
+	for name, limit := range map[string]func(context.Context, *Provider) context.Context{
+		"budget": func(ctx context.Context, p *Provider) context.Context {
+			return WithRequestBudget(ctx, 3)
+		},
+		"quota": func(ctx context.Context, p *Provider) context.Context {
+			p.Quotas = map[string]Quota{"team-a": {MaxMutationsPerHour: 1}}
+			return WithTenant(ctx, "team-a")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, p := newFakeRage4(t, "example.com.")
+			p.TransactionalSetRecords = true
+			f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+			ctx := limit(context.Background(), p)
+
+			// the update is made, the creation refused, and the update
+			// reverted regardless of the limit
+			_, err := p.SetRecords(ctx, "example.com.", []libdns.Record{
+				libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9"},
+				libdns.RR{Name: "new", Type: "A", Data: "192.0.2.3"},
+			})
+			var rollbackErr *RollbackError
+			if err == nil || errors.As(err, &rollbackErr) {
+				t.Fatalf("expected SetRecords to fail and roll back, got %v", err)
+			}
+			records := f.domainRecords(1)
+			if len(records) != 1 || records[0].Content != "192.0.2.1" {
+				t.Errorf("expected the zone to be restored, got %+v", records)
+			}
+			if f.calls("UpdateRecord") != 2 {
+				t.Errorf("unexpected requests: %v", f.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// RecordsAsRR returns the records of zone as miekg/dns resource records
+// with fully-qualified owner names, for consumption by tooling that
+// validates, signs, or serves zones.
+func (p *Provider) RecordsAsRR(ctx context.Context, zone string) ([]dns.RR, error) {
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := dns.Fqdn(zone)
+	rrs := make([]dns.RR, 0, len(records))
+	for _, record := range records {
+		rr, err := safely(func() (dns.RR, error) {
+			return RecordToRR(record, origin)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert record %s %s: %w", record.RR().Name, record.RR().Type, err)
+		}
+		rrs = append(rrs, rr)
+	}
+
+	return rrs, nil
+}
+
+// RecordToRR converts a libdns.Record with a name relative to origin
+// into a miekg/dns resource record.
+func RecordToRR(record libdns.Record, origin string) (dns.RR, error) {
+	r := record.RR()
+	hdr := dns.RR_Header{
+		Name:  libdns.AbsoluteName(normalizeName(r.Name), origin),
+		Class: dns.ClassINET,
+		Ttl:   uint32(r.TTL.Seconds()),
+	}
+
+	// TXT data is free-form text and is built directly rather than being
+	// run through the zone file parser
+	switch r.Type {
+	case "TXT":
+		hdr.Rrtype = dns.TypeTXT
+		return &dns.TXT{Hdr: hdr, Txt: splitTXT(r.Data)}, nil
+	case "SPF":
+		hdr.Rrtype = dns.TypeSPF
+		return &dns.SPF{Hdr: hdr, Txt: splitTXT(r.Data)}, nil
+	}
+
+	// host names in the data are made fully-qualified, since Rage4
+	// returns them without the trailing dot
+	rdata := r.Data
+	switch r.Type {
+	case "CNAME", "NS", "PTR":
+		rdata = dns.Fqdn(r.Data)
+	case "MX":
+		fields := strings.Fields(r.Data)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed MX data %q; expected '<preference> <target>'", r.Data)
+		}
+		rdata = fields[0] + " " + dns.Fqdn(fields[1])
+	case "SRV":
+		fields := strings.Fields(r.Data)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed SRV data %q; expected '<priority> <weight> <port> <target>'", r.Data)
+		}
+		rdata = strings.Join(fields[:3], " ") + " " + dns.Fqdn(fields[3])
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", hdr.Name, hdr.Ttl, r.Type, rdata))
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty record data")
+	}
+
+	return rr, nil
+}
+
+// splitTXT splits a TXT value into character strings of at most 255
+// bytes each
+func splitTXT(value string) []string {
+	if value == "" {
+		return []string{""}
+	}
+
+	var chunks []string
+	for len(value) > 255 {
+		chunks = append(chunks, value[:255])
+		value = value[255:]
+	}
+	return append(chunks, value)
+}
+
+// RecordFromRR converts a miekg/dns resource record into a
+// libdns.Record with a name relative to origin. Record types that Rage4
+// manages itself, or that it does not support, are rejected.
+func RecordFromRR(rr dns.RR, origin string) (libdns.Record, error) {
+	hdr := rr.Header()
+
+	if !dns.IsSubDomain(origin, hdr.Name) {
+		return nil, fmt.Errorf("record is outside of zone %s", origin)
+	}
+
+	name := libdns.RelativeName(hdr.Name, origin)
+	if name == "" {
+		name = "@"
+	}
+
+	record := libdns.RR{
+		Name: name,
+		TTL:  time.Duration(hdr.Ttl) * time.Second,
+		Type: dns.TypeToString[hdr.Rrtype],
+	}
+
+	switch v := rr.(type) {
+	case *dns.SOA:
+		return nil, fmt.Errorf("SOA records are managed by Rage4")
+	case *dns.NS:
+		if name == "@" {
+			return nil, fmt.Errorf("apex NS records are managed by Rage4")
+		}
+		record.Data = strings.TrimSuffix(v.Ns, ".")
+	case *dns.A:
+		record.Data = v.A.String()
+	case *dns.AAAA:
+		record.Data = v.AAAA.String()
+	case *dns.CNAME:
+		record.Data = strings.TrimSuffix(v.Target, ".")
+	case *dns.PTR:
+		record.Data = strings.TrimSuffix(v.Ptr, ".")
+	case *dns.MX:
+		record.Data = fmt.Sprintf("%d %s", v.Preference, strings.TrimSuffix(v.Mx, "."))
+	case *dns.SRV:
+		record.Data = fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, strings.TrimSuffix(v.Target, "."))
+	case *dns.TXT:
+		record.Data = strings.Join(v.Txt, "")
+	case *dns.SPF:
+		record.Data = strings.Join(v.Txt, "")
+	case *dns.CAA, *dns.SSHFP, *dns.TLSA:
+		record.Data = strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String()))
+	default:
+		return nil, fmt.Errorf("unsupported record type %s", record.Type)
+	}
+
+	return record.Parse()
+}
@@ -0,0 +1,79 @@
+package libdnsrage4
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+func TestRecordToRR(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    libdns.Record
+		expected string
+	}{
+		{
+			name:     "A record",
+			input:    libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 3600 * time.Second},
+			expected: "www.example.com.\t3600\tIN\tA\t192.0.2.1",
+		},
+		{
+			name:     "MX record at apex",
+			input:    libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: "mail.example.com"},
+			expected: "example.com.\t300\tIN\tMX\t10 mail.example.com.",
+		},
+		{
+			name:     "SRV record",
+			input:    libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: 300 * time.Second, Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com"},
+			expected: "_sip._tcp.example.com.\t300\tIN\tSRV\t10 20 5060 sip.example.com.",
+		},
+		{
+			name:     "TXT record with spaces and quotes",
+			input:    libdns.RR{Name: "txt", Type: "TXT", Data: `say "hi" there`, TTL: 60 * time.Second},
+			expected: "txt.example.com.\t60\tIN\tTXT\t\"say \\\"hi\\\" there\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, err := RecordToRR(tt.input, "example.com.")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rr.String() != tt.expected {
+				t.Errorf("got %q, want %q", rr.String(), tt.expected)
+			}
+
+			// converting back must yield the original record
+			back, err := RecordFromRR(rr, "example.com.")
+			if err != nil {
+				t.Fatalf("failed to convert back: %v", err)
+			}
+			if back.RR() != tt.input.RR() {
+				t.Errorf("round trip mismatch: got %+v, want %+v", back, tt.input)
+			}
+		})
+	}
+}
+
+func TestRecordToRRLongTXT(t *testing.T) {
+	value := strings.Repeat("a", 600)
+	rr, err := RecordToRR(libdns.RR{Name: "long", Type: "TXT", Data: value}, "example.com.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	txt := rr.(*dns.TXT)
+	if len(txt.Txt) != 3 || len(txt.Txt[0]) != 255 || len(txt.Txt[2]) != 90 {
+		t.Errorf("unexpected TXT chunks: %d", len(txt.Txt))
+	}
+}
+
+func TestRecordToRRMalformedSRV(t *testing.T) {
+	if _, err := RecordToRR(libdns.RR{Name: "_sip._tcp", Type: "SRV", Data: "sip.example.com"}, "example.com."); err == nil {
+		t.Error("expected error for malformed SRV value")
+	}
+}
@@ -0,0 +1,129 @@
+package libdnsrage4
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SchemaDrift reports fields in a Rage4 API response that the package
+// does not model, an early sign that the API changed. Each field is
+// reported once per provider.
+type SchemaDrift struct {
+	// Method is the API method, such as "GetRecords"
+	Method string `json:"method"`
+
+	// Fields are the paths of the unknown fields, such as "geo_code"
+	// or "[].health.state" for fields of the elements of a list
+	Fields []string `json:"fields"`
+}
+
+// driftTracker remembers the fields already reported to OnSchemaDrift
+type driftTracker struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// checkSchema reports the fields of data, the response to method decoded
+// into out, that out has no field for
+func (p *Provider) checkSchema(method string, data []byte, out any) {
+	if p.OnSchemaDrift == nil || out == nil {
+		return
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return
+	}
+
+	unknown := make(map[string]bool)
+	unknownFields(decoded, reflect.TypeOf(out), "", unknown)
+
+	p.drift.mu.Lock()
+	if p.drift.seen == nil {
+		p.drift.seen = make(map[string]bool)
+	}
+	var fields []string
+	for field := range unknown {
+		if key := method + " " + field; !p.drift.seen[key] {
+			p.drift.seen[key] = true
+			fields = append(fields, field)
+		}
+	}
+	p.drift.mu.Unlock()
+
+	if len(fields) > 0 {
+		slices.Sort(fields)
+		p.OnSchemaDrift(SchemaDrift{Method: method, Fields: fields})
+	}
+}
+
+// unknownFields adds to unknown the paths of the object keys in v that t,
+// the type v was decoded into, has no field for
+func unknownFields(v any, t reflect.Type, path string, unknown map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		known := jsonFields(t)
+		for key, value := range v {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			ft, ok := known[strings.ToLower(key)]
+			if !ok {
+				unknown[field] = true
+				continue
+			}
+			unknownFields(value, ft, field, unknown)
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for _, elem := range v {
+			unknownFields(elem, t.Elem(), path+"[]", unknown)
+		}
+	}
+}
+
+// jsonFields returns the types of the fields of struct type t by their
+// lower-cased JSON names, the way encoding/json matches them
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for n, t := range jsonFields(ft) {
+					fields[n] = t
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
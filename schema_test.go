@@ -0,0 +1,42 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rapi/GetDomainByName":
+			w.Write([]byte(`{"status":true,"data":{"id":1,"name":"example.com","dnssec":{"enabled":true}}}`))
+		case "/rapi/GetRecords":
+			w.Write([]byte(`[{"id":5,"name":"www.example.com","type":"A","content":"192.0.2.1","ttl":300,"health":{"state":"up"}},` +
+				`{"id":6,"name":"example.com","type":"A","content":"192.0.2.2","ttl":300,"GEO_LAT":1.5}]`))
+		}
+	}))
+	defer srv.Close()
+
+	var drifts []SchemaDrift
+	p := &Provider{Email: "user@example.com", APIKey: "secret", Endpoint: srv.URL + "/rapi"}
+	p.OnSchemaDrift = func(d SchemaDrift) { drifts = append(drifts, d) }
+
+	ctx := context.Background()
+	for range 2 {
+		p.InvalidateDomainCache()
+		if _, err := p.GetRecords(ctx, "example.com."); err != nil {
+			t.Fatalf("GetRecords failed: %v", err)
+		}
+	}
+
+	want := []SchemaDrift{
+		{Method: "GetDomainByName", Fields: []string{"dnssec"}},
+		{Method: "GetRecords", Fields: []string{"[].health"}},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("expected each unknown field to be reported once, got %+v", drifts)
+	}
+}
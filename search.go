@@ -0,0 +1,123 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// SearchResult is a record found by an account-wide search.
+type SearchResult struct {
+	Zone   string        `json:"zone"`
+	Record libdns.Record `json:"record"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding the record as in
+// Records.
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Zone   string     `json:"zone"`
+		Record jsonRecord `json:"record"`
+	}{r.Zone, newJSONRecord(r.Record)})
+}
+
+// SearchContent returns every record in the account whose data is
+// exactly content, across all zones, such as every A record pointing at
+// an address.
+func (p *Provider) SearchContent(ctx context.Context, content string) ([]SearchResult, error) {
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	var results []SearchResult
+	for _, domain := range domains {
+		zone := domain.Name + "."
+		records, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get records of %s: %w", zone, err)
+		}
+
+		for _, record := range records {
+			if record.RR().Data == content {
+				results = append(results, SearchResult{Zone: zone, Record: record})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// ReplaceOptions controls an account-wide content replacement.
+type ReplaceOptions struct {
+	// DryRun computes the per-zone change sets without applying them
+	DryRun bool
+
+	// Confirm, if set, is called once with the change sets of every
+	// affected zone before any of them is applied. Returning an error
+	// aborts the whole replacement.
+	Confirm func(ctx context.Context, plans []*ChangeSet) error
+}
+
+// ReplaceContent replaces the data of every record in the account whose
+// data is exactly oldContent with newContent, for example to move all
+// records from one datacenter IP to another. It returns one change set
+// per affected zone. Zones are applied one at a time; if applying a zone
+// fails, the zones before it remain changed. Replacements run with
+// PriorityBackground unless ctx carries a priority.
+func (p *Provider) ReplaceContent(ctx context.Context, oldContent, newContent string, opts ReplaceOptions) (_ []*ChangeSet, err error) {
+	ctx = background(ctx)
+	ctx, done, err := p.beginOp(ctx, "ReplaceContent", "")
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	results, err := p.SearchContent(ctx, oldContent)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := replacementPlans(results, newContent)
+	if opts.DryRun || len(plans) == 0 {
+		return plans, nil
+	}
+
+	if opts.Confirm != nil {
+		if err := opts.Confirm(ctx, plans); err != nil {
+			return plans, fmt.Errorf("%w: %w", ErrNotApproved, err)
+		}
+	}
+
+	for _, cs := range plans {
+		if err := p.Apply(ctx, cs); err != nil {
+			return plans, fmt.Errorf("failed to apply changes to %s: %w", cs.Zone, err)
+		}
+	}
+
+	return plans, nil
+}
+
+// replacementPlans groups search results into per-zone change sets that
+// recreate each record with newContent
+func replacementPlans(results []SearchResult, newContent string) []*ChangeSet {
+	var plans []*ChangeSet
+	byZone := make(map[string]*ChangeSet)
+	for _, result := range results {
+		cs, ok := byZone[result.Zone]
+		if !ok {
+			cs = &ChangeSet{Zone: result.Zone}
+			byZone[result.Zone] = cs
+			plans = append(plans, cs)
+		}
+
+		replacement := result.Record.RR()
+		replacement.Data = newContent
+
+		cs.Delete = append(cs.Delete, result.Record)
+		cs.Create = append(cs.Create, parseRecord(replacement))
+	}
+	return plans
+}
@@ -0,0 +1,33 @@
+package libdnsrage4
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReplacementPlans(t *testing.T) {
+	results := []SearchResult{
+		{Zone: "example.com.", Record: recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour})},
+		{Zone: "example.net.", Record: recordWithID(2, libdns.RR{Name: "@", Type: "A", Data: "192.0.2.1", TTL: time.Minute})},
+		{Zone: "example.com.", Record: recordWithID(3, libdns.RR{Name: "api", Type: "A", Data: "192.0.2.1", TTL: time.Hour})},
+	}
+
+	plans := replacementPlans(results, "198.51.100.1")
+
+	if len(plans) != 2 {
+		t.Fatalf("expected one plan per zone, got %d", len(plans))
+	}
+	if plans[0].Zone != "example.com." || len(plans[0].Delete) != 2 || len(plans[0].Create) != 2 {
+		t.Errorf("unexpected plan for example.com.: %+v", plans[0])
+	}
+
+	created := plans[1].Create[0]
+	if rr := created.RR(); recordID(created) != 0 || rr.Data != "198.51.100.1" || rr.TTL != time.Minute || rr.Name != "@" {
+		t.Errorf("unexpected replacement record: %+v", created)
+	}
+	if recordID(plans[1].Delete[0]) != 2 {
+		t.Errorf("expected original record to be deleted by ID, got %+v", plans[1].Delete[0])
+	}
+}
@@ -0,0 +1,94 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/libdns/libdns"
+)
+
+// Selector is a Matcher built from common criteria. Every criterion that
+// is set must match; the zero Selector matches every record.
+//
+// Names are compared relative to the zone, with "@" for the apex.
+type Selector struct {
+	// Name matches one exact name
+	Name string `json:"name,omitempty"`
+
+	// NameGlob matches names against a shell pattern as understood by
+	// path.Match, such as "_acme-challenge.*"
+	NameGlob string `json:"name_glob,omitempty"`
+
+	// NameRegexp matches names against a regular expression
+	NameRegexp *regexp.Regexp `json:"-"`
+
+	// Types matches any of the listed record types
+	Types []string `json:"types,omitempty"`
+
+	// ContentRegexp matches record data against a regular expression
+	ContentRegexp *regexp.Regexp `json:"-"`
+
+	// Content, if set, is an arbitrary predicate on record data
+	Content func(value string) bool `json:"-"`
+}
+
+// Validate reports whether the selector's patterns are well-formed.
+func (s Selector) Validate() error {
+	if s.NameGlob != "" {
+		if _, err := path.Match(s.NameGlob, ""); err != nil {
+			return fmt.Errorf("invalid name glob %q: %w", s.NameGlob, err)
+		}
+	}
+	return nil
+}
+
+// Match implements Matcher. A malformed NameGlob matches nothing.
+func (s Selector) Match(record libdns.Record) bool {
+	rr := record.RR()
+	name := normalizeName(rr.Name)
+
+	if s.Name != "" && name != normalizeName(s.Name) {
+		return false
+	}
+	if s.NameGlob != "" {
+		if ok, err := path.Match(s.NameGlob, name); err != nil || !ok {
+			return false
+		}
+	}
+	if s.NameRegexp != nil && !s.NameRegexp.MatchString(name) {
+		return false
+	}
+	if len(s.Types) > 0 && !slices.Contains(s.Types, rr.Type) {
+		return false
+	}
+	if s.ContentRegexp != nil && !s.ContentRegexp.MatchString(rr.Data) {
+		return false
+	}
+	if s.Content != nil && !s.Content(rr.Data) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the records selected by m.
+func Filter(records []libdns.Record, m Matcher) []libdns.Record {
+	var selected []libdns.Record
+	for _, record := range records {
+		if m.Match(record) {
+			selected = append(selected, record)
+		}
+	}
+	return selected
+}
+
+// GetMatching lists the records of zone selected by m.
+func (p *Provider) GetMatching(ctx context.Context, zone string, m Matcher) ([]libdns.Record, error) {
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return Filter(records, m), nil
+}
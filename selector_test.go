@@ -0,0 +1,63 @@
+package libdnsrage4
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSelector(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Name: "@", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "www", Type: "AAAA", Data: "2001:db8::1"},
+		libdns.RR{Name: "_acme-challenge.www", Type: "TXT", Data: "token"},
+		libdns.RR{Name: "api.dev", Type: "CNAME", Data: "lb.example.net"},
+	}
+
+	tests := []struct {
+		name     string
+		selector Selector
+		want     int
+	}{
+		{"zero selector matches everything", Selector{}, 5},
+		{"exact apex name", Selector{Name: "@"}, 1},
+		{"exact name", Selector{Name: "www"}, 2},
+		{"glob", Selector{NameGlob: "*.dev"}, 1},
+		{"glob does not match apex", Selector{NameGlob: "_acme-challenge.*"}, 1},
+		{"regexp", Selector{NameRegexp: regexp.MustCompile(`^(@|www)$`)}, 3},
+		{"types", Selector{Types: []string{"AAAA", "CNAME"}}, 2},
+		{"content regexp", Selector{ContentRegexp: regexp.MustCompile(`^192\.0\.2\.`)}, 2},
+		{"content predicate", Selector{Content: func(v string) bool { return strings.Contains(v, "example") }}, 1},
+		{"criteria combine", Selector{Name: "www", Types: []string{"A"}}, 1},
+		{"malformed glob matches nothing", Selector{NameGlob: "["}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter(records, tt.selector); len(got) != tt.want {
+				t.Errorf("expected %d records, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	if err := (Selector{NameGlob: "["}).Validate(); err == nil {
+		t.Error("expected malformed glob to be rejected")
+	}
+}
+
+func TestGetMatching(t *testing.T) {
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "mail.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+
+	records, err := p.GetMatching(context.Background(), "example.com.", Selector{NameGlob: "w*"})
+	if err != nil {
+		t.Fatalf("GetMatching failed: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Name != "www" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
@@ -0,0 +1,78 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// SkipReport collects the records that reads started with
+// WithSkipReport left out because they could not be converted. It is
+// safe for concurrent use, so one report can follow GetAllRecords.
+type SkipReport struct {
+	mu      sync.Mutex
+	skipped []*ConversionError
+}
+
+// Skipped returns the records skipped so far, in the order they were
+// read.
+func (r *SkipReport) Skipped() []*ConversionError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.skipped)
+}
+
+func (r *SkipReport) add(err *ConversionError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skipped = append(r.skipped, err)
+}
+
+type skipReportKey struct{}
+
+// WithSkipReport returns a context whose record reads, such as
+// GetRecords, GetAllRecords and the reads behind Plan, Import and
+// Snapshot, skip records that fail to convert and add them to report,
+// instead of failing the whole zone because of one exotic record. For
+// example:
+//
+//	var report libdnsrage4.SkipReport
+//	records, err := p.GetRecords(libdnsrage4.WithSkipReport(ctx, &report), zone)
+//
+// Writes never skip records, since acting on a partial view of a zone
+// could change more than intended.
+func WithSkipReport(ctx context.Context, report *SkipReport) context.Context {
+	return context.WithValue(ctx, skipReportKey{}, report)
+}
+
+// skipReportFrom returns the report set with WithSkipReport, or nil
+func skipReportFrom(ctx context.Context) *SkipReport {
+	report, _ := ctx.Value(skipReportKey{}).(*SkipReport)
+	return report
+}
+
+// readRecords converts the records of a zone read for the caller,
+// skipping those that fail to convert if ctx carries a SkipReport
+func readRecords(ctx context.Context, result []Rage4Record, zoneName string) ([]libdns.Record, error) {
+	report := skipReportFrom(ctx)
+	if report == nil {
+		return convertRecords(result, zoneName)
+	}
+
+	var records []libdns.Record
+	for _, record := range result {
+		converted, err := fromRage4(record, zoneName)
+		var ce *ConversionError
+		if errors.As(err, &ce) {
+			report.add(ce)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, converted)
+	}
+	return records, nil
+}
@@ -0,0 +1,48 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSkipReport(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "a.example.", "b.example.")
+	f.addRecord(1, Rage4Record{Name: "www.a.example", Type: "A", Content: "192.0.2.1", TTL: 300})
+	bad := f.addRecord(1, Rage4Record{Name: "a.example", Type: "MX", Content: "ten mail.a.example", TTL: 300})
+	f.addRecord(2, Rage4Record{Name: "b.example", Type: "SRV", Content: "5060", TTL: 300})
+
+	if _, err := p.GetRecords(ctx, "a.example."); err == nil {
+		t.Fatal("expected the malformed record to fail the read without a report")
+	}
+
+	var report SkipReport
+	records, err := p.GetRecords(WithSkipReport(ctx, &report), "a.example.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Name != "www" {
+		t.Fatalf("expected the valid record only, got %+v", records)
+	}
+	if skipped := report.Skipped(); len(skipped) != 1 || skipped[0].ID != bad || skipped[0].Zone != "a.example" {
+		t.Fatalf("expected the malformed record to be reported, got %+v", skipped)
+	}
+
+	report = SkipReport{}
+	all, err := p.GetAllRecords(WithSkipReport(ctx, &report), []string{"a.example.", "b.example."})
+	if err != nil {
+		t.Fatalf("GetAllRecords failed: %v", err)
+	}
+	if len(all["a.example."]) != 1 || len(all["b.example."]) != 0 {
+		t.Fatalf("unexpected records %+v", all)
+	}
+	skipped := report.Skipped()
+	if len(skipped) != 2 {
+		t.Fatalf("expected both malformed records to be reported, got %+v", skipped)
+	}
+	for _, s := range skipped {
+		if s.Err == nil || s.Field != "content" {
+			t.Errorf("unexpected report entry %+v", s)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Snapshot is the state of a zone at a point in time.
+type Snapshot struct {
+	Zone    string    `json:"zone"`
+	Taken   time.Time `json:"taken"`
+	Records Records   `json:"records"`
+}
+
+// Snapshot captures the current records of zone.
+func (p *Provider) Snapshot(ctx context.Context, zone string) (*Snapshot, error) {
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		Zone:    zone,
+		Taken:   time.Now().UTC(),
+		Records: records,
+	}, nil
+}
+
+// RecordChange describes a record that exists in both snapshots of a
+// diff but whose content differs.
+type RecordChange struct {
+	Before libdns.Record `json:"before"`
+	After  libdns.Record `json:"after"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding the records as in
+// Records.
+func (c RecordChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Before jsonRecord `json:"before"`
+		After  jsonRecord `json:"after"`
+	}{newJSONRecord(c.Before), newJSONRecord(c.After)})
+}
+
+// ZoneDiff is the difference between two snapshots of a zone.
+type ZoneDiff struct {
+	Zone    string         `json:"zone"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Added   Records        `json:"added,omitempty"`
+	Removed Records        `json:"removed,omitempty"`
+	Changed []RecordChange `json:"changed,omitempty"`
+}
+
+// Diff compares two snapshots of the same zone. Records are paired by
+// their Rage4 ID first, so in-place updates show up as changes; records
+// without a matching ID are paired by name, type, and data, so that TTL
+// adjustments are also reported as changes. Everything else
+// is reported as added or removed.
+func Diff(from, to *Snapshot) *ZoneDiff {
+	d := &ZoneDiff{Zone: to.Zone, From: from.Taken, To: to.Taken}
+
+	pairedFrom := make([]bool, len(from.Records))
+	pairedTo := make([]bool, len(to.Records))
+	pair := func(match func(a, b libdns.Record) bool) {
+		for i, a := range from.Records {
+			if pairedFrom[i] {
+				continue
+			}
+			for j, b := range to.Records {
+				if pairedTo[j] || !match(a, b) {
+					continue
+				}
+				pairedFrom[i], pairedTo[j] = true, true
+				if !recordsEqual(a, b) {
+					d.Changed = append(d.Changed, RecordChange{Before: a, After: b})
+				}
+				break
+			}
+		}
+	}
+
+	pair(func(a, b libdns.Record) bool {
+		return recordID(a) != 0 && recordID(a) == recordID(b)
+	})
+	pair(func(a, b libdns.Record) bool {
+		ra, rb := a.RR(), b.RR()
+		return normalizeName(ra.Name) == normalizeName(rb.Name) && ra.Type == rb.Type && ra.Data == rb.Data
+	})
+
+	for i, r := range from.Records {
+		if !pairedFrom[i] {
+			d.Removed = append(d.Removed, r)
+		}
+	}
+	for j, r := range to.Records {
+		if !pairedTo[j] {
+			d.Added = append(d.Added, r)
+		}
+	}
+
+	return d
+}
+
+// Empty reports whether the snapshots were identical.
+func (d *ZoneDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String renders the diff in a unified-diff-like format, one record per
+// line, prefixed with "+" for additions, "-" for removals and "~" for
+// changes.
+func (d *ZoneDiff) String() string {
+	var lines []string
+	for _, r := range d.Removed {
+		lines = append(lines, "- "+formatRecord(r))
+	}
+	for _, r := range d.Added {
+		lines = append(lines, "+ "+formatRecord(r))
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, "~ "+formatRecord(c.Before)+" => "+formatRecord(c.After))
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i][2:] < lines[j][2:]
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s %s\n", d.Zone, d.From.Format(time.RFC3339))
+	fmt.Fprintf(&b, "+++ %s %s\n", d.Zone, d.To.Format(time.RFC3339))
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// recordsEqual reports whether two records have identical content,
+// ignoring their IDs
+func recordsEqual(a, b libdns.Record) bool {
+	ra, rb := a.RR(), b.RR()
+	ra.Name, rb.Name = normalizeName(ra.Name), normalizeName(rb.Name)
+	return ra == rb
+}
+
+// formatRecord renders a record in a compact zone-file-like form
+func formatRecord(record libdns.Record) string {
+	r := record.RR()
+	return strings.Join([]string{normalizeName(r.Name), fmt.Sprint(int(r.TTL.Seconds())), r.Type, r.Data}, " ")
+}
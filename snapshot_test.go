@@ -0,0 +1,70 @@
+package libdnsrage4
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestDiff(t *testing.T) {
+	from := &Snapshot{
+		Zone:  "example.com.",
+		Taken: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Records: []libdns.Record{
+			recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour}),
+			recordWithID(2, libdns.RR{Name: "old", Type: "A", Data: "192.0.2.2", TTL: time.Hour}),
+			recordWithID(3, libdns.RR{Name: "@", Type: "MX", Data: "10 mail.example.com", TTL: time.Hour}),
+			recordWithID(4, libdns.RR{Name: "same", Type: "TXT", Data: "unchanged", TTL: time.Hour}),
+		},
+	}
+	to := &Snapshot{
+		Zone:  "example.com.",
+		Taken: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Records: []libdns.Record{
+			// updated in place
+			recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.9", TTL: time.Hour}),
+			// recreated with a new ID and a lower TTL
+			recordWithID(30, libdns.RR{Name: "@", Type: "MX", Data: "10 mail.example.com", TTL: time.Minute}),
+			recordWithID(4, libdns.RR{Name: "same", Type: "TXT", Data: "unchanged", TTL: time.Hour}),
+			recordWithID(5, libdns.RR{Name: "new", Type: "AAAA", Data: "2001:db8::1", TTL: time.Hour}),
+		},
+	}
+
+	d := Diff(from, to)
+
+	if len(d.Removed) != 1 || recordID(d.Removed[0]) != 2 {
+		t.Errorf("unexpected removals: %+v", d.Removed)
+	}
+	if len(d.Added) != 1 || recordID(d.Added[0]) != 5 {
+		t.Errorf("unexpected additions: %+v", d.Added)
+	}
+	if len(d.Changed) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", d.Changed)
+	}
+	if d.Changed[0].After.RR().Data != "192.0.2.9" || d.Changed[1].After.RR().TTL != time.Minute {
+		t.Errorf("unexpected changes: %+v", d.Changed)
+	}
+
+	expected := `--- example.com. 2024-01-01T00:00:00Z
++++ example.com. 2024-01-02T00:00:00Z
+~ @ 3600 MX 10 mail.example.com => @ 60 MX 10 mail.example.com
++ new 3600 AAAA 2001:db8::1
+- old 3600 A 192.0.2.2
+~ www 3600 A 192.0.2.1 => www 3600 A 192.0.2.9
+`
+	if d.String() != expected {
+		t.Errorf("unexpected rendering:\n%s\nwant:\n%s", d.String(), expected)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	s := &Snapshot{
+		Zone:    "example.com.",
+		Records: []libdns.Record{recordWithID(1, libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"})},
+	}
+
+	if d := Diff(s, s); !d.Empty() {
+		t.Errorf("expected empty diff, got %+v", d)
+	}
+}
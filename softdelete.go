@@ -0,0 +1,198 @@
+package libdnsrage4
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrUndoExpired is returned by Undo once the undo window of a deletion
+// has passed.
+var ErrUndoExpired = errors.New("undo window has passed")
+
+// defaultUndoWindow is the undo window when UndoWindow is not set
+const defaultUndoWindow = 15 * time.Minute
+
+// undoPrefix is the Store prefix of soft deletions
+const undoPrefix = "undo/"
+
+// SoftDelete is a deletion that can still be reversed with Undo.
+type SoftDelete struct {
+	Token   string    `json:"token"`
+	Zone    string    `json:"zone"`
+	Deleted time.Time `json:"deleted"`
+	Expires time.Time `json:"expires"`
+	Records Records   `json:"records"`
+}
+
+// softDelete saves the records about to be deleted by DeleteRecords to
+// UndoStore, in full, since callers may identify records by ID alone.
+// It returns the raw records of the zone for the deletion to reuse.
+func (p *Provider) softDelete(ctx context.Context, domainID int64, zone string, records []libdns.Record) ([]Rage4Record, error) {
+	zoneName := strings.TrimSuffix(zone, ".")
+	raw, err := p.getRage4Records(ctx, domainID, zoneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing records: %w", err)
+	}
+	// pick the records the way deleteRecords does, so that records
+	// matched by name and type alone are saved too
+	remaining := raw
+	saved := make(map[int64]bool)
+	var deleted []libdns.Record
+	for _, record := range records {
+		var found Rage4Record
+		if id := recordID(record); id != 0 {
+			if i := slices.IndexFunc(raw, func(r Rage4Record) bool { return r.ID == id }); i >= 0 {
+				found = raw[i]
+			}
+		} else {
+			found, remaining = findRecord(remaining, zoneName, record)
+		}
+		if found.ID == 0 || saved[found.ID] {
+			continue
+		}
+		converted, err := fromRage4(found, zoneName)
+		if err != nil {
+			return nil, err
+		}
+		saved[found.ID] = true
+		deleted = append(deleted, converted)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate undo token: %w", err)
+	}
+
+	p.mu.RLock()
+	window := p.UndoWindow
+	p.mu.RUnlock()
+	if window <= 0 {
+		window = defaultUndoWindow
+	}
+	now := time.Now().UTC()
+	sd := SoftDelete{
+		Token:   hex.EncodeToString(b),
+		Zone:    zoneName + ".",
+		Deleted: now,
+		Expires: now.Add(window),
+		Records: deleted,
+	}
+
+	data, err := json.Marshal(sd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode deleted records: %w", err)
+	}
+	if err := p.UndoStore.Put(ctx, undoPrefix+sd.Token, data); err != nil {
+		return nil, fmt.Errorf("failed to save deleted records: %w", err)
+	}
+
+	return raw, nil
+}
+
+// SoftDeletes returns the deletions that can still be undone, newest
+// first. Expired ones are removed from UndoStore.
+func (p *Provider) SoftDeletes(ctx context.Context) ([]SoftDelete, error) {
+	if p.UndoStore == nil {
+		return nil, nil
+	}
+
+	keys, err := p.UndoStore.List(ctx, undoPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletions: %w", err)
+	}
+
+	var pending []SoftDelete
+	for _, key := range keys {
+		sd, err := p.loadSoftDelete(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if time.Now().After(sd.Expires) {
+			if err := p.UndoStore.Delete(ctx, key); err != nil {
+				return nil, fmt.Errorf("failed to remove expired deletion: %w", err)
+			}
+			continue
+		}
+		pending = append(pending, *sd)
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Deleted.After(pending[j].Deleted) })
+	return pending, nil
+}
+
+// Undo recreates the records removed by the deletion with the given
+// token, as listed by SoftDeletes. Records that exist again are left
+// alone, so a partially failed deletion is undone correctly. Recreated
+// records get new IDs but keep their Rage4-specific settings. It returns
+// the recreated records.
+func (p *Provider) Undo(ctx context.Context, token string) (_ []libdns.Record, err error) {
+	if p.UndoStore == nil {
+		return nil, fmt.Errorf("soft delete is not enabled")
+	}
+
+	key := undoPrefix + token
+	sd, err := p.loadSoftDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(sd.Expires) {
+		return nil, fmt.Errorf("%w: deletion %s expired at %s", ErrUndoExpired, token, sd.Expires.Format(time.RFC3339))
+	}
+
+	ctx, done, err := p.beginOp(ctx, "Undo", sd.Zone)
+	if err != nil {
+		return nil, err
+	}
+	defer done(&err)
+
+	existing, err := p.GetRecords(ctx, sd.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []libdns.Record
+	for _, r := range sd.Records {
+		if !containsRecord(existing, r) {
+			missing = append(missing, withoutID(r))
+		}
+	}
+
+	var restored []libdns.Record
+	if len(missing) > 0 {
+		if restored, err = p.AppendRecords(ctx, sd.Zone, missing); err != nil {
+			return nil, fmt.Errorf("failed to restore records: %w", err)
+		}
+	}
+
+	if err := p.UndoStore.Delete(ctx, key); err != nil {
+		return restored, fmt.Errorf("failed to remove undone deletion: %w", err)
+	}
+	return restored, nil
+}
+
+// loadSoftDelete reads a deletion from UndoStore
+func (p *Provider) loadSoftDelete(ctx context.Context, key string) (*SoftDelete, error) {
+	data, err := p.UndoStore.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deletion: %w", err)
+	}
+
+	var sd SoftDelete
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return nil, fmt.Errorf("failed to decode deletion %s: %w", key, err)
+	}
+	return &sd, nil
+}
@@ -0,0 +1,120 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSoftDeleteUndo(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.UndoStore = &MemoryStore{}
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+	f.addRecord(1, Rage4Record{Name: "@", Type: "MX", Content: "mail.example.com", TTL: 3600, Priority: 10})
+
+	// deleting by ID alone still preserves the full record
+	_, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.Address{Name: "www", ProviderData: Rage4Record{ID: id}}})
+	if err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if n := len(f.domainRecords(1)); n != 1 {
+		t.Fatalf("expected the record to be deleted, %d left", n)
+	}
+
+	pending, err := p.SoftDeletes(ctx)
+	if err != nil {
+		t.Fatalf("SoftDeletes failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Zone != "example.com." || len(pending[0].Records) != 1 ||
+		pending[0].Records[0].RR().Data != "192.0.2.1" || pending[0].Records[0].RR().TTL != 300*time.Second {
+		t.Fatalf("unexpected pending deletions: %+v", pending)
+	}
+
+	restored, err := p.Undo(ctx, pending[0].Token)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if len(restored) != 1 {
+		t.Errorf("expected one record to be restored, got %+v", restored)
+	}
+	records := f.domainRecords(1)
+	if len(records) != 2 || records[1].Name != "www.example.com" || records[1].Content != "192.0.2.1" || records[1].TTL != 300 {
+		t.Errorf("unexpected records after undo: %+v", records)
+	}
+
+	if _, err := p.Undo(ctx, pending[0].Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a deletion to be undone only once, got %v", err)
+	}
+}
+
+func TestSoftDeleteUndoByNameAndType(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.UndoStore = &MemoryStore{}
+	description := "web"
+	f.addRecord(1, Rage4Record{
+		Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300,
+		GeoRegionID: 7, Description: &description, Weight: 5,
+	})
+
+	// an empty Data and TTL match any record of the name and type
+	if _, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A"}}); err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+	if n := len(f.domainRecords(1)); n != 0 {
+		t.Fatalf("expected the record to be deleted, %d left", n)
+	}
+
+	pending, err := p.SoftDeletes(ctx)
+	if err != nil {
+		t.Fatalf("SoftDeletes failed: %v", err)
+	}
+	if len(pending) != 1 || len(pending[0].Records) != 1 {
+		t.Fatalf("expected the deleted record to be saved, got %+v", pending)
+	}
+	if _, err := p.Undo(ctx, pending[0].Token); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	records := f.domainRecords(1)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record after undo, got %+v", records)
+	}
+	r := records[0]
+	if r.Content != "192.0.2.1" || r.TTL != 300 || r.GeoRegionID != 7 || r.Description == nil || *r.Description != "web" || r.Weight != 5 {
+		t.Errorf("record not restored with its settings: %+v", r)
+	}
+}
+
+func TestSoftDeleteExpires(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.UndoStore = &MemoryStore{}
+	p.UndoWindow = time.Nanosecond
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+
+	_, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}})
+	if err != nil {
+		t.Fatalf("DeleteRecords failed: %v", err)
+	}
+
+	keys, _ := p.UndoStore.List(ctx, undoPrefix)
+	if len(keys) != 1 {
+		t.Fatalf("expected one saved deletion, got %v", keys)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := p.Undo(ctx, keys[0][len(undoPrefix):]); !errors.Is(err, ErrUndoExpired) {
+		t.Errorf("expected ErrUndoExpired, got %v", err)
+	}
+	if pending, err := p.SoftDeletes(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("expected no pending deletions, got %+v, %v", pending, err)
+	}
+	if keys, _ := p.UndoStore.List(ctx, undoPrefix); len(keys) != 0 {
+		t.Errorf("expected expired deletions to be pruned, got %v", keys)
+	}
+}
@@ -0,0 +1,109 @@
+package libdnsrage4
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// OperationStats counts the outcomes of provider operations.
+type OperationStats struct {
+	Operations  int64     `json:"operations"`
+	Errors      int64     `json:"errors"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   time.Time `json:"last_error,omitzero"`
+
+	// LastErrorMessage is the message of the most recent error
+	LastErrorMessage string `json:"last_error_message,omitempty"`
+}
+
+// ErrorRate returns the fraction of operations that failed.
+func (s OperationStats) ErrorRate() float64 {
+	if s.Operations == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Operations)
+}
+
+// record counts the outcome of one operation
+func (s *OperationStats) record(now time.Time, err error) {
+	s.Operations++
+	if err != nil {
+		s.Errors++
+		s.LastError = now
+		s.LastErrorMessage = err.Error()
+	} else {
+		s.LastSuccess = now
+	}
+}
+
+// Stats are the operation statistics of a provider since it was
+// created. Operations made on behalf of another one, such as the
+// deletions of an Apply, are counted as part of it.
+type Stats struct {
+	// Total covers every operation
+	Total OperationStats `json:"total"`
+
+	// Zones covers the operations on each zone, keyed by zone name with
+	// a trailing dot. Account-wide operations are only in Total.
+	Zones map[string]OperationStats `json:"zones"`
+
+	// Operations covers each kind of operation, such as "SetRecords"
+	Operations map[string]OperationStats `json:"operations"`
+}
+
+// Stats returns a copy of the provider's operation statistics, for
+// dashboards that track the health of many zones.
+func (p *Provider) Stats() Stats {
+	return p.stats.snapshot()
+}
+
+// statsRecorder accumulates Stats. The zero value is ready to use.
+type statsRecorder struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// record counts the outcome of an operation
+func (r *statsRecorder) record(name, zone string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stats.Zones == nil {
+		r.stats.Zones = make(map[string]OperationStats)
+		r.stats.Operations = make(map[string]OperationStats)
+	}
+
+	now := time.Now().UTC()
+	r.stats.Total.record(now, err)
+
+	byName := r.stats.Operations[name]
+	byName.record(now, err)
+	r.stats.Operations[name] = byName
+
+	if zone != "" {
+		zone = strings.TrimSuffix(zone, ".") + "."
+		byZone := r.stats.Zones[zone]
+		byZone.record(now, err)
+		r.stats.Zones[zone] = byZone
+	}
+}
+
+// snapshot returns a copy of the statistics
+func (r *statsRecorder) snapshot() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Stats{
+		Total:      r.stats.Total,
+		Zones:      make(map[string]OperationStats, len(r.stats.Zones)),
+		Operations: make(map[string]OperationStats, len(r.stats.Operations)),
+	}
+	for zone, stats := range r.stats.Zones {
+		s.Zones[zone] = stats
+	}
+	for name, stats := range r.stats.Operations {
+		s.Operations[name] = stats
+	}
+	return s
+}
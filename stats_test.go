@@ -0,0 +1,41 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+
+	p.GetRecords(ctx, "example.com.")
+	p.GetRecords(ctx, "example.com")
+	p.GetRecords(ctx, "missing.example.")
+	p.Apply(ctx, &ChangeSet{
+		Zone:   "example.com.",
+		Create: []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}},
+	})
+
+	stats := p.Stats()
+
+	if stats.Total.Operations != 4 || stats.Total.Errors != 1 {
+		t.Errorf("unexpected totals: %+v", stats.Total)
+	}
+
+	zone := stats.Zones["example.com."]
+	if zone.Operations != 3 || zone.Errors != 0 || zone.LastSuccess.IsZero() {
+		t.Errorf("unexpected stats for example.com.: %+v", zone)
+	}
+	missing := stats.Zones["missing.example."]
+	if missing.ErrorRate() != 1 || missing.LastErrorMessage == "" {
+		t.Errorf("unexpected stats for missing.example.: %+v", missing)
+	}
+
+	// the AppendRecords inside Apply is part of the Apply
+	if stats.Operations["Apply"].Operations != 1 || stats.Operations["AppendRecords"].Operations != 0 {
+		t.Errorf("unexpected per-operation stats: %+v", stats.Operations)
+	}
+}
@@ -0,0 +1,59 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]Store{
+		"memory": &MemoryStore{},
+		"file":   &FileStore{Dir: t.TempDir() + "/store"},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+			if keys, err := store.List(ctx, ""); err != nil || len(keys) != 0 {
+				t.Errorf("expected empty store, got %v, %v", keys, err)
+			}
+
+			for _, key := range []string{"b/2", "a/1", "b/1", "..", "c"} {
+				if err := store.Put(ctx, key, []byte(key)); err != nil {
+					t.Fatalf("put %q failed: %v", key, err)
+				}
+			}
+			if err := store.Put(ctx, "c", []byte("replaced")); err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := store.Get(ctx, "c")
+			if err != nil || string(value) != "replaced" {
+				t.Errorf("unexpected value: %q, %v", value, err)
+			}
+
+			keys, err := store.List(ctx, "b/")
+			if err != nil || !slices.Equal(keys, []string{"b/1", "b/2"}) {
+				t.Errorf("unexpected keys: %v, %v", keys, err)
+			}
+
+			if err := store.Delete(ctx, "b/1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, "b/1"); err != nil {
+				t.Errorf("deleting a missing key must not fail: %v", err)
+			}
+
+			keys, err = store.List(ctx, "")
+			if err != nil || !slices.Equal(keys, []string{"..", "a/1", "b/2", "c"}) {
+				t.Errorf("unexpected keys: %v, %v", keys, err)
+			}
+		})
+	}
+}
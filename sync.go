@@ -0,0 +1,128 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// zoneFileSuffix is the extension of the zone files read by SyncDir
+const zoneFileSuffix = ".zone"
+
+// SyncOptions controls SyncDir.
+type SyncOptions struct {
+	// DryRun only plans the changes, for example to show them on a pull
+	// request before it is merged
+	DryRun bool
+
+	// Prune also deletes the record sets that are not in the zone file,
+	// making the file the complete definition of the zone. Records
+	// managed by Rage4, such as the SOA and apex NS records, are kept.
+	Prune bool
+}
+
+// SyncResult describes the sync of one zone by SyncDir.
+type SyncResult struct {
+	Zone string `json:"zone"`
+
+	// File is the zone file the zone was read from
+	File string `json:"file"`
+
+	// Changes are the changes planned for the zone, and applied unless
+	// DryRun is set or Err is not nil
+	Changes *ChangeSet `json:"changes,omitempty"`
+
+	// Skipped are the records in the file that cannot be represented
+	Skipped []SkippedRecord `json:"skipped,omitempty"`
+
+	// Applied reports whether Changes were applied
+	Applied bool `json:"applied"`
+
+	Err error `json:"-"`
+}
+
+// SyncDir makes the zones in the account match the zone files in dir,
+// such as the checkout of a repository holding the DNS configuration.
+// Every file named <zone>.zone, in BIND format, defines the zone it is
+// named after; other files are ignored. Each zone is planned and, unless
+// opts.DryRun is set, applied on its own, so a zone that fails does not
+// hold up the others: the results of all zones are returned, in file
+// name order, along with their errors joined. Syncs run with
+// PriorityBackground unless ctx carries a priority.
+func (p *Provider) SyncDir(ctx context.Context, dir string, opts SyncOptions) ([]SyncResult, error) {
+	ctx = background(ctx)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), zoneFileSuffix) {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var (
+		results []SyncResult
+		errs    []error
+	)
+	for _, file := range files {
+		result := SyncResult{
+			Zone: strings.TrimSuffix(file, zoneFileSuffix) + ".",
+			File: filepath.Join(dir, file),
+		}
+		if result.Err = p.syncZone(ctx, &result, opts); result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Zone, result.Err))
+		}
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// syncZone plans and applies the zone file of result, filling result in
+// as it goes
+func (p *Provider) syncZone(ctx context.Context, result *SyncResult, opts SyncOptions) error {
+	f, err := os.Open(result.File)
+	if err != nil {
+		return fmt.Errorf("failed to open zone file: %w", err)
+	}
+	defer f.Close()
+
+	parsed, err := ParseBIND(f, result.Zone)
+	if err != nil {
+		return err
+	}
+	result.Skipped = parsed.Skipped
+
+	if result.Changes, err = p.plan(ctx, result.Zone, parsed.Records, opts.Prune); err != nil {
+		return err
+	}
+	if opts.DryRun || result.Changes.Empty() {
+		return nil
+	}
+
+	if err := p.Apply(ctx, result.Changes); err != nil {
+		return err
+	}
+	result.Applied = true
+	return nil
+}
+
+// managedByRage4 reports whether record is maintained by Rage4 itself
+// and must not be pruned
+func managedByRage4(record libdns.Record) bool {
+	if data, ok := rage4Data(record); ok && data.IsSystem {
+		return true
+	}
+	rr := record.RR()
+	return rr.Type == "SOA" || (rr.Type == "NS" && normalizeName(rr.Name) == "@")
+}
@@ -0,0 +1,78 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZoneFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSyncDir(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.", "example.org.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "NS", Content: "ns1.r4ns.com", TTL: 86400, IsSystem: true})
+	f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "old.example.com", Type: "A", Content: "192.0.2.2", TTL: 3600})
+
+	dir := writeZoneFiles(t, map[string]string{
+		"example.com.zone": "$ORIGIN example.com.\n" +
+			"@ 3600 IN SOA ns1.r4ns.com. hostmaster.example.com. 1 3600 600 604800 3600\n" +
+			"www 3600 IN A 192.0.2.9\n",
+		"example.org.zone": "$ORIGIN example.org.\n@ 3600 IN TXT \"hello\"\n",
+		"example.net.zone": "$ORIGIN example.net.\n@ 3600 IN A 192.0.2.1\n",
+		"README.md":        "not a zone",
+	})
+
+	results, err := p.SyncDir(ctx, dir, SyncOptions{DryRun: true, Prune: true})
+	if err == nil || len(results) != 3 {
+		t.Fatalf("expected the unknown zone to fail alone, got %+v, %v", results, err)
+	}
+	if results[0].Zone != "example.com." || len(results[0].Changes.Delete) != 2 || len(results[0].Changes.Create) != 1 ||
+		len(results[0].Skipped) != 1 || results[0].Applied {
+		t.Errorf("unexpected plan for example.com.: %+v", results[0])
+	}
+	if results[1].Zone != "example.net." || results[1].Err == nil {
+		t.Errorf("expected example.net. to fail, got %+v", results[1])
+	}
+	if f.calls("CreateRecord") != 0 || f.calls("DeleteRecord") != 0 {
+		t.Fatalf("expected a dry run to change nothing, got %v", f.requests)
+	}
+
+	os.Remove(filepath.Join(dir, "example.net.zone"))
+	results, err = p.SyncDir(ctx, dir, SyncOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("SyncDir failed: %v", err)
+	}
+	if !results[0].Applied || !results[1].Applied {
+		t.Errorf("expected both zones to be applied, got %+v", results)
+	}
+	com := f.domainRecords(1)
+	if len(com) != 2 || !com[0].IsSystem || com[1].Name != "www.example.com" || com[1].Content != "192.0.2.9" {
+		t.Errorf("unexpected records in example.com.: %+v", com)
+	}
+	if org := f.domainRecords(2); len(org) != 1 || org[0].Content != "hello" {
+		t.Errorf("unexpected records in example.org.: %+v", org)
+	}
+
+	results, err = p.SyncDir(ctx, dir, SyncOptions{Prune: true})
+	if err != nil || results[0].Applied || !results[0].Changes.Empty() {
+		t.Errorf("expected syncing again to change nothing, got %+v, %v", results, err)
+	}
+
+	if _, err := p.SyncDir(ctx, filepath.Join(dir, "missing"), SyncOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing directory to fail, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Template is a parameterized zone layout. The names and data of its
+// records may reference parameters as ${name}; the parameter "zone" is
+// always available and holds the zone name without a trailing dot.
+type Template struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Params      []string    `json:"params,omitempty"`
+	Records     []libdns.RR `json:"records"`
+}
+
+// Built-in templates for common zone layouts.
+var (
+	// TemplateStaticSite points the apex at a web server and www at the
+	// apex. Parameters: ipv4.
+	TemplateStaticSite = &Template{
+		Name:        "static-site",
+		Description: "Apex A record with www aliased to it",
+		Params:      []string{"ipv4"},
+		Records: []libdns.RR{
+			{Name: "@", Type: "A", Data: "${ipv4}", TTL: time.Hour},
+			{Name: "www", Type: "CNAME", Data: "${zone}", TTL: time.Hour},
+		},
+	}
+
+	// TemplateSaaSCustomer points a customer's domain at a SaaS
+	// platform. Parameters: ipv4 (the platform's apex address) and
+	// target (the platform host name).
+	TemplateSaaSCustomer = &Template{
+		Name:        "saas-customer",
+		Description: "Apex and www pointing at a hosted platform",
+		Params:      []string{"ipv4", "target"},
+		Records: []libdns.RR{
+			{Name: "@", Type: "A", Data: "${ipv4}", TTL: 5 * time.Minute},
+			{Name: "www", Type: "CNAME", Data: "${target}", TTL: 5 * time.Minute},
+		},
+	}
+
+	// TemplateMailOnly sets up a domain that only receives mail.
+	// Parameters: mx (the mail exchanger) and dmarc_rua (the address
+	// receiving DMARC reports).
+	TemplateMailOnly = &Template{
+		Name:        "mail-only",
+		Description: "MX with SPF and DMARC, no web presence",
+		Params:      []string{"mx", "dmarc_rua"},
+		Records: []libdns.RR{
+			{Name: "@", Type: "MX", Data: "10 ${mx}", TTL: time.Hour},
+			{Name: "@", Type: "TXT", Data: "v=spf1 mx -all", TTL: time.Hour},
+			{Name: "_dmarc", Type: "TXT", Data: "v=DMARC1; p=reject; rua=mailto:${dmarc_rua}", TTL: time.Hour},
+		},
+	}
+)
+
+// Render returns the records of the template for zone with the given
+// parameters. Every declared parameter must be given, and every
+// referenced parameter must be declared.
+func (t *Template) Render(zone string, params map[string]string) ([]libdns.Record, error) {
+	for _, name := range t.Params {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("template %s: missing parameter %q", t.Name, name)
+		}
+	}
+
+	var undeclared []string
+	expand := func(s string) string {
+		return os.Expand(s, func(name string) string {
+			if name == "zone" {
+				return strings.TrimSuffix(zone, ".")
+			}
+			if !slices.Contains(t.Params, name) {
+				undeclared = append(undeclared, name)
+				return ""
+			}
+			return params[name]
+		})
+	}
+
+	rendered := make([]libdns.RR, len(t.Records))
+	for i, rr := range t.Records {
+		rr.Name = expand(rr.Name)
+		rr.Data = expand(rr.Data)
+		rendered[i] = rr
+	}
+
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("template %s: undeclared parameters %q", t.Name, undeclared)
+	}
+
+	records := make([]libdns.Record, len(rendered))
+	for i, rr := range rendered {
+		record, err := rr.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("template %s: invalid record %s %s: %w", t.Name, rr.Name, rr.Type, err)
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// ApplyTemplate instantiates a template in zone. Only the record sets
+// the template defines are touched, and applying the same template with
+// the same parameters again changes nothing, so it is safe to run
+// repeatedly. It returns the change set that was applied.
+func (p *Provider) ApplyTemplate(ctx context.Context, zone string, t *Template, params map[string]string) (*ChangeSet, error) {
+	records, err := t.Render(zone, params)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := p.Plan(ctx, zone, records)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Apply(ctx, cs); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
@@ -0,0 +1,59 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplateRender(t *testing.T) {
+	records, err := TemplateMailOnly.Render("example.com.", map[string]string{
+		"mx":        "mx.example.net",
+		"dmarc_rua": "dmarc@example.net",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if records[0].RR().Data != "10 mx.example.net" || records[2].RR().Data != "v=DMARC1; p=reject; rua=mailto:dmarc@example.net" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	// the template itself is left untouched
+	if TemplateMailOnly.Records[0].Data != "10 ${mx}" {
+		t.Error("rendering must not modify the template")
+	}
+
+	if _, err := TemplateMailOnly.Render("example.com.", map[string]string{"mx": "mx.example.net"}); err == nil {
+		t.Error("expected missing parameter to be rejected")
+	}
+
+	broken := &Template{Name: "broken", Records: TemplateStaticSite.Records}
+	if _, err := broken.Render("example.com.", map[string]string{"ipv4": "192.0.2.1"}); err == nil {
+		t.Error("expected undeclared parameter to be rejected")
+	}
+}
+
+func TestApplyTemplateIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	params := map[string]string{"ipv4": "192.0.2.1"}
+
+	cs, err := p.ApplyTemplate(ctx, "example.com.", TemplateStaticSite, params)
+	if err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	if len(cs.Create) != 2 {
+		t.Errorf("expected two records to be created, got %+v", cs)
+	}
+	stored := f.domainRecords(1)
+	if len(stored) != 2 || stored[1].Name != "www.example.com" || stored[1].Content != "example.com" {
+		t.Errorf("unexpected stored records: %+v", stored)
+	}
+
+	cs, err = p.ApplyTemplate(ctx, "example.com.", TemplateStaticSite, params)
+	if err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	if !cs.Empty() || f.calls("CreateRecord") != 2 {
+		t.Errorf("expected applying again to change nothing, got %+v", cs)
+	}
+}
@@ -0,0 +1,144 @@
+package libdnsrage4
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Phases of an operation, as reported in RequestTiming.
+const (
+	// PhaseLookup covers resolving zone names and record types
+	PhaseLookup = "lookup"
+
+	// PhaseRead covers reading the records of a zone
+	PhaseRead = "read"
+
+	// PhaseMutation covers creating and deleting records and zones
+	PhaseMutation = "mutation"
+)
+
+// RequestTiming is the timing of one API request.
+type RequestTiming struct {
+	// Method is the API method, such as "GetRecords"
+	Method string `json:"method"`
+
+	// Phase is PhaseLookup, PhaseRead or PhaseMutation
+	Phase string `json:"phase"`
+
+	Start time.Time `json:"start"`
+
+	// Wait is the time spent before the request was sent, backing off
+	// before a retry and waiting for RequestsPerSecond and a slot under
+	// MaxConcurrentRequests
+	Wait time.Duration `json:"wait"`
+
+	// Duration is the time Rage4 took to respond
+	Duration time.Duration `json:"duration"`
+
+	// StatusCode is zero if no response was received
+	StatusCode int   `json:"status_code,omitempty"`
+	Err        error `json:"-"`
+}
+
+// OperationTiming is the timing breakdown of one provider operation,
+// reported to Provider.OnTiming when it ends.
+type OperationTiming struct {
+	Operation string          `json:"operation"`
+	Zone      string          `json:"zone,omitempty"`
+	Start     time.Time       `json:"start"`
+	Duration  time.Duration   `json:"duration"`
+	Requests  []RequestTiming `json:"requests"`
+	Err       error           `json:"-"`
+}
+
+// Wait returns the total time the operation's requests spent waiting
+// on the provider's own limits.
+func (t OperationTiming) Wait() time.Duration {
+	var d time.Duration
+	for _, r := range t.Requests {
+		d += r.Wait
+	}
+	return d
+}
+
+// Phase returns the total time Rage4 took to answer the operation's
+// requests in the given phase.
+func (t OperationTiming) Phase(phase string) time.Duration {
+	var d time.Duration
+	for _, r := range t.Requests {
+		if r.Phase == phase {
+			d += r.Duration
+		}
+	}
+	return d
+}
+
+// String summarizes the timing in a single line, e.g.
+// "SetRecords example.com. 412ms: lookup 80ms, read 95ms, mutation 230ms, wait 0s"
+func (t OperationTiming) String() string {
+	return fmt.Sprintf("%s %s %s: lookup %s, read %s, mutation %s, wait %s",
+		t.Operation, t.Zone, t.Duration.Round(time.Millisecond),
+		t.Phase(PhaseLookup).Round(time.Millisecond),
+		t.Phase(PhaseRead).Round(time.Millisecond),
+		t.Phase(PhaseMutation).Round(time.Millisecond),
+		t.Wait().Round(time.Millisecond))
+}
+
+// requestPhase returns the phase an API method belongs to
+func requestPhase(method string) string {
+	switch method {
+	case "GetDomains", "GetDomain", "GetDomainByName", "ListRecordTypes", "ListGeoRegions", "ListWebhooks":
+		return PhaseLookup
+	case "GetRecords":
+		return PhaseRead
+	default:
+		return PhaseMutation
+	}
+}
+
+// time records the timing of a request sent on behalf of the operation
+func (op *operation) time(req *http.Request, start time.Time, wait time.Duration, resp *http.Response, err error) {
+	if op.onTiming == nil {
+		return
+	}
+
+	method := strings.TrimPrefix(path.Base(req.URL.Path), "/")
+	rt := RequestTiming{
+		Method:   method,
+		Phase:    requestPhase(method),
+		Start:    start,
+		Wait:     wait,
+		Duration: time.Since(start) - wait,
+		Err:      err,
+	}
+	if resp != nil {
+		rt.StatusCode = resp.StatusCode
+	}
+
+	op.mu.Lock()
+	op.timings = append(op.timings, rt)
+	op.mu.Unlock()
+}
+
+// report passes the timing of the finished operation to OnTiming
+func (op *operation) report(err error) {
+	if op.onTiming == nil {
+		return
+	}
+
+	op.mu.Lock()
+	timing := OperationTiming{
+		Operation: op.name,
+		Zone:      op.zone,
+		Start:     op.start,
+		Duration:  time.Since(op.start),
+		Requests:  op.timings,
+		Err:       err,
+	}
+	op.mu.Unlock()
+
+	op.onTiming(timing)
+}
@@ -0,0 +1,53 @@
+package libdnsrage4
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestOnTiming(t *testing.T) {
+	ctx := context.Background()
+	_, p := newFakeRage4(t, "example.com.")
+
+	var timings []OperationTiming
+	p.OnTiming = func(timing OperationTiming) { timings = append(timings, timing) }
+
+	_, err := p.SetRecords(ctx, "example.com.", []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+
+	if len(timings) != 1 {
+		t.Fatalf("expected one timing per operation, got %+v", timings)
+	}
+	timing := timings[0]
+	if timing.Operation != "SetRecords" || timing.Zone != "example.com." || timing.Err != nil {
+		t.Errorf("unexpected timing: %+v", timing)
+	}
+
+	var methods, phases []string
+	for _, r := range timing.Requests {
+		methods = append(methods, r.Method)
+		phases = append(phases, r.Phase)
+		if r.StatusCode != 200 || r.Duration <= 0 || r.Start.Before(timing.Start) {
+			t.Errorf("unexpected request timing: %+v", r)
+		}
+	}
+	if strings.Join(methods, ",") != "GetDomainByName,GetRecords,CreateRecord" ||
+		strings.Join(phases, ",") != "lookup,read,mutation" {
+		t.Errorf("unexpected requests: %v %v", methods, phases)
+	}
+
+	if timing.Duration < timing.Phase(PhaseLookup)+timing.Phase(PhaseRead)+timing.Phase(PhaseMutation) {
+		t.Errorf("phases exceed the operation: %s", timing)
+	}
+	if !strings.HasPrefix(timing.String(), "SetRecords example.com. ") {
+		t.Errorf("unexpected summary: %s", timing)
+	}
+}
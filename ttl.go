@@ -0,0 +1,85 @@
+package libdnsrage4
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrTTLNotAllowed is returned with StrictTTL for TTLs that are not
+// among AllowedTTLs.
+var ErrTTLNotAllowed = errors.New("TTL not allowed")
+
+// TTL presets for records.
+const (
+	TTLMinute      = time.Minute
+	TTLFiveMinutes = 5 * time.Minute
+	TTLHalfHour    = 30 * time.Minute
+	TTLHour        = time.Hour
+	TTLHalfDay     = 12 * time.Hour
+	TTLDay         = 24 * time.Hour
+)
+
+// SnapTTL returns the TTL that records created with ttl get. A zero TTL
+// means the default of one hour. With AllowedTTLs set, the TTL is
+// snapped to the nearest allowed value, the shorter one on a tie, so
+// that Rage4 does not silently substitute one; with StrictTTL, a TTL
+// that is not allowed fails with ErrTTLNotAllowed instead.
+func (p *Provider) SnapTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	p.mu.RLock()
+	allowed, strict := p.AllowedTTLs, p.StrictTTL
+	p.mu.RUnlock()
+
+	seconds := int(ttl.Seconds())
+	if len(allowed) == 0 || slices.Contains(allowed, seconds) {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if strict {
+		return 0, fmt.Errorf("%w: %s (allowed: %v seconds)", ErrTTLNotAllowed, ttl, allowed)
+	}
+
+	best := allowed[0]
+	for _, a := range allowed[1:] {
+		d, bd := abs(a-seconds), abs(best-seconds)
+		if d < bd || (d == bd && a < best) {
+			best = a
+		}
+	}
+	return time.Duration(best) * time.Second, nil
+}
+
+// snapTTLs returns records with their TTLs snapped, so that they
+// compare equal to the records Rage4 ends up with. Zero TTLs, which
+// match any TTL, are kept.
+func (p *Provider) snapTTLs(records []libdns.Record) ([]libdns.Record, error) {
+	snapped := slices.Clone(records)
+	for i, record := range snapped {
+		rr := record.RR()
+		if rr.TTL == 0 {
+			continue
+		}
+		ttl, err := p.SnapTTL(rr.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record %s %s: %w", rr.Name, rr.Type, err)
+		}
+		if ttl != rr.TTL {
+			snapped[i] = withTTL(record, ttl)
+		}
+	}
+	return snapped, nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSnapTTL(t *testing.T) {
+	p := &Provider{AllowedTTLs: []int{60, 300, 3600, 86400}}
+
+	for _, tc := range []struct {
+		ttl, want time.Duration
+	}{
+		{0, TTLHour},
+		{TTLFiveMinutes, TTLFiveMinutes},
+		{90 * time.Second, TTLMinute},
+		{180 * time.Second, TTLMinute}, // a tie snaps to the shorter TTL
+		{TTLHalfDay, TTLHour},
+		{7 * TTLDay, TTLDay},
+	} {
+		got, err := p.SnapTTL(tc.ttl)
+		if err != nil || got != tc.want {
+			t.Errorf("SnapTTL(%s) = %s, %v; want %s", tc.ttl, got, err, tc.want)
+		}
+	}
+
+	p.StrictTTL = true
+	if _, err := p.SnapTTL(TTLHalfDay); !errors.Is(err, ErrTTLNotAllowed) {
+		t.Errorf("expected ErrTTLNotAllowed, got %v", err)
+	}
+
+	if got, _ := (&Provider{}).SnapTTL(90 * time.Second); got != 90*time.Second {
+		t.Errorf("expected TTLs to be kept without AllowedTTLs, got %s", got)
+	}
+}
+
+func TestSetRecordsSnapsTTLs(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.AllowedTTLs = []int{300, 3600}
+	records := []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 45 * time.Minute}}
+
+	for range 2 {
+		if _, err := p.SetRecords(ctx, "example.com.", records); err != nil {
+			t.Fatalf("SetRecords failed: %v", err)
+		}
+	}
+	if stored := f.domainRecords(1); len(stored) != 1 || stored[0].TTL != 3600 {
+		t.Errorf("unexpected stored records: %+v", stored)
+	}
+	if f.calls("CreateRecord") != 1 || f.calls("UpdateRecord") != 0 {
+		t.Errorf("expected the snapped record to match on the second run, got %v", f.requests)
+	}
+
+	p.StrictTTL = true
+	if _, err := p.SetRecords(ctx, "example.com.", records); !errors.Is(err, ErrTTLNotAllowed) {
+		t.Errorf("expected ErrTTLNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package libdnsrage4
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// UDPLimitOf reports whether a record read from Rage4 or set with
+// WithUDPLimit has the UDP answer limit set.
+func UDPLimitOf(record libdns.Record) bool {
+	data, _ := rage4Data(record)
+	return data.UDPLimit
+}
+
+// WithUDPLimit returns record with Rage4's UDP answer limit set or
+// cleared, keeping its other Rage4 settings, for passing to
+// AppendRecords or SetRecords. With the limit set, Rage4 answers queries
+// over UDP with only as many records of the set as fit in a small
+// response, which keeps large round-robin sets from being truncated.
+// Records of types libdns has no struct for cannot carry settings and
+// are rejected.
+func WithUDPLimit(record libdns.Record, limit bool) (libdns.Record, error) {
+	limited, err := withSetting(record, settingUDPLimit, func(data *Rage4Record) {
+		data.UDPLimit = limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot have a UDP limit: %w", record.RR().Type, err)
+	}
+	return limited, nil
+}
@@ -0,0 +1,54 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestUDPLimit(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	id := f.addRecord(1, Rage4Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600, Weight: 2})
+
+	var desired []libdns.Record
+	for _, data := range []string{"192.0.2.1", "192.0.2.2"} {
+		r, err := WithUDPLimit(libdns.RR{Name: "www", Type: "A", Data: data, TTL: time.Hour}, true)
+		if err != nil {
+			t.Fatalf("WithUDPLimit failed: %v", err)
+		}
+		desired = append(desired, r)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", desired); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	for _, r := range f.domainRecords(1) {
+		if !r.UDPLimit {
+			t.Errorf("expected the UDP limit to be set, got %+v", r)
+		}
+		if r.ID == id && r.Weight != 2 {
+			t.Errorf("expected the updated record to keep its weight, got %+v", r)
+		}
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	if len(records) != 2 || !UDPLimitOf(records[0]) || !UDPLimitOf(records[1]) {
+		t.Errorf("expected the UDP limit to be read back, got %+v", records)
+	}
+
+	cleared, err := WithUDPLimit(records[0], false)
+	if err != nil {
+		t.Fatalf("WithUDPLimit failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{cleared, records[1]}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].UDPLimit || !stored[1].UDPLimit {
+		t.Errorf("expected only the first record's limit to be cleared, got %+v", stored)
+	}
+}
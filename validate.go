@@ -0,0 +1,157 @@
+package libdnsrage4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Violation is a problem a Validator found with a desired record.
+type Violation struct {
+	Record  libdns.Record `json:"record"`
+	Message string        `json:"message"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding the record as in
+// Records.
+func (v Violation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Record  jsonRecord `json:"record"`
+		Message string     `json:"message"`
+	}{newJSONRecord(v.Record), v.Message})
+}
+
+// ValidationError is returned by Validate and Plan when validators
+// reject desired records. It lists every violation found, not just the
+// first, so a CI run reports all problems at once.
+type ValidationError struct {
+	Zone       string      `json:"zone"`
+	Violations []Violation `json:"violations"`
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d violations in %s", len(e.Violations), e.Zone)
+	for _, v := range e.Violations {
+		rr := v.Record.RR()
+		fmt.Fprintf(&b, "; %s %s %s: %s", normalizeName(rr.Name), rr.Type, rr.Data, v.Message)
+	}
+	return b.String()
+}
+
+// Validator checks the records desired for a zone, for example against
+// business rules or an external admission service. It returns the
+// violations found; an error means the check itself could not be done.
+type Validator func(ctx context.Context, zone string, records []libdns.Record) ([]Violation, error)
+
+// Validate runs the configured Validators over the records desired for
+// zone and returns a *ValidationError with all violations, if any. It
+// makes no API requests, so it can gate changes in CI without
+// credentials. Plan calls it before computing changes.
+func (p *Provider) Validate(ctx context.Context, zone string, records []libdns.Record) error {
+	verr := &ValidationError{Zone: zone}
+	for _, validate := range p.Validators {
+		violations, err := validate(ctx, zone, records)
+		if err != nil {
+			return fmt.Errorf("failed to validate records: %w", err)
+		}
+		verr.Violations = append(verr.Violations, violations...)
+	}
+
+	if len(verr.Violations) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// ValidateSchema is a Validator for the basic well-formedness of
+// records: supported types, non-empty values, TTLs in range, addresses
+// of the right family, and CNAMEs that neither sit at the apex nor share
+// their name with other records.
+func ValidateSchema(ctx context.Context, zone string, records []libdns.Record) ([]Violation, error) {
+	var violations []Violation
+
+	types := make(map[string][]string)
+	for _, record := range records {
+		r := record.RR()
+		name := normalizeName(r.Name)
+		types[name] = append(types[name], r.Type)
+	}
+
+	for _, record := range records {
+		r := record.RR()
+		name := normalizeName(r.Name)
+		report := func(format string, args ...any) {
+			violations = append(violations, Violation{Record: record, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if _, ok := converterFor(r.Type); !ok && !slices.Contains(supportedRecordTypes, r.Type) {
+			report("unsupported record type")
+		}
+		if strings.ContainsAny(name, " \t") {
+			report("name contains whitespace")
+		}
+		if r.Data == "" {
+			report("empty value")
+		}
+		if r.TTL < 0 || r.TTL > math.MaxInt32*time.Second {
+			report("TTL out of range")
+		}
+
+		switch r.Type {
+		case "A", "AAAA":
+			addr, err := netip.ParseAddr(r.Data)
+			if err != nil || addr.Is4() != (r.Type == "A") {
+				report("not an IPv%s address", map[string]string{"A": "4", "AAAA": "6"}[r.Type])
+			}
+		case "CNAME":
+			if name == "@" {
+				report("CNAME at the zone apex")
+			} else if len(types[name]) > 1 {
+				report("CNAME alongside other records")
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// ForbidTargets returns a Validator that rejects records whose target
+// (the data of most records, the host name of MX and SRV records)
+// matches any of the given path.Match patterns, such as
+// "*.herokuapp.com" to keep dangling CNAMEs to deprovisioned services
+// out of a zone. Trailing dots and case are ignored.
+func ForbidTargets(patterns ...string) Validator {
+	return func(ctx context.Context, zone string, records []libdns.Record) ([]Violation, error) {
+		var violations []Violation
+		for _, r := range records {
+			target := strings.ToLower(strings.TrimSuffix(recordTarget(r.RR()), "."))
+			for _, pattern := range patterns {
+				if ok, err := path.Match(strings.ToLower(strings.TrimSuffix(pattern, ".")), target); err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+				} else if ok {
+					violations = append(violations, Violation{Record: r, Message: fmt.Sprintf("target matches forbidden pattern %q", pattern)})
+					break
+				}
+			}
+		}
+		return violations, nil
+	}
+}
+
+// recordTarget returns the host name an MX or SRV record points to, and
+// the data of other records
+func recordTarget(rr libdns.RR) string {
+	if fields := strings.Fields(rr.Data); len(fields) > 0 && (rr.Type == "MX" || rr.Type == "SRV") {
+		return fields[len(fields)-1]
+	}
+	return rr.Data
+}
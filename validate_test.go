@@ -0,0 +1,68 @@
+package libdnsrage4
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestValidateSchema(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"},
+		libdns.RR{Name: "v6", Type: "AAAA", Data: "192.0.2.1"},
+		libdns.RR{Name: "@", Type: "CNAME", Data: "example.net"},
+		libdns.RR{Name: "mail", Type: "CNAME", Data: "example.net"},
+		libdns.RR{Name: "mail", Type: "TXT", Data: "v=spf1 -all"},
+		libdns.RR{Name: "odd", Type: "BOGUS", Data: "x"},
+		libdns.RR{Name: "empty", Type: "TXT"},
+	}
+
+	violations, err := ValidateSchema(context.Background(), "example.com.", records)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, v := range violations {
+		got = append(got, normalizeName(v.Record.RR().Name)+": "+v.Message)
+	}
+	expected := []string{
+		"v6: not an IPv6 address",
+		"@: CNAME at the zone apex",
+		"mail: CNAME alongside other records",
+		"odd: unsupported record type",
+		"empty: empty value",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected violations:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestPlanRunsValidators(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.Validators = []Validator{ValidateSchema, ForbidTargets("*.herokuapp.com")}
+
+	_, err := p.Plan(ctx, "example.com.", []libdns.Record{
+		libdns.RR{Name: "app", Type: "CNAME", Data: "old-app.herokuapp.com."},
+		libdns.RR{Name: "www", Type: "A", Data: "not-an-ip"},
+	})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatalf("expected both violations to be reported, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `forbidden pattern "*.herokuapp.com"`) {
+		t.Errorf("unexpected message: %v", err)
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("expected validation to fail before any request, got %v", f.requests)
+	}
+
+	if _, err := p.Plan(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Errorf("expected valid records to pass, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package libdnsrage4
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// DefaultVerificationPrefix is the label under which verification
+// tokens are published when NewVerification is given no prefix.
+const DefaultVerificationPrefix = "_rage4-verification"
+
+// Verification is a domain ownership challenge: whoever controls
+// Domain proves it by publishing Token in a TXT record at Name, relative
+// to Domain.
+type Verification struct {
+	Domain string `json:"domain"`
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+
+	// RecordID is the ID of the TXT record PublishVerification created,
+	// so that RemoveVerification deletes exactly that record
+	RecordID int64 `json:"record_id,omitempty"`
+
+	// Resolver, if set, is used by Check when it is given no lookup, in
+	// place of net.DefaultResolver
+	Resolver Resolver `json:"-"`
+}
+
+// NewVerification returns a challenge for domain with a fresh random
+// token, published under prefix.
+func NewVerification(domain, prefix string) (*Verification, error) {
+	if prefix == "" {
+		prefix = DefaultVerificationPrefix
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &Verification{
+		Domain: strings.TrimSuffix(domain, "."),
+		Name:   prefix,
+		Token:  base64.RawURLEncoding.EncodeToString(b),
+	}, nil
+}
+
+// NewApexVerification returns a challenge that publishes token, such as
+// "google-site-verification=..." or "MS=ms12345678", in a TXT record at
+// the apex of domain, as many services ask for. The apex usually holds
+// other TXT records, such as SPF, which are left alone. Tokens must fit
+// in a single TXT string of 255 bytes.
+func NewApexVerification(domain, token string) (*Verification, error) {
+	if token == "" {
+		return nil, fmt.Errorf("verification token is required")
+	}
+	if len(token) > 255 {
+		return nil, fmt.Errorf("verification token is %d bytes, more than fit in a TXT string", len(token))
+	}
+	return &Verification{Domain: strings.TrimSuffix(domain, "."), Name: "@", Token: token}, nil
+}
+
+// FQDN returns the fully-qualified name of the TXT record, without a
+// trailing dot.
+func (v *Verification) FQDN() string {
+	domain := strings.TrimSuffix(v.Domain, ".")
+	if v.Name == "@" || v.Name == "" {
+		return domain
+	}
+	return v.Name + "." + domain
+}
+
+// Instructions returns what a customer has to configure at their DNS
+// provider to complete the challenge.
+func (v *Verification) Instructions() string {
+	return fmt.Sprintf("Add a TXT record for %s with the value %q", v.FQDN(), v.Token)
+}
+
+// Check reports whether the token is visible in public DNS. lookup
+// returns the TXT values of a name; it defaults to a lookup with
+// Resolver. A name that does not exist is not an error.
+func (v *Verification) Check(ctx context.Context, lookup func(ctx context.Context, name string) ([]string, error)) (bool, error) {
+	if lookup == nil {
+		resolver := v.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		lookup = resolver.LookupTXT
+	}
+
+	values, err := lookup(ctx, v.FQDN()+".")
+	if dnsErr := (*net.DNSError)(nil); errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %w", v.FQDN(), err)
+	}
+
+	return slices.Contains(values, v.Token), nil
+}
+
+// Wait polls with Check every interval, one minute by default, until
+// the token is visible or ctx is done. Lookup failures are retried.
+func (v *Verification) Wait(ctx context.Context, lookup func(ctx context.Context, name string) ([]string, error), interval time.Duration) error {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if ok, err := v.Check(ctx, lookup); err == nil && ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s is not verified yet: %w", v.Domain, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishVerification writes the TXT record of v for a domain hosted in
+// zone, which must be the domain itself or one of its parents, and sets
+// v.RecordID to the record's ID. It does nothing if the record already
+// exists, and leaves other TXT records at the same name alone, but
+// fails if together they would no longer fit in a DNS answer over UDP.
+func (p *Provider) PublishVerification(ctx context.Context, zone string, v *Verification) (err error) {
+	ctx, done, err := p.beginOp(ctx, "PublishVerification", zone)
+	if err != nil {
+		return err
+	}
+	defer done(&err)
+
+	record, err := v.recordIn(zone)
+	if err != nil {
+		return err
+	}
+
+	existing, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	probe := record
+	probe.TTL = 0
+	if containsRecord(existing, probe) {
+		return nil
+	}
+
+	values := []string{record.Text}
+	for _, r := range existing {
+		if rr := r.RR(); rr.Type == "TXT" && strings.EqualFold(rr.Name, record.Name) {
+			values = append(values, rr.Data)
+		}
+	}
+	if size := txtAnswerSize(v.FQDN(), values); size > maxTXTAnswerSize {
+		return fmt.Errorf("TXT records of %s would take %d bytes with the verification token, more than the %d that fit in an answer over UDP", v.FQDN(), size, maxTXTAnswerSize)
+	}
+
+	created, err := p.AppendRecords(ctx, zone, []libdns.Record{record})
+	if err != nil {
+		return err
+	}
+	v.RecordID = recordID(created[0])
+	return nil
+}
+
+// RemoveVerification deletes the TXT record of v from zone once the
+// challenge is complete: the record PublishVerification created if
+// v.RecordID is set, or else any TXT record at its name holding the
+// token.
+func (p *Provider) RemoveVerification(ctx context.Context, zone string, v *Verification) error {
+	record, err := v.recordIn(zone)
+	if err != nil {
+		return err
+	}
+
+	record.TTL = 0
+	var target libdns.Record = record
+	if v.RecordID != 0 {
+		target = withRage4Data(record, Rage4Record{ID: v.RecordID})
+	}
+	if _, err := p.DeleteRecords(ctx, zone, []libdns.Record{target}); err != nil {
+		return err
+	}
+	v.RecordID = 0
+	return nil
+}
+
+// maxTXTAnswerSize is the largest DNS answer sent over UDP without
+// fragmentation, as recommended by DNS Flag Day 2020. TXT records
+// growing past it are only answered over TCP, which some verifiers do
+// not fall back to.
+const maxTXTAnswerSize = 1232
+
+// txtAnswerSize estimates the size of a DNS answer holding the TXT
+// records of fqdn with the given values
+func txtAnswerSize(fqdn string, values []string) int {
+	size := 12 + len(fqdn) + 2 + 4 // header and question
+	for _, value := range values {
+		chunks := max(1, (len(value)+254)/255)
+		size += 12 + chunks + len(value) // compressed name, type, class, TTL and length
+	}
+	return size
+}
+
+// recordIn returns the TXT record of v with its name relative to zone
+func (v *Verification) recordIn(zone string) (libdns.TXT, error) {
+	zoneName := strings.ToLower(strings.TrimSuffix(zone, "."))
+	fqdn := strings.ToLower(v.FQDN())
+	if fqdn != zoneName && !strings.HasSuffix(fqdn, "."+zoneName) {
+		return libdns.TXT{}, fmt.Errorf("%s is not in zone %s", v.FQDN(), zone)
+	}
+
+	return libdns.TXT{
+		Name: libdns.RelativeName(fqdn+".", zoneName+"."),
+		TTL:  5 * time.Minute,
+		Text: v.Token,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerificationCheck(t *testing.T) {
+	ctx := context.Background()
+	v, err := NewVerification("customer.example.", "")
+	if err != nil {
+		t.Fatalf("NewVerification failed: %v", err)
+	}
+	if v.FQDN() != "_rage4-verification.customer.example" || len(v.Token) != 32 {
+		t.Errorf("unexpected verification: %+v", v)
+	}
+	if !strings.Contains(v.Instructions(), v.Token) {
+		t.Errorf("instructions must include the token: %s", v.Instructions())
+	}
+
+	var published []string
+	lookup := func(ctx context.Context, name string) ([]string, error) {
+		if name != "_rage4-verification.customer.example." {
+			t.Errorf("unexpected lookup of %s", name)
+		}
+		if published == nil {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return published, nil
+	}
+
+	if ok, err := v.Check(ctx, lookup); ok || err != nil {
+		t.Errorf("expected missing record to be unverified, got %v, %v", ok, err)
+	}
+	published = []string{"unrelated"}
+	if ok, _ := v.Check(ctx, lookup); ok {
+		t.Error("expected other values to be ignored")
+	}
+	published = append(published, v.Token)
+	if err := v.Wait(ctx, lookup, time.Millisecond); err != nil {
+		t.Errorf("Wait failed: %v", err)
+	}
+}
+
+func TestPublishVerification(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	v := &Verification{Domain: "shop.example.com", Name: "_verify", Token: "token"}
+
+	for range 2 {
+		if err := p.PublishVerification(ctx, "example.com.", v); err != nil {
+			t.Fatalf("PublishVerification failed: %v", err)
+		}
+	}
+	records := f.domainRecords(1)
+	if len(records) != 1 || records[0].Name != "_verify.shop.example.com" || records[0].Content != "token" {
+		t.Errorf("expected a single verification record, got %+v", records)
+	}
+
+	if err := p.RemoveVerification(ctx, "example.com.", v); err != nil {
+		t.Fatalf("RemoveVerification failed: %v", err)
+	}
+	if records := f.domainRecords(1); len(records) != 0 {
+		t.Errorf("expected the record to be removed, got %+v", records)
+	}
+
+	if err := p.PublishVerification(ctx, "example.net.", v); err == nil {
+		t.Error("expected a domain outside the zone to be rejected")
+	}
+}
+
+func TestApexVerification(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: 3600})
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "TXT", Content: "MS=ms12345678", TTL: 3600})
+
+	v, err := NewApexVerification("example.com.", "MS=ms12345678")
+	if err != nil {
+		t.Fatalf("NewApexVerification failed: %v", err)
+	}
+	if v.FQDN() != "example.com" {
+		t.Errorf("unexpected name: %s", v.FQDN())
+	}
+
+	// a token published by hand is neither recreated nor removed by ID
+	if err := p.PublishVerification(ctx, "example.com.", v); err != nil || v.RecordID != 0 {
+		t.Fatalf("expected the existing token to be kept, got ID %d, %v", v.RecordID, err)
+	}
+
+	v.Token = "google-site-verification=abc"
+	if err := p.PublishVerification(ctx, "example.com.", v); err != nil {
+		t.Fatalf("PublishVerification failed: %v", err)
+	}
+	if records := f.domainRecords(1); len(records) != 3 || records[2].Name != "example.com" || records[2].ID != v.RecordID {
+		t.Errorf("expected the token to be added at the apex, got %+v", records)
+	}
+	if err := p.RemoveVerification(ctx, "example.com.", v); err != nil {
+		t.Fatalf("RemoveVerification failed: %v", err)
+	}
+	records := f.domainRecords(1)
+	if len(records) != 2 || records[0].Content != "v=spf1 -all" || records[1].Content != "MS=ms12345678" {
+		t.Errorf("expected only the added token to be removed, got %+v", records)
+	}
+
+	// TXT records that no longer fit in a UDP answer are refused
+	f.addRecord(1, Rage4Record{Name: "example.com", Type: "TXT", Content: strings.Repeat("x", 1100), TTL: 3600})
+	if err := p.PublishVerification(ctx, "example.com.", v); err == nil {
+		t.Error("expected oversized TXT records to be refused")
+	}
+	if _, err := NewApexVerification("example.com", strings.Repeat("x", 256)); err == nil {
+		t.Error("expected an oversized token to be rejected")
+	}
+}
@@ -0,0 +1,40 @@
+package libdnsrage4
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// WeightOf returns the round-robin weight of a record read from Rage4 or
+// set with WithWeight, and whether it has one. SRV records carry their
+// weight in their data instead.
+func WeightOf(record libdns.Record) (int, bool) {
+	data, _ := rage4Data(record)
+	weight := settingsOf(data).Weight
+	return weight, weight != 0
+}
+
+// WithWeight returns record with its round-robin weight set, keeping its
+// other Rage4 settings, for passing to AppendRecords or SetRecords.
+// Rage4 answers with the records of a set in proportion to their
+// weights; zero removes the weight, so that the record is answered as
+// often as unweighted ones. SRV records, whose weight is part of their
+// data, and records of types libdns has no struct for are rejected.
+func WithWeight(record libdns.Record, weight int) (libdns.Record, error) {
+	rr := record.RR()
+	if rr.Type == "SRV" {
+		return nil, fmt.Errorf("SRV records carry their weight in their data")
+	}
+	if weight < 0 {
+		return nil, fmt.Errorf("invalid weight %d", weight)
+	}
+
+	weighted, err := withSetting(record, settingWeight, func(data *Rage4Record) {
+		data.Weight = weight
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s records cannot be weighted: %w", rr.Type, err)
+	}
+	return weighted, nil
+}
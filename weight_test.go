@@ -0,0 +1,80 @@
+package libdnsrage4
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestWeightedRecords(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+
+	var desired []libdns.Record
+	for data, weight := range map[string]int{"192.0.2.1": 3, "192.0.2.2": 1} {
+		r, err := WithWeight(libdns.RR{Name: "www", Type: "A", Data: data, TTL: time.Hour}, weight)
+		if err != nil {
+			t.Fatalf("WithWeight failed: %v", err)
+		}
+		desired = append(desired, r)
+	}
+	srv := libdns.RR{Name: "_sip._udp", Type: "SRV", Data: "10 60 5060 sip.example.com", TTL: time.Hour}
+	if _, err := p.AppendRecords(ctx, "example.com.", append(desired, srv)); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	stored := make(map[string]int)
+	for _, r := range f.domainRecords(1) {
+		stored[r.Content] = r.Weight
+	}
+	if stored["192.0.2.1"] != 3 || stored["192.0.2.2"] != 1 || stored["60 5060 sip.example.com"] != 60 {
+		t.Errorf("unexpected weights sent: %v", stored)
+	}
+
+	records, err := p.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatalf("GetRecords failed: %v", err)
+	}
+	weights := make(map[string]int)
+	for _, r := range records {
+		if weight, ok := WeightOf(r); ok {
+			weights[r.RR().Data] = weight
+		}
+	}
+	if len(weights) != 2 || weights["192.0.2.1"] != 3 || weights["192.0.2.2"] != 1 {
+		t.Errorf("unexpected weights read: %v", weights)
+	}
+
+	// records already weighted as desired are left alone, others updated
+	updates := f.calls("UpdateRecord")
+	if _, err := p.SetRecords(ctx, "example.com.", desired); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if f.calls("UpdateRecord") != updates {
+		t.Error("expected records with the desired weights to be left alone")
+	}
+	reweighted, err := WithWeight(desired[0], 5)
+	if err != nil {
+		t.Fatalf("WithWeight failed: %v", err)
+	}
+	if _, err := p.SetRecords(ctx, "example.com.", []libdns.Record{reweighted, desired[1]}); err != nil {
+		t.Fatalf("SetRecords failed: %v", err)
+	}
+	if f.calls("UpdateRecord") != updates+1 {
+		t.Errorf("expected one update, got %d", f.calls("UpdateRecord")-updates)
+	}
+	for _, r := range f.domainRecords(1) {
+		if r.Content == reweighted.RR().Data && r.Weight != 5 {
+			t.Errorf("expected the weight to be updated, got %+v", r)
+		}
+	}
+
+	if _, err := WithWeight(srv, 5); err == nil {
+		t.Error("expected an error for an SRV record")
+	}
+	if _, err := WithWeight(desired[0], -1); err == nil {
+		t.Error("expected an error for a negative weight")
+	}
+}
@@ -0,0 +1,105 @@
+package libdnsrage4
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZoneDefaults are the settings applied to records created in a zone
+// that do not set them themselves.
+type ZoneDefaults struct {
+	// TTL is used for records created without a TTL, instead of one
+	// hour
+	TTL time.Duration `json:"ttl,omitempty"`
+
+	// GeoRegionID is the Rage4 geo region of records whose
+	// ProviderData does not name one
+	GeoRegionID int `json:"geo_region_id,omitempty"`
+
+	// Description tags records whose ProviderData has no description,
+	// for example with the team or system that owns them
+	Description string `json:"description,omitempty"`
+
+	// WebhookID is the Rage4 webhook notified of failover events of
+	// records whose ProviderData names none; see CreateWebhook
+	WebhookID int64 `json:"webhook_id,omitempty"`
+}
+
+// zoneDefaults returns the defaults configured for the zone, which may
+// be given with or without the trailing dot, with the provider's
+// RecordDescription unless they set a description
+func (p *Provider) zoneDefaults(zone string) ZoneDefaults {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var defaults ZoneDefaults
+	zone = strings.TrimSuffix(zone, ".")
+	for name, d := range p.ZoneDefaults {
+		if strings.EqualFold(strings.TrimSuffix(name, "."), zone) {
+			defaults = d
+			break
+		}
+	}
+	if defaults.Description == "" {
+		defaults.Description = p.RecordDescription
+	}
+	return defaults
+}
+
+// apply fills in the settings of r, converted from a record to create,
+// that neither the record nor its ProviderData set
+func (d ZoneDefaults) apply(r *Rage4Record) {
+	if r.TTL == 0 {
+		r.TTL = int(d.TTL.Seconds())
+	}
+	if r.GeoRegionID == 0 {
+		r.GeoRegionID = d.GeoRegionID
+	}
+	if r.Description == nil && d.Description != "" {
+		description := d.Description
+		r.Description = &description
+	}
+	if r.WebhookID == nil && d.WebhookID != 0 {
+		webhookID := d.WebhookID
+		r.WebhookID = &webhookID
+	}
+}
+
+// setRecordOptions adds the Rage4-specific settings of r, such as geo,
+// failover and weight, to the parameters of a CreateRecord or
+// UpdateRecord request
+func setRecordOptions(params url.Values, r Rage4Record) {
+	if r.GeoRegionID != 0 {
+		params.Set("geozone", strconv.Itoa(r.GeoRegionID))
+	}
+	if r.GeoLat != nil && r.GeoLong != nil {
+		params.Set("geolat", strconv.FormatFloat(*r.GeoLat, 'f', -1, 64))
+		params.Set("geolong", strconv.FormatFloat(*r.GeoLong, 'f', -1, 64))
+	}
+	if r.GeoAsNum != nil {
+		params.Set("geoasnum", strconv.FormatInt(*r.GeoAsNum, 10))
+	}
+	if r.FailoverEnabled {
+		params.Set("failover", "true")
+		if r.FailoverContent != nil {
+			params.Set("failovercontent", *r.FailoverContent)
+		}
+		if r.FailoverWithdraw {
+			params.Set("failoverwithdraw", "true")
+		}
+	}
+	if r.Description != nil {
+		params.Set("description", *r.Description)
+	}
+	if r.WebhookID != nil {
+		params.Set("webhookid", strconv.FormatInt(*r.WebhookID, 10))
+	}
+	if r.Weight != 0 {
+		params.Set("weight", strconv.Itoa(r.Weight))
+	}
+	if r.UDPLimit {
+		params.Set("udplimit", "true")
+	}
+}
@@ -0,0 +1,48 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestZoneDefaults(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t, "example.com.")
+	p.ZoneDefaults = map[string]ZoneDefaults{
+		"example.com": {TTL: TTLFiveMinutes, GeoRegionID: 7, Description: "team-web"},
+	}
+	owner := "team-mail"
+
+	_, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{
+		libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com",
+			ProviderData: Rage4Record{GeoRegionID: 3, Description: &owner}},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+
+	stored := f.domainRecords(1)
+	if len(stored) != 2 {
+		t.Fatalf("unexpected stored records: %+v", stored)
+	}
+	if www := stored[0]; www.TTL != 300 || www.GeoRegionID != 7 || www.Description == nil || *www.Description != "team-web" {
+		t.Errorf("expected the zone defaults to apply, got %+v", www)
+	}
+	if mx := stored[1]; mx.TTL != 3600 || mx.GeoRegionID != 3 || mx.Description == nil || *mx.Description != "team-mail" {
+		t.Errorf("expected the record's own settings to win, got %+v", mx)
+	}
+
+	f, p = newFakeRage4(t, "example.com.")
+	p.ZoneDefaults = map[string]ZoneDefaults{"example.net.": {TTL: TTLMinute}}
+	if _, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{libdns.RR{Name: "www", Type: "A", Data: "192.0.2.1"}}); err != nil {
+		t.Fatalf("AppendRecords failed: %v", err)
+	}
+	if stored := f.domainRecords(1); stored[0].TTL != 3600 || stored[0].GeoRegionID != 0 || stored[0].Description != nil {
+		t.Errorf("expected other zones' defaults to be ignored, got %+v", stored[0])
+	}
+}
@@ -0,0 +1,129 @@
+package libdnsrage4
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ZoneOptions are the settings of a zone created with CreateZone or
+// OnboardZone, covering the parameters of Rage4's domain creation
+// endpoints.
+type ZoneOptions struct {
+	// Email is the owner of the zone, to whom Rage4 sends its
+	// notifications; it defaults to the account email
+	Email string
+
+	// Vanity, if set, makes the zone use vanity name servers from the
+	// start, as with SetVanityNameservers. It cannot be combined with
+	// Subnet.
+	Vanity *VanityNameservers
+
+	// Records are created in the zone right after it, such as its apex
+	// A, MX and SPF records; Rage4 creates the apex NS records itself
+	Records []libdns.Record
+
+	// Subnet, if valid, makes the zone the reverse zone of an IPv4 or
+	// IPv6 prefix, which must be the one the zone name stands for, such
+	// as 192.0.2.0/24 for "2.0.192.in-addr.arpa."
+	Subnet netip.Prefix
+}
+
+// CreateZone creates a zone with the given options and returns the ID
+// of its domain. If opts.Records cannot all be created, the zone is
+// left in place and its ID is returned along with the error.
+func (p *Provider) CreateZone(ctx context.Context, zone string, opts ZoneOptions) (_ int64, err error) {
+	ctx, done, err := p.beginOp(ctx, "CreateZone", zone)
+	if err != nil {
+		return 0, err
+	}
+	defer done(&err)
+
+	return p.createZone(ctx, strings.TrimSuffix(zone, "."), opts)
+}
+
+// createZone creates a zone as set out by opts, with the endpoint for
+// its kind, and returns its domain ID
+func (p *Provider) createZone(ctx context.Context, zoneName string, opts ZoneOptions) (int64, error) {
+	email := opts.Email
+	if email == "" {
+		p.mu.RLock()
+		email = p.Email
+		p.mu.RUnlock()
+	}
+
+	method, params := "CreateRegularDomain", url.Values{}
+	switch {
+	case opts.Subnet.IsValid():
+		if opts.Vanity != nil {
+			return 0, fmt.Errorf("reverse zones are created with Rage4's regular name servers")
+		}
+		subnet := opts.Subnet.Masked()
+		if want, err := reverseZoneName(subnet); err != nil {
+			return 0, err
+		} else if !strings.EqualFold(zoneName, want) {
+			return 0, fmt.Errorf("the reverse zone of %s is %s, not %s", subnet, want, zoneName)
+		}
+		method = "CreateReverseDomain6"
+		if subnet.Addr().Is4() {
+			method = "CreateReverseDomain4"
+		}
+		params.Set("subnet", strconv.Itoa(subnet.Bits()))
+	case opts.Vanity != nil:
+		if opts.Vanity.Domain == "" {
+			return 0, fmt.Errorf("vanity name servers need a domain")
+		}
+		method, params = "CreateRegularDomainExt", opts.Vanity.params()
+	}
+	params.Set("name", zoneName)
+	params.Set("email", email)
+
+	var result CommonResponse
+	if err := p.post(ctx, method, params, &result); err != nil {
+		return 0, fmt.Errorf("failed to create zone: %w", err)
+	}
+
+	if len(opts.Records) > 0 {
+		if _, err := p.appendRecords(ctx, result.ID, zoneName, opts.Records); err != nil {
+			return result.ID, fmt.Errorf("failed to create records: %w", err)
+		}
+	}
+	return result.ID, nil
+}
+
+// reverseZoneName returns the name of the reverse zone of prefix, which
+// must end on a label boundary: a multiple of 8 bits for IPv4 and of 4
+// bits for IPv6
+func reverseZoneName(prefix netip.Prefix) (string, error) {
+	addr := prefix.Addr()
+	if addr.Is4() {
+		if prefix.Bits()%8 != 0 || prefix.Bits() == 0 || prefix.Bits() == 32 {
+			return "", fmt.Errorf("IPv4 reverse zones need a /8, /16 or /24 prefix, got %s", prefix)
+		}
+		b := addr.As4()
+		labels := []string{"in-addr.arpa"}
+		for _, octet := range b[:prefix.Bits()/8] {
+			labels = append([]string{strconv.Itoa(int(octet))}, labels...)
+		}
+		return strings.Join(labels, "."), nil
+	}
+
+	if prefix.Bits()%4 != 0 || prefix.Bits() == 0 {
+		return "", fmt.Errorf("IPv6 reverse zones need a prefix length that is a multiple of 4, got %s", prefix)
+	}
+	b := addr.As16()
+	labels := []string{"ip6.arpa"}
+	for i := range prefix.Bits() / 4 {
+		nibble := b[i/2] >> 4
+		if i%2 == 1 {
+			nibble = b[i/2] & 0xf
+		}
+		labels = append([]string{strconv.FormatUint(uint64(nibble), 16)}, labels...)
+	}
+	return strings.Join(labels, "."), nil
+}
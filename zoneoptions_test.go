@@ -0,0 +1,76 @@
+package libdnsrage4
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCreateZone(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t)
+
+	id, err := p.CreateZone(ctx, "example.org.", ZoneOptions{
+		Email:   "dns@example.org",
+		Vanity:  &VanityNameservers{Domain: "example.net"},
+		Records: []libdns.Record{libdns.RR{Name: "@", Type: "MX", Data: "10 mail.example.org"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateZone failed: %v", err)
+	}
+	if n := f.calls("CreateRegularDomainExt"); n != 1 {
+		t.Errorf("expected the zone to be created with vanity name servers, got %d calls", n)
+	}
+	if f.domains[0].Email != "dns@example.org" {
+		t.Errorf("unexpected owner email: %s", f.domains[0].Email)
+	}
+	ns, err := p.Nameservers(ctx, "example.org.")
+	if err != nil || !slices.Equal(ns, []string{"ns1.example.net", "ns2.example.net"}) {
+		t.Errorf("unexpected name servers: %v, %v", ns, err)
+	}
+	if prefix := f.domainSettings[id].Get("nsprefix"); prefix != "ns" {
+		t.Errorf("expected the default vanity prefix to be sent, got %q", prefix)
+	}
+	if records := f.domainRecords(id); len(records) != 3 || records[2].Type != "MX" {
+		t.Errorf("expected the MX record next to the NS records, got %+v", records)
+	}
+}
+
+func TestCreateReverseZone(t *testing.T) {
+	ctx := context.Background()
+	f, p := newFakeRage4(t)
+
+	for zone, subnet := range map[string]string{
+		"2.0.192.in-addr.arpa.":       "192.0.2.0/24",
+		"51.198.in-addr.arpa":         "198.51.100.7/16",
+		"8.b.d.0.1.0.0.2.ip6.arpa.":   "2001:db8::/32",
+		"1.8.b.d.0.1.0.0.2.ip6.arpa.": "2001:db8:1000::/36",
+	} {
+		id, err := p.CreateZone(ctx, zone, ZoneOptions{Subnet: netip.MustParsePrefix(subnet)})
+		if err != nil {
+			t.Errorf("CreateZone(%s, %s) failed: %v", zone, subnet, err)
+			continue
+		}
+		prefix := netip.MustParsePrefix(subnet)
+		if got := f.domainSettings[id].Get("subnet"); got != strconv.Itoa(prefix.Bits()) {
+			t.Errorf("unexpected subnet for %s: %s", zone, got)
+		}
+	}
+	if f.calls("CreateReverseDomain4") != 2 || f.calls("CreateReverseDomain6") != 2 {
+		t.Errorf("unexpected endpoints: %v", f.requests)
+	}
+
+	for zone, subnet := range map[string]string{
+		"3.0.192.in-addr.arpa.":   "192.0.2.0/24",
+		"0.192.in-addr.arpa.":     "192.0.2.0/23",
+		"1.2.0.192.in-addr.arpa.": "192.0.2.1/32",
+	} {
+		if _, err := p.CreateZone(ctx, zone, ZoneOptions{Subnet: netip.MustParsePrefix(subnet)}); err == nil {
+			t.Errorf("expected %s for %s to be rejected", zone, subnet)
+		}
+	}
+}